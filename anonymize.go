@@ -0,0 +1,81 @@
+package mkey
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"sync"
+	"time"
+)
+
+// Anonymizer replaces IDs for analytics exports: each ID keeps its timestamp
+// bucket, while the rest of the timestamp and the node and step are replaced
+// by a keyed pseudo-random function of the ID. Exports keep their time
+// distribution and stay joinable, since one ID always maps to the same
+// value, but cannot be linked back to production records without the key.
+//
+// Distinct IDs within a bucket may map to the same value; the chance falls
+// with the bucket size, which spreads them further.
+type Anonymizer struct {
+	layout   Layout
+	bucketMs int64
+
+	mu  sync.Mutex
+	mac hash.Hash
+}
+
+// NewAnonymizer creates an anonymizer for IDs of layout l that keeps
+// timestamps at granularity, at least one millisecond. key should be at
+// least 16 random bytes and kept out of the export.
+func NewAnonymizer(key []byte, l Layout, granularity time.Duration) (*Anonymizer, error) {
+	if len(key) < 16 {
+		return nil, errors.New("key must be at least 16 bytes")
+	}
+	if err := l.Validate(); err != nil {
+		return nil, err
+	}
+	if granularity < time.Millisecond {
+		return nil, errors.New("granularity must be at least 1ms")
+	}
+
+	return &Anonymizer{
+		layout:   l,
+		bucketMs: granularity.Milliseconds(),
+		mac:      hmac.New(sha256.New, key),
+	}, nil
+}
+
+// Anonymize returns the anonymized form of id
+func (a *Anonymizer) Anonymize(id ID) ID {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(id))
+
+	a.mu.Lock()
+	a.mac.Reset()
+	a.mac.Write(buf[:])
+	prf := binary.BigEndian.Uint64(a.mac.Sum(nil))
+	a.mu.Unlock()
+
+	l := a.layout
+	shift := l.TimeShift()
+	t := (int64(id) >> shift) & l.TimeMask()
+	base := t - t%a.bucketMs
+
+	// Everything above the timestamp (priority) is kept as is
+	high := int64(id) &^ (l.TimeMask()<<shift | (1<<shift - 1))
+	span := uint64(min(a.bucketMs, l.TimeMask()-base+1)) << shift
+	return ID(high | base<<shift | int64(prf%span))
+}
+
+// Anonymize anonymizes an ID of the default layout at millisecond
+// granularity; see Anonymizer for other layouts and coarser buckets. It
+// returns 0 if key is shorter than 16 bytes.
+func Anonymize(id ID, key []byte) ID {
+	a, err := NewAnonymizer(key, DefaultLayout(), time.Millisecond)
+	if err != nil {
+		return 0
+	}
+	return a.Anonymize(id)
+}
@@ -0,0 +1,82 @@
+package mkey
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestAnonymizer(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 16)
+	l := DefaultLayout()
+	a, err := NewAnonymizer(key, l, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, _ := NewNode(5)
+
+	bucket := func(id ID) int64 { return (l.Time(id) - l.Epoch) / time.Minute.Milliseconds() }
+	seen := make(map[ID]bool)
+	for range 1000 {
+		id := n.Generate()
+		anon := a.Anonymize(id)
+		if anon == id {
+			t.Fatalf("Anonymize(%v) returned the ID", id)
+		}
+		if anon != a.Anonymize(id) {
+			t.Fatal("Anonymize is not deterministic")
+		}
+		if bucket(anon) != bucket(id) {
+			t.Fatalf("bucket %d, want %d", bucket(anon), bucket(id))
+		}
+		seen[anon] = true
+	}
+	if len(seen) < 999 {
+		t.Fatalf("only %d distinct values for 1000 IDs", len(seen))
+	}
+
+	other, _ := NewAnonymizer(bytes.Repeat([]byte{8}, 16), l, time.Minute)
+	id := n.Generate()
+	if other.Anonymize(id) == a.Anonymize(id) {
+		t.Fatal("different keys gave the same value")
+	}
+}
+
+func TestAnonymizerPriority(t *testing.T) {
+	l := DefaultLayout()
+	l.StepBits = 10
+	l.PriorityBits = 2
+	a, err := NewAnonymizer(bytes.Repeat([]byte{1}, 16), l, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := l.Compose(time.Now(), 3, 4)
+	id |= 2 << (63 - l.PriorityBits)
+	anon := a.Anonymize(id)
+	if l.Priority(anon) != 2 || l.Time(anon) != l.Time(id) {
+		t.Fatalf("priority %d time %d, want 2 and %d", l.Priority(anon), l.Time(anon), l.Time(id))
+	}
+}
+
+func TestAnonymize(t *testing.T) {
+	n, _ := NewNode(1)
+	id := n.Generate()
+	key := bytes.Repeat([]byte{7}, 16)
+	got := Anonymize(id, key)
+	if l := DefaultLayout(); got == id || l.Time(got) != l.Time(id) {
+		t.Fatalf("Anonymize = %v for %v", got, id)
+	}
+	if Anonymize(id, key[:15]) != 0 {
+		t.Fatal("short key did not return 0")
+	}
+
+	for name, f := range map[string]func() (*Anonymizer, error){
+		"short key":   func() (*Anonymizer, error) { return NewAnonymizer(key[:15], DefaultLayout(), time.Second) },
+		"granularity": func() (*Anonymizer, error) { return NewAnonymizer(key, DefaultLayout(), time.Microsecond) },
+		"layout":      func() (*Anonymizer, error) { return NewAnonymizer(key, Layout{StepBits: 30}, time.Second) },
+	} {
+		if _, err := f(); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package mkey
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// snapshotSep separates the name and version in a Snapshot cache key
+const snapshotSep = "@"
+
+// Snapshot treats an ID as a logical version: data cached as of a Snapshot
+// reflects every write whose ID is at or below it. A client that remembers
+// the ID of its last write can then be served any cache entry whose snapshot
+// covers that ID and still read its own writes. IDs only order like this
+// under layouts without PriorityBits.
+type Snapshot ID
+
+// AsOf returns the snapshot covering id and every ID issued before it
+func AsOf(id ID) Snapshot {
+	return Snapshot(id)
+}
+
+// AsOfTime returns the snapshot covering every ID of layout l with a
+// timestamp at or before t, e.g. for a cache rebuilt from a point in time
+func AsOfTime(l Layout, t time.Time) Snapshot {
+	return Snapshot(l.MaxID(t))
+}
+
+// ID returns the snapshot as an ID
+func (s Snapshot) ID() ID {
+	return ID(s)
+}
+
+// Covers reports whether data as of s reflects the write that issued id
+func (s Snapshot) Covers(id ID) bool {
+	return id <= ID(s)
+}
+
+// Satisfies reports whether data as of s is fresh enough for a reader that
+// requires at least min, e.g. the snapshot of its last write
+func (s Snapshot) Satisfies(min Snapshot) bool {
+	return s >= min
+}
+
+// Compare returns -1, 0 or +1 as s is older than, equal to or newer than o
+func (s Snapshot) Compare(o Snapshot) int {
+	switch {
+	case s < o:
+		return -1
+	case s > o:
+		return 1
+	}
+	return 0
+}
+
+// Later returns the newer of s and o, for merging the versions of the
+// inputs a cached value was computed from
+func (s Snapshot) Later(o Snapshot) Snapshot {
+	return max(s, o)
+}
+
+// CacheKey returns name@<version> with the version in fixed-width Base62,
+// so keys of one name sort by version and the version can be recovered with
+// ParseCacheKey
+func (s Snapshot) CacheKey(name string) string {
+	return name + snapshotSep + formatBase62(uint64(s), Base62Width)
+}
+
+// ParseCacheKey splits a key made by Snapshot.CacheKey into its name and
+// snapshot. Names may themselves contain '@'; the version follows the last one.
+func ParseCacheKey(key string) (string, Snapshot, error) {
+	i := strings.LastIndex(key, snapshotSep)
+	if i < 0 {
+		return "", 0, errors.New("cache key has no snapshot version")
+	}
+	v, err := parseBase62([]byte(key[i+len(snapshotSep):]))
+	if err != nil {
+		return "", 0, err
+	}
+	return key[:i], Snapshot(v), nil
+}
@@ -0,0 +1,73 @@
+package mkey
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSnapshotOrder(t *testing.T) {
+	n, _ := NewNode(1)
+	write := n.Generate()
+	s := AsOf(write)
+	if s.ID() != write {
+		t.Fatalf("ID() = %d, want %d", s.ID(), write)
+	}
+	if !s.Covers(write) || s.Covers(n.Generate()) {
+		t.Fatal("a snapshot must cover its own ID and not later ones")
+	}
+
+	later := AsOf(n.Generate())
+	if !later.Satisfies(s) || s.Satisfies(later) {
+		t.Fatal("only the newer snapshot satisfies the older")
+	}
+	if s.Compare(later) != -1 || later.Compare(s) != 1 || s.Compare(s) != 0 {
+		t.Fatalf("Compare = %d, %d, %d", s.Compare(later), later.Compare(s), s.Compare(s))
+	}
+	if s.Later(later) != later || later.Later(s) != later {
+		t.Fatal("Later did not pick the newer snapshot")
+	}
+}
+
+func TestAsOfTime(t *testing.T) {
+	l := DefaultLayout()
+	at := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	s := AsOfTime(l, at)
+
+	last, _ := l.Compose(at, l.MaxNode(), l.MaxStep())
+	next, _ := l.Compose(at.Add(time.Millisecond), 0, 0)
+	if !s.Covers(last) {
+		t.Fatal("snapshot does not cover the last ID of its millisecond")
+	}
+	if s.Covers(next) {
+		t.Fatal("snapshot covers an ID of the next millisecond")
+	}
+}
+
+func TestSnapshotCacheKey(t *testing.T) {
+	snaps := []Snapshot{0, 1, 61, 62, 1 << 40, 1<<63 - 1}
+	keys := make([]string, len(snaps))
+	for i, s := range snaps {
+		keys[i] = s.CacheKey("user@42")
+		name, back, err := ParseCacheKey(keys[i])
+		if err != nil {
+			t.Fatalf("ParseCacheKey(%q): %v", keys[i], err)
+		}
+		if name != "user@42" || back != s {
+			t.Fatalf("ParseCacheKey(%q) = %q, %d, want user@42, %d", keys[i], name, back, s)
+		}
+		if len(keys[i]) != len("user@42@")+Base62Width {
+			t.Fatalf("CacheKey %q is not fixed width", keys[i])
+		}
+	}
+	if !slices.IsSorted(keys) {
+		t.Fatalf("cache keys %v do not sort by version", keys)
+	}
+
+	for _, key := range []string{"user", "user@", "user@!!", strings.Repeat("z", 20)} {
+		if _, _, err := ParseCacheKey(key); err == nil {
+			t.Errorf("ParseCacheKey(%q) accepted a key without a valid version", key)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package mkey
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strconv"
+)
+
+// NodeIDEnv is the environment variable NewNodeAuto reads an explicit node ID from
+const NodeIDEnv = "MKEY_NODE_ID"
+
+// ErrNodeUnset is returned in strict mode when the node ID is left at zero
+var ErrNodeUnset = errors.New("node ID is unset (0) in strict mode")
+
+// NewNodeAuto creates a strict node with the default layout and a node ID
+// taken from MKEY_NODE_ID or, if that is not set, derived from the host name.
+// Derived IDs are never zero but may collide between hosts; set MKEY_NODE_ID
+// wherever collisions cannot be tolerated.
+func NewNodeAuto() (*Node, error) {
+	cfg := NewConfig()
+	cfg.Strict = true
+
+	id, err := AutoNodeID(cfg.NodeBits)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Node = id
+	return NewNodeWithConfig(cfg)
+}
+
+// AutoNodeID returns the node ID NewNodeAuto would use for a layout with the given node bits
+func AutoNodeID(bits uint8) (int64, error) {
+	if bits == 0 || bits > MaxNodeBits {
+		return 0, fmt.Errorf("bits must be between 1 and %d", MaxNodeBits)
+	}
+	max := int64(-1 ^ (-1 << bits))
+
+	if v := os.Getenv(NodeIDEnv); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id < 1 || id > max {
+			return 0, fmt.Errorf("%s must be between 1 and %d, got %q", NodeIDEnv, max, v)
+		}
+		return id, nil
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		return 0, fmt.Errorf("deriving node ID: %w", err)
+	}
+	h := fnv.New64a()
+	h.Write([]byte(host))
+
+	// Map into [1, max] so a derived ID is never the unset value
+	return 1 + int64(h.Sum64()%uint64(max)), nil
+}
@@ -0,0 +1,60 @@
+package mkey
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStrict(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Strict = true
+	if _, err := NewNodeWithConfig(cfg); !errors.Is(err, ErrNodeUnset) {
+		t.Fatalf("strict node 0: err = %v, want ErrNodeUnset", err)
+	}
+	cfg.Node = 1
+	if _, err := NewNodeWithConfig(cfg); err != nil {
+		t.Fatalf("strict node 1: %v", err)
+	}
+}
+
+func TestAutoNodeIDEnv(t *testing.T) {
+	t.Setenv(NodeIDEnv, "17")
+	if id, err := AutoNodeID(10); err != nil || id != 17 {
+		t.Fatalf("AutoNodeID = %d, %v; want 17", id, err)
+	}
+	n, err := NewNodeAuto()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := n.Layout().NodeID(n.Generate()); got != 17 {
+		t.Fatalf("NewNodeAuto node = %d, want 17", got)
+	}
+
+	for _, v := range []string{"0", "-1", "1024", "abc"} {
+		t.Setenv(NodeIDEnv, v)
+		if _, err := AutoNodeID(10); err == nil {
+			t.Errorf("%s=%q accepted", NodeIDEnv, v)
+		}
+	}
+}
+
+func TestAutoNodeIDHost(t *testing.T) {
+	t.Setenv(NodeIDEnv, "")
+	for _, bits := range []uint8{1, 4, 10, MaxNodeBits} {
+		a, err := AutoNodeID(bits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b, _ := AutoNodeID(bits); a != b {
+			t.Fatalf("derived ID not stable: %d, %d", a, b)
+		}
+		if a < 1 || a > int64(1)<<bits-1 {
+			t.Fatalf("AutoNodeID(%d) = %d, out of range", bits, a)
+		}
+	}
+	for _, bits := range []uint8{0, MaxNodeBits + 1} {
+		if _, err := AutoNodeID(bits); err == nil {
+			t.Errorf("AutoNodeID(%d) succeeded", bits)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package mkey
+
+import (
+	"testing"
+	"time"
+)
+
+// barrierConfigs are the layouts and options under which IDs issued before a
+// barrier may carry fields or step values that sort above plain IDs
+var barrierConfigs = map[string]func(*Config){
+	"plain":           func(*Config) {},
+	"ttl":             func(c *Config) { c.ExpiryBits, c.NodeBits = 8, 2 },
+	"shard":           func(c *Config) { c.ShardBits, c.NodeBits = 4, 6 },
+	"scramble":        func(c *Config) { c.ScrambleSteps = true },
+	"randomStepStart": func(c *Config) { c.RandomStepStart = true },
+	"priority":        func(c *Config) { c.PriorityBits, c.NodeBits = 2, 8 },
+}
+
+// issueMixed issues a few IDs in every way the configuration supports and
+// returns the highest
+func issueMixed(t *testing.T, n *Node, cfg *Config) ID {
+	t.Helper()
+	high := n.Generate()
+	note := func(id ID, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		high = max(high, id)
+	}
+	for range 3 {
+		note(n.Generate(), nil)
+	}
+	if cfg.ExpiryBits > 0 {
+		note(n.GenerateWithTTL(time.Minute))
+	}
+	if cfg.ShardBits > 0 {
+		batches, err := n.GenerateBatchSharded(map[int64]int{n.layout.MaxShard(): 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, id := range batches[n.layout.MaxShard()] {
+			note(id, nil)
+		}
+	}
+	if cfg.PriorityBits > 0 {
+		note(n.GenerateWithPriority(1))
+	}
+	ids, err := n.GenerateBatch(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range ids {
+		note(id, nil)
+	}
+	return high
+}
+
+func TestBarrierAboveIssued(t *testing.T) {
+	for name, opt := range barrierConfigs {
+		t.Run(name, func(t *testing.T) {
+			cfg := NewConfig()
+			cfg.Node = 3
+			opt(cfg)
+			n, err := NewNodeWithConfig(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			prev := ID(0)
+			for i := range 200 {
+				high := issueMixed(t, n, cfg)
+				b := n.Barrier()
+				if b <= high {
+					t.Fatalf("round %d: barrier %d <= issued ID %d", i, b, high)
+				}
+				if b <= prev {
+					t.Fatalf("round %d: barrier %d <= previous barrier %d", i, b, prev)
+				}
+				if got := n.layout.NodeID(b); got != cfg.Node {
+					t.Fatalf("barrier node = %d, want %d", got, cfg.Node)
+				}
+				if cfg.PriorityBits == 0 {
+					if next := n.Generate(); next <= b {
+						t.Fatalf("round %d: ID %d issued after barrier %d sorts below it", i, next, b)
+					}
+				}
+				prev = b
+			}
+		})
+	}
+}
+
+func TestBarrierUnique(t *testing.T) {
+	n, err := NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[ID]bool)
+	for range 10000 {
+		for _, id := range []ID{n.Barrier(), n.Generate()} {
+			if seen[id] {
+				t.Fatalf("duplicate ID %d", id)
+			}
+			seen[id] = true
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package mkey
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+const bloomMagic = "MKBF\x01"
+
+// bloomMaxHashes bounds the hash count; NewBloomFilter stays below it for
+// any representable fpRate, and decoding rejects more, since every Add and
+// Has loops k times
+const bloomMaxHashes = 2048
+
+// BloomFilter is a compact "have I seen this ID" set with false positives but
+// no false negatives. It hashes through Hash64, because raw IDs concentrate
+// their entropy in a few bits. A BloomFilter is not safe for concurrent Add.
+type BloomFilter struct {
+	bits []uint64
+	m    uint64
+	k    uint32
+}
+
+// NewBloomFilter sizes a filter for n IDs at false positive rate fpRate
+func NewBloomFilter(n int, fpRate float64) *BloomFilter {
+	if n < 1 {
+		n = 1
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 0.01
+	}
+
+	m := uint64(math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	k := uint32(math.Round(float64(m) / float64(n) * math.Ln2))
+	k = min(max(k, 1), bloomMaxHashes)
+	return &BloomFilter{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}
+}
+
+// locations derives the bit positions for id by double hashing
+func (b *BloomFilter) locations(id ID) (uint64, uint64) {
+	h1 := id.Hash64()
+	h2 := (ID(h1).Hash64()) | 1
+	return h1, h2
+}
+
+// Add inserts id
+func (b *BloomFilter) Add(id ID) {
+	h1, h2 := b.locations(id)
+	for i := uint32(0); i < b.k; i++ {
+		pos := (h1 + uint64(i)*h2) % b.m
+		b.bits[pos/64] |= 1 << (pos % 64)
+	}
+}
+
+// Has reports whether id may have been added; false means it definitely was not
+func (b *BloomFilter) Has(id ID) bool {
+	h1, h2 := b.locations(id)
+	for i := uint32(0); i < b.k; i++ {
+		pos := (h1 + uint64(i)*h2) % b.m
+		if b.bits[pos/64]&(1<<(pos%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, so filters can be
+// shipped between processes
+func (b *BloomFilter) MarshalBinary() ([]byte, error) {
+	out := make([]byte, 0, len(bloomMagic)+12+8*len(b.bits))
+	out = append(out, bloomMagic...)
+	out = binary.BigEndian.AppendUint32(out, b.k)
+	out = binary.BigEndian.AppendUint64(out, b.m)
+	for _, w := range b.bits {
+		out = binary.BigEndian.AppendUint64(out, w)
+	}
+	return out, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+func (b *BloomFilter) UnmarshalBinary(data []byte) error {
+	header := len(bloomMagic) + 12
+	if len(data) < header || string(data[:len(bloomMagic)]) != bloomMagic {
+		return errors.New("invalid bloom filter encoding")
+	}
+	k := binary.BigEndian.Uint32(data[len(bloomMagic):])
+	m := binary.BigEndian.Uint64(data[len(bloomMagic)+4:])
+	// Derive the word count from the payload, so m cannot size anything
+	body := len(data) - header
+	if body%8 != 0 {
+		return errors.New("invalid bloom filter encoding")
+	}
+	words := uint64(body / 8)
+	if k == 0 || k > bloomMaxHashes || m == 0 || m > 64*words || m <= 64*(words-1) {
+		return errors.New("invalid bloom filter encoding")
+	}
+
+	bits := make([]uint64, words)
+	for i := range bits {
+		bits[i] = binary.BigEndian.Uint64(data[header+8*i:])
+	}
+	b.bits, b.m, b.k = bits, m, k
+	return nil
+}
@@ -0,0 +1,114 @@
+package mkey
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestBloomFilterNoFalseNegatives(t *testing.T) {
+	n, err := NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := NewBloomFilter(1000, 0.01)
+	ids, err := n.GenerateBatch(1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range ids {
+		b.Add(id)
+	}
+	for _, id := range ids {
+		if !b.Has(id) {
+			t.Fatalf("added %d not found", id)
+		}
+	}
+
+	// The false positive rate should be near the requested one
+	var fp int
+	for range 10000 {
+		if b.Has(n.Generate()) {
+			fp++
+		}
+	}
+	if fp > 300 {
+		t.Fatalf("%d false positives in 10000, want about 100", fp)
+	}
+}
+
+func TestBloomFilterRoundTrip(t *testing.T) {
+	for _, fpRate := range []float64{0.5, 0.01, 1e-9, 1e-300} {
+		b := NewBloomFilter(100, fpRate)
+		for i := range 100 {
+			b.Add(ID(i) << 22)
+		}
+		data, err := b.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got BloomFilter
+		if err := got.UnmarshalBinary(data); err != nil {
+			t.Fatalf("fpRate %g: %v", fpRate, err)
+		}
+		if got.m != b.m || got.k != b.k {
+			t.Fatalf("fpRate %g: got m=%d k=%d, want m=%d k=%d", fpRate, got.m, got.k, b.m, b.k)
+		}
+		for i := range 100 {
+			if !got.Has(ID(i) << 22) {
+				t.Fatalf("fpRate %g: decoded filter lost %d", fpRate, i)
+			}
+		}
+	}
+}
+
+func bloomEncoding(k uint32, m uint64, words int) []byte {
+	data := []byte(bloomMagic)
+	data = binary.BigEndian.AppendUint32(data, k)
+	data = binary.BigEndian.AppendUint64(data, m)
+	return append(data, make([]byte, 8*words)...)
+}
+
+func TestBloomFilterUnmarshalRejects(t *testing.T) {
+	tests := map[string][]byte{
+		"empty":        nil,
+		"bad magic":    []byte("MKBX\x01\x00\x00\x00\x01\x00\x00\x00\x00\x00\x00\x00\x40"),
+		"zero k":       bloomEncoding(0, 64, 1),
+		"huge k":       bloomEncoding(1<<32-1, 64, 1),
+		"zero m":       bloomEncoding(3, 0, 0),
+		"max m":        bloomEncoding(3, 1<<64-1, 0),
+		"max m words":  bloomEncoding(3, 1<<64-1, 1),
+		"m too large":  bloomEncoding(3, 65, 1),
+		"m too small":  bloomEncoding(3, 64, 2),
+		"partial word": append(bloomEncoding(3, 64, 1), 0),
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			var b BloomFilter
+			if err := b.UnmarshalBinary(data); err == nil {
+				t.Fatal("accepted invalid encoding")
+			}
+		})
+	}
+}
+
+func FuzzBloomFilterUnmarshal(f *testing.F) {
+	valid, _ := NewBloomFilter(10, 0.01).MarshalBinary()
+	f.Add(valid)
+	f.Add(bloomEncoding(3, 1<<64-1, 0))
+	f.Add(bloomEncoding(3, 1<<63+1, 1))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var b BloomFilter
+		if err := b.UnmarshalBinary(data); err != nil {
+			return
+		}
+		// A decoded filter must encode back to the input and be usable
+		out, _ := b.MarshalBinary()
+		if string(out) != string(data) {
+			t.Fatal("round trip changed the encoding")
+		}
+		b.Add(1)
+		if !b.Has(1) {
+			t.Fatal("decoded filter lost an added ID")
+		}
+	})
+}
@@ -0,0 +1,149 @@
+package mkey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrBufferClosed is returned by BufferedNode.Next once the node is closed
+// and its buffer drained
+var ErrBufferClosed = errors.New("buffered node is closed")
+
+// BufferConfig sizes a BufferedNode
+type BufferConfig struct {
+	// Size is the number of IDs the buffer holds, 1024 if 0
+	Size int
+
+	// LowWatermark is the fill level at or below which the producer starts
+	// refilling, Size/4 if 0
+	LowWatermark int
+
+	// HighWatermark is the fill level the producer refills to, Size if 0
+	HighWatermark int
+}
+
+// BufferedNode keeps a buffer of pre-generated IDs filled by a background
+// goroutine, so latency-sensitive callers get an ID with a channel receive.
+// Buffered IDs carry the time they were generated, not the time they are
+// taken, so they sort by generation and may be up to a buffer's worth of
+// generation time old.
+type BufferedNode struct {
+	node      *Node
+	ids       chan ID
+	low, high int
+
+	refill chan struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewBufferedNode starts a producer filling a buffer from node. Call Close
+// to stop it.
+func NewBufferedNode(node *Node, cfg BufferConfig) (*BufferedNode, error) {
+	size := cfg.Size
+	if size == 0 {
+		size = 1024
+	}
+	low, high := cfg.LowWatermark, cfg.HighWatermark
+	if low == 0 {
+		low = size / 4
+	}
+	if high == 0 {
+		high = size
+	}
+	if size < 0 || low < 0 || high > size || low >= high {
+		return nil, fmt.Errorf("watermarks must satisfy 0 <= low < high <= size, got %d, %d, %d", low, high, size)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &BufferedNode{
+		node:   node,
+		ids:    make(chan ID, size),
+		low:    low,
+		high:   high,
+		refill: make(chan struct{}, 1),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go b.produce(ctx)
+	return b, nil
+}
+
+func (b *BufferedNode) produce(ctx context.Context) {
+	defer close(b.done)
+	defer close(b.ids)
+
+	for {
+		for need := b.high - len(b.ids); need > 0; need = b.high - len(b.ids) {
+			batch, err := b.node.GenerateBatchContext(ctx, need)
+			for _, id := range batch {
+				select {
+				case b.ids <- id:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				if ctx.Err() != nil || errors.Is(err, ErrClosed) {
+					return
+				}
+				// Wait out clock regressions like Generate does
+				time.Sleep(time.Millisecond)
+			}
+		}
+
+		select {
+		case <-b.refill:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Next returns a buffered ID, blocking while the buffer is empty. After
+// Close it drains the buffer and then returns ErrBufferClosed.
+func (b *BufferedNode) Next() (ID, error) {
+	id, ok := <-b.ids
+	if !ok {
+		return 0, ErrBufferClosed
+	}
+	if len(b.ids) <= b.low {
+		select {
+		case b.refill <- struct{}{}:
+		default:
+		}
+	}
+	return id, nil
+}
+
+// Len returns the number of IDs currently buffered
+func (b *BufferedNode) Len() int {
+	return len(b.ids)
+}
+
+// Size returns the buffer capacity
+func (b *BufferedNode) Size() int {
+	return cap(b.ids)
+}
+
+// Watermarks returns the fill levels at which refilling starts and stops
+func (b *BufferedNode) Watermarks() (low, high int) {
+	return b.low, b.high
+}
+
+// Node returns the node IDs are generated from
+func (b *BufferedNode) Node() *Node {
+	return b.node
+}
+
+// Close stops the producer and waits for it to exit. IDs still buffered can
+// be taken with Next. It is safe to call Close more than once.
+func (b *BufferedNode) Close() error {
+	b.once.Do(b.cancel)
+	<-b.done
+	return nil
+}
@@ -0,0 +1,110 @@
+package mkey
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// waitLen waits until the buffer holds want IDs
+func waitLen(t *testing.T, b *BufferedNode, want int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for b.Len() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("buffer holds %d IDs, want %d", b.Len(), want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBufferedNode(t *testing.T) {
+	n, _ := NewNode(1)
+	b, err := NewBufferedNode(n, BufferConfig{Size: 100, LowWatermark: 20, HighWatermark: 80})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	if b.Size() != 100 || b.Node() != n {
+		t.Fatalf("Size %d, Node %p", b.Size(), b.Node())
+	}
+	waitLen(t, b, 80)
+
+	// taking IDs above the low watermark does not trigger a refill
+	var prev ID
+	for range 50 {
+		id, err := b.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id <= prev {
+			t.Fatalf("buffered ID %v does not follow %v", id, prev)
+		}
+		prev = id
+	}
+	time.Sleep(10 * time.Millisecond)
+	if b.Len() != 30 {
+		t.Fatalf("buffer refilled above the low watermark: %d", b.Len())
+	}
+
+	for range 10 {
+		if _, err := b.Next(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitLen(t, b, 80)
+
+	// IDs generated directly on the node after buffering sort after the buffer
+	if id := n.Generate(); id <= prev {
+		t.Fatal("node went back while the buffer filled")
+	}
+}
+
+func TestBufferedNodeClose(t *testing.T) {
+	n, _ := NewNode(1)
+	b, err := NewBufferedNode(n, BufferConfig{Size: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if low, high := b.Watermarks(); low != 2 || high != 10 {
+		t.Fatalf("default watermarks %d, %d; want 2, 10", low, high)
+	}
+	waitLen(t, b, 10)
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	b.Close()
+
+	for i := range 10 {
+		if _, err := b.Next(); err != nil {
+			t.Fatalf("buffered ID %d lost on Close: %v", i, err)
+		}
+	}
+	if _, err := b.Next(); !errors.Is(err, ErrBufferClosed) {
+		t.Fatalf("Next on a drained buffer: err = %v", err)
+	}
+
+	def, err := NewBufferedNode(n, BufferConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer def.Close()
+	if low, high := def.Watermarks(); def.Size() != 1024 || low != 256 || high != 1024 {
+		t.Fatalf("defaults: size %d, watermarks %d, %d", def.Size(), low, high)
+	}
+}
+
+func TestBufferedNodeInvalid(t *testing.T) {
+	n, _ := NewNode(1)
+	for _, cfg := range []BufferConfig{
+		{Size: -1},
+		{Size: 10, HighWatermark: 11},
+		{Size: 10, LowWatermark: 5, HighWatermark: 5},
+		{Size: 10, LowWatermark: -1},
+	} {
+		if b, err := NewBufferedNode(n, cfg); err == nil {
+			b.Close()
+			t.Errorf("%+v accepted", cfg)
+		}
+	}
+}
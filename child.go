@@ -0,0 +1,99 @@
+package mkey
+
+import (
+	"errors"
+	"time"
+)
+
+// DefaultChildWindow is how long after its creation a parent accepts child
+// IDs when Config.ChildWindow is 0
+const DefaultChildWindow = time.Minute
+
+var (
+	// ErrNoChildBits is returned by Child when the layout has no ChildBits
+	ErrNoChildBits = errors.New("layout has no child bits")
+
+	// ErrNotParent is returned by Child for IDs this node instance did not
+	// issue with Generate, or that are older than the child window
+	ErrNotParent = errors.New("ID is not a parent of this node")
+
+	// ErrChildrenExhausted is returned by Child once a parent has
+	// MaxChild children
+	ErrChildrenExhausted = errors.New("parent has no child IDs left")
+)
+
+// ChildMask returns the mask of the child component
+func (l Layout) ChildMask() int64 {
+	return -1 ^ (-1 << l.ChildBits)
+}
+
+// MaxChild returns how many children a parent can have
+func (l Layout) MaxChild() int64 {
+	return l.ChildMask()
+}
+
+// ChildIndex returns the position of id among its parent's children, 1 for
+// the first child and 0 for a parent
+func (l Layout) ChildIndex(id ID) int64 {
+	return int64(id) & l.ChildMask()
+}
+
+// Parent returns the parent of a child ID, or id itself for a parent
+func (l Layout) Parent(id ID) ID {
+	return id &^ ID(l.ChildMask())
+}
+
+// Child issues the next child ID of parent: children sort after the parent
+// and before any ID issued after it, e.g. to number the revisions of an
+// entity. Up to MaxChild children are issued per parent.
+//
+// The child counters live in memory, so parent must have been issued by
+// this node instance, since its creation, at most Config.ChildWindow ago;
+// this keeps a restarted node from reissuing children. Children are not
+// reflected in HighWatermark.
+func (n *Node) Child(parent ID) (ID, error) {
+	l := n.layout
+	if l.ChildBits == 0 {
+		return 0, ErrNoChildBits
+	}
+	if n.closed.Load() {
+		return 0, ErrClosed
+	}
+	if parent <= 0 || l.NodeID(parent) != n.node || l.ChildIndex(parent) != 0 {
+		return 0, ErrNotParent
+	}
+
+	created := l.Time(parent) - l.Epoch
+	now := n.now()
+	if created < n.started || created > now || now-created > n.childWindow {
+		return 0, ErrNotParent
+	}
+
+	n.childMu.Lock()
+	defer n.childMu.Unlock()
+
+	n.pruneChildren(now)
+	c := n.children[parent] + 1
+	if c > l.MaxChild() {
+		return 0, ErrChildrenExhausted
+	}
+	if n.children == nil {
+		n.children = make(map[ID]int64)
+	}
+	n.children[parent] = c
+	return parent + ID(c), nil
+}
+
+// pruneChildren drops the counters of parents outside the child window at
+// most once per window. Callers hold n.childMu.
+func (n *Node) pruneChildren(now int64) {
+	if now-n.childPruned < n.childWindow {
+		return
+	}
+	n.childPruned = now
+	for p := range n.children {
+		if now-(n.layout.Time(p)-n.layout.Epoch) > n.childWindow {
+			delete(n.children, p)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package mkey
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func childNode(t *testing.T, clock Clock, window time.Duration) *Node {
+	t.Helper()
+	cfg := NewConfig()
+	cfg.Node = 6
+	cfg.ChildBits = 2
+	cfg.ChildWindow = window
+	cfg.Clock = clock
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestChild(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	n := childNode(t, clock, 0)
+	l := n.Layout()
+
+	parent := n.Generate()
+	sibling := n.Generate()
+	if l.ChildIndex(parent) != 0 || l.ChildIndex(sibling) != 0 {
+		t.Fatal("node issued IDs with child bits set")
+	}
+
+	prev := parent
+	for i := int64(1); i <= l.MaxChild(); i++ {
+		c, err := n.Child(parent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c <= prev || c >= sibling {
+			t.Fatalf("child %d = %v not between %v and %v", i, c, prev, sibling)
+		}
+		if l.ChildIndex(c) != i || l.Parent(c) != parent {
+			t.Fatalf("child %d decodes as index %d of %v", i, l.ChildIndex(c), l.Parent(c))
+		}
+		prev = c
+	}
+	if _, err := n.Child(parent); !errors.Is(err, ErrChildrenExhausted) {
+		t.Fatalf("err = %v, want ErrChildrenExhausted", err)
+	}
+	if _, err := n.Child(sibling); err != nil {
+		t.Fatalf("sibling: %v", err)
+	}
+	if _, err := n.Child(prev); !errors.Is(err, ErrNotParent) {
+		t.Fatalf("child of a child: err = %v", err)
+	}
+
+	// parents leave the window after DefaultChildWindow
+	clock.Advance(DefaultChildWindow + time.Millisecond)
+	if _, err := n.Child(sibling); !errors.Is(err, ErrNotParent) {
+		t.Fatalf("expired parent: err = %v", err)
+	}
+}
+
+func TestChildForeignParent(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	n := childNode(t, clock, time.Second)
+	parent := n.Generate()
+
+	// a restarted instance must not number children of the old one's IDs
+	clock.Advance(time.Millisecond)
+	restarted := childNode(t, clock, time.Second)
+	if _, err := restarted.Child(parent); !errors.Is(err, ErrNotParent) {
+		t.Fatalf("parent from before the restart: err = %v", err)
+	}
+
+	other, _ := NewNode(7)
+	for _, p := range []ID{0, -1, other.Generate()} {
+		if _, err := n.Child(p); !errors.Is(err, ErrNotParent) {
+			t.Errorf("Child(%v): err = %v", p, err)
+		}
+	}
+
+	plain, _ := NewNode(6)
+	if _, err := plain.Child(plain.Generate()); !errors.Is(err, ErrNoChildBits) {
+		t.Fatalf("layout without child bits: err = %v", err)
+	}
+
+	n.Close()
+	if _, err := n.Child(parent); !errors.Is(err, ErrClosed) {
+		t.Fatalf("closed node: err = %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package mkey
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the time source of a Node. Inject one via Config.Clock to make
+// generation deterministic in tests or to simulate clock edge cases; the
+// default is the system clock.
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+}
+
+// SystemClock is the Clock backed by the time package, with the platform's
+// high-resolution source on Windows
+type SystemClock struct{}
+
+// Now implements Clock
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Since implements Clock
+func (SystemClock) Since(t time.Time) time.Duration {
+	return sinceEpoch(t)
+}
+
+// ManualClock is a Clock that only moves when told to. A node that exhausts
+// its step space waits until another goroutine advances the clock.
+type ManualClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+// NewManualClock returns a ManualClock set to t
+func NewManualClock(t time.Time) *ManualClock {
+	return &ManualClock{t: t.Round(0)}
+}
+
+// Now implements Clock
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+// Since implements Clock
+func (c *ManualClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// Advance moves the clock forward by d, or backwards if d is negative
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.t = c.t.Add(d)
+	c.mu.Unlock()
+}
+
+// Set moves the clock to t
+func (c *ManualClock) Set(t time.Time) {
+	c.mu.Lock()
+	c.t = t.Round(0)
+	c.mu.Unlock()
+}
@@ -0,0 +1,66 @@
+package mkey
+
+import (
+	"testing"
+	"time"
+)
+
+func TestManualClock(t *testing.T) {
+	start := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	c := NewManualClock(start)
+	if !c.Now().Equal(start) {
+		t.Fatalf("Now = %v", c.Now())
+	}
+	c.Advance(1500 * time.Millisecond)
+	if got := c.Since(start); got != 1500*time.Millisecond {
+		t.Fatalf("Since = %v after Advance", got)
+	}
+	c.Advance(-time.Second)
+	if got := c.Since(start); got != 500*time.Millisecond {
+		t.Fatalf("Since = %v after a negative Advance", got)
+	}
+	c.Set(start.Add(time.Hour))
+	if got := c.Since(start); got != time.Hour {
+		t.Fatalf("Since = %v after Set", got)
+	}
+
+	// monotonic readings are stripped so Set and Since agree with wall time
+	if m := NewManualClock(time.Now()).Now(); m != m.Round(0) {
+		t.Fatal("ManualClock kept a monotonic reading")
+	}
+}
+
+func TestNodeManualClock(t *testing.T) {
+	start := time.UnixMilli(time.Now().UnixMilli())
+	clock := NewManualClock(start)
+	cfg := NewConfig()
+	cfg.Node = 1
+	cfg.Clock = clock
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := n.Layout()
+
+	// IDs are fully determined by the clock
+	for i := range 5 {
+		id := n.Generate()
+		if l.Time(id) != start.UnixMilli() || l.Step(id) != int64(i) {
+			t.Fatalf("ID %d at %d step %d", i, l.Time(id)-start.UnixMilli(), l.Step(id))
+		}
+	}
+	clock.Advance(42 * time.Millisecond)
+	id := n.Generate()
+	if l.Time(id) != start.UnixMilli()+42 || l.Step(id) != 0 {
+		t.Fatalf("ID after Advance at %d step %d", l.Time(id)-start.UnixMilli(), l.Step(id))
+	}
+	want, _ := l.Compose(start.Add(42*time.Millisecond), 1, 0)
+	if id != want {
+		t.Fatalf("ID = %v, want %v", id, want)
+	}
+
+	var sys Clock = SystemClock{}
+	if d := sys.Since(sys.Now().Add(-time.Second)); d < time.Second || d > time.Minute {
+		t.Fatalf("SystemClock.Since = %v", d)
+	}
+}
@@ -0,0 +1,44 @@
+package mkey
+
+import "errors"
+
+// ErrClosed is returned by generation methods of a Node after Close
+var ErrClosed = errors.New("node is closed")
+
+// closedPanic is the panic of methods that cannot return ErrClosed
+const closedPanic = "mkey: ID requested from a closed Node; use GenerateContext or TryGenerate to get ErrClosed"
+
+// Close shuts the node down: further calls fail with ErrClosed, or panic in
+// methods such as Generate that cannot report errors, and calls waiting
+// for a maintenance window, the rate limit or the next millisecond give up
+// with ErrClosed.
+// Close then waits for calls that already hold the generator lock to
+// finish, stops the Config.CoarseTime ticker and releases the node's
+// Config.Exclusive registration, so a replacement with the same node ID can
+// be created at once. BufferedNode and PacedNode wrappers on top of the node
+// stop producing; their buffered IDs can still be taken. It is safe to call
+// Close more than once.
+func (n *Node) Close() error {
+	n.closeOnce.Do(func() {
+		n.closed.Store(true)
+		n.maint.close()
+		n.rate.close()
+
+		// Drain calls in progress
+		n.lock()
+		n.unlock()
+
+		if n.coarse != nil {
+			n.coarse.close()
+		}
+		if n.exclusive != nil {
+			releaseExclusive(exclusiveKey{fingerprint: n.layout.Fingerprint(), node: n.node}, n.exclusive)
+		}
+	})
+	return nil
+}
+
+// Closed reports whether Close has been called, e.g. for a readiness endpoint
+func (n *Node) Closed() bool {
+	return n.closed.Load()
+}
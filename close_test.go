@@ -0,0 +1,220 @@
+package mkey
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCloseFailsLaterCalls(t *testing.T) {
+	n, err := NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.Generate()
+	if err := n.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+	if !n.Closed() {
+		t.Fatal("Closed() = false after Close")
+	}
+
+	if _, err := n.GenerateContext(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Errorf("GenerateContext: got %v, want ErrClosed", err)
+	}
+	if _, err := n.TryGenerate(); !errors.Is(err, ErrClosed) {
+		t.Errorf("TryGenerate: got %v, want ErrClosed", err)
+	}
+	if _, err := n.GenerateBatch(3); !errors.Is(err, ErrClosed) {
+		t.Errorf("GenerateBatch: got %v, want ErrClosed", err)
+	}
+	if _, err := n.GenerateRange(3); !errors.Is(err, ErrClosed) {
+		t.Errorf("GenerateRange: got %v, want ErrClosed", err)
+	}
+}
+
+func TestGeneratePanicsAfterClose(t *testing.T) {
+	for name, f := range map[string]func(*Node){
+		"Generate": func(n *Node) { n.Generate() },
+		"Barrier":  func(n *Node) { n.Barrier() },
+	} {
+		t.Run(name, func(t *testing.T) {
+			n, err := NewNode(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			n.Close()
+			defer func() {
+				if recover() == nil {
+					t.Error("no panic after Close")
+				}
+			}()
+			f(n)
+		})
+	}
+}
+
+func TestCloseWakesWaiters(t *testing.T) {
+	now := time.Now()
+	cfg := NewConfig()
+	cfg.Maintenance = []MaintenanceWindow{{Start: now.Add(-time.Minute), End: now.Add(time.Hour)}}
+	maint, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg = NewConfig()
+	cfg.RateLimit, cfg.RateBurst = 0.001, 1
+	rate, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rate.Generate()
+
+	for name, n := range map[string]*Node{"maintenance": maint, "rate limit": rate} {
+		errc := make(chan error, 1)
+		go func() {
+			_, err := n.GenerateContext(context.Background())
+			errc <- err
+		}()
+		time.Sleep(20 * time.Millisecond)
+		n.Close()
+
+		select {
+		case err := <-errc:
+			if !errors.Is(err, ErrClosed) {
+				t.Errorf("%s waiter: got %v, want ErrClosed", name, err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s waiter not woken by Close", name)
+		}
+	}
+}
+
+func TestCloseReleasesExclusive(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Node = 731
+	cfg.Exclusive = true
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewNodeWithConfig(cfg); !errors.Is(err, ErrNodeInUse) {
+		t.Fatalf("duplicate node: got %v, want ErrNodeInUse", err)
+	}
+	n.Close()
+
+	m, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("replacement after Close: %v", err)
+	}
+	m.Close()
+}
+
+func TestCloseStopsWrappers(t *testing.T) {
+	n, err := NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewBufferedNode(n, BufferConfig{Size: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			if _, err := b.Next(); err != nil {
+				return
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("BufferedNode kept producing after the node was closed")
+	}
+
+	count := 0
+	for range n.Stream() {
+		count++
+	}
+	if count != 0 {
+		t.Errorf("Stream of a closed node yielded %d IDs", count)
+	}
+}
+
+func TestGenerateRecordsClockBackWait(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	cfg := NewConfig()
+	cfg.Clock = clock
+	cfg.DetectClockBack = true
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.Generate()
+	clock.Advance(-time.Second)
+
+	done := make(chan ID)
+	go func() { done <- n.Generate() }()
+
+	deadline := time.Now().Add(time.Second)
+	for n.waitSince.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("waitSince not set while Generate waits out a clock regression")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	clock.Advance(2 * time.Second)
+	<-done
+	if n.waitSince.Load() != 0 {
+		t.Error("waitSince not cleared after the wait")
+	}
+}
+
+func TestCloseWhileWaiting(t *testing.T) {
+	n, _ := stalledNode(t)
+
+	// the ManualClock never moves, so these calls wait holding the lock
+	// until Close makes them give up
+	errc := make(chan error, 2)
+	go func() {
+		_, err := n.GenerateContext(context.Background())
+		errc <- err
+	}()
+	go func() {
+		defer func() {
+			if r := recover(); r != closedPanic {
+				errc <- errors.New("Generate did not panic with closedPanic")
+				return
+			}
+			errc <- ErrClosed
+		}()
+		n.Generate()
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	closed := make(chan struct{})
+	go func() {
+		n.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close blocked behind a call waiting for the next millisecond")
+	}
+	for range 2 {
+		if err := <-errc; !errors.Is(err, ErrClosed) {
+			t.Fatalf("waiting call after Close: %v, want ErrClosed", err)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/icehuntmen/mkey"
+)
+
+// maxListed caps how many individual duplicates and regions are printed
+const maxListed = 20
+
+type duplicate struct {
+	id        mkey.ID
+	firstLine int
+	line      int
+}
+
+type region struct {
+	start, end int
+}
+
+type msKey struct {
+	node int64
+	ms   int64
+}
+
+type auditReport struct {
+	labels     map[string]string
+	total      int
+	duplicates []duplicate
+	dupCount   int
+	regions    []region
+	regionN    int
+	nodes      map[int64]int
+	peak       int
+	peakKey    msKey
+	stepCap    int64
+}
+
+func runAudit(args []string) error {
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	cfg := layoutFlags(fs)
+	format := fs.String("format", "decimal", "ID encoding: decimal, base32, base58 or base64")
+	fs.Func("label", "deployment label `key=value` recorded in the report; repeatable", func(s string) error {
+		k, v, ok := strings.Cut(s, "=")
+		if !ok || k == "" {
+			return fmt.Errorf("invalid label %q, want key=value", s)
+		}
+		if cfg.Labels == nil {
+			cfg.Labels = make(map[string]string)
+		}
+		cfg.Labels[k] = v
+		return nil
+	})
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: mkey audit [flags] <file|->")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	parse, err := idParser(*format)
+	if err != nil {
+		return err
+	}
+	node, err := mkey.NewNodeWithConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if name := fs.Arg(0); name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	rep, err := audit(r, parse, node, node.Layout().MaxStep()+1)
+	if err != nil {
+		return err
+	}
+	rep.print(os.Stdout)
+
+	if rep.dupCount > 0 {
+		return fmt.Errorf("%d duplicate IDs found", rep.dupCount)
+	}
+	return nil
+}
+
+func idParser(format string) (func(string) (mkey.ID, error), error) {
+	switch format {
+	case "decimal":
+		return func(s string) (mkey.ID, error) {
+			v, err := strconv.ParseInt(s, 10, 64)
+			return mkey.ID(v), err
+		}, nil
+	case "base32":
+		return func(s string) (mkey.ID, error) { return mkey.ParseBase32([]byte(s)) }, nil
+	case "base58":
+		return func(s string) (mkey.ID, error) { return mkey.ParseBase58([]byte(s)) }, nil
+	case "base64":
+		return func(s string) (mkey.ID, error) { return mkey.ParseBase64([]byte(s)) }, nil
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// audit streams IDs from r, one per line, and collects the report.
+// Blank lines and lines starting with '#' are ignored.
+func audit(r io.Reader, parse func(string) (mkey.ID, error), node *mkey.Node, stepCap int64) (*auditReport, error) {
+	rep := &auditReport{
+		labels:  node.Labels(),
+		nodes:   make(map[int64]int),
+		stepCap: stepCap,
+	}
+	seen := make(map[mkey.ID]int)
+	perMs := make(map[msKey]int)
+
+	var (
+		prev    mkey.ID
+		havePrv bool
+		cur     *region
+		line    int
+	)
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line++
+		s := strings.TrimSpace(sc.Text())
+		if s == "" || strings.HasPrefix(s, "#") {
+			continue
+		}
+		id, err := parse(s)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rep.total++
+
+		if first, ok := seen[id]; ok {
+			rep.dupCount++
+			if len(rep.duplicates) < maxListed {
+				rep.duplicates = append(rep.duplicates, duplicate{id: id, firstLine: first, line: line})
+			}
+		} else {
+			seen[id] = line
+		}
+
+		if havePrv && id < prev {
+			if cur == nil {
+				rep.regionN++
+				cur = &region{start: line}
+			}
+			cur.end = line
+		} else if cur != nil {
+			if len(rep.regions) < maxListed {
+				rep.regions = append(rep.regions, *cur)
+			}
+			cur = nil
+		}
+		prev, havePrv = id, true
+
+		n := id.NodeID(node)
+		rep.nodes[n]++
+
+		k := msKey{node: n, ms: id.Time(node)}
+		perMs[k]++
+		if perMs[k] > rep.peak {
+			rep.peak = perMs[k]
+			rep.peakKey = k
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if cur != nil && len(rep.regions) < maxListed {
+		rep.regions = append(rep.regions, *cur)
+	}
+	if rep.total == 0 {
+		return nil, errors.New("no IDs found in input")
+	}
+	return rep, nil
+}
+
+func (rep *auditReport) print(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	defer tw.Flush()
+
+	if len(rep.labels) > 0 {
+		keys := make([]string, 0, len(rep.labels))
+		for k := range rep.labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for i, k := range keys {
+			keys[i] = k + "=" + rep.labels[k]
+		}
+		fmt.Fprintf(tw, "Labels:\t%s\n", strings.Join(keys, " "))
+	}
+	fmt.Fprintf(tw, "IDs:\t%d\n", rep.total)
+	fmt.Fprintf(tw, "Duplicates:\t%d\n", rep.dupCount)
+	for _, d := range rep.duplicates {
+		fmt.Fprintf(tw, "\t%s\tline %d (first seen on line %d)\n", d.id, d.line, d.firstLine)
+	}
+	listedMore(tw, rep.dupCount, len(rep.duplicates))
+
+	fmt.Fprintf(tw, "Out-of-order regions:\t%d\n", rep.regionN)
+	for _, r := range rep.regions {
+		fmt.Fprintf(tw, "\tlines %d-%d\n", r.start, r.end)
+	}
+	listedMore(tw, rep.regionN, len(rep.regions))
+
+	fmt.Fprintf(tw, "Nodes:\t%d\n", len(rep.nodes))
+	ids := make([]int64, 0, len(rep.nodes))
+	for n := range rep.nodes {
+		ids = append(ids, n)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, n := range ids {
+		c := rep.nodes[n]
+		fmt.Fprintf(tw, "\tnode %d\t%d\t%.1f%%\n", n, c, 100*float64(c)/float64(rep.total))
+	}
+
+	fmt.Fprintf(tw, "Peak per-ms usage:\t%d of %d steps (%.1f%%) on node %d at %s\n",
+		rep.peak, rep.stepCap, 100*float64(rep.peak)/float64(rep.stepCap), rep.peakKey.node,
+		time.UnixMilli(rep.peakKey.ms).UTC().Format(time.RFC3339Nano))
+}
+
+func listedMore(w io.Writer, total, listed int) {
+	if total > listed {
+		fmt.Fprintf(w, "\t... and %d more\n", total-listed)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/icehuntmen/mkey"
+)
+
+func auditNode(t *testing.T, labels map[string]string) *mkey.Node {
+	t.Helper()
+	cfg := mkey.NewConfig()
+	cfg.Labels = labels
+	node, err := mkey.NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return node
+}
+
+func TestAuditReport(t *testing.T) {
+	node := auditNode(t, nil)
+	parse, err := idParser("decimal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b, c := node.Generate(), node.Generate(), node.Generate()
+	in := strings.Join([]string{"# header", a.String(), c.String(), "", b.String(), b.String()}, "\n")
+
+	rep, err := audit(strings.NewReader(in), parse, node, node.Layout().MaxStep()+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.total != 4 || rep.dupCount != 1 || rep.regionN != 1 {
+		t.Fatalf("total %d, duplicates %d, regions %d; want 4, 1, 1", rep.total, rep.dupCount, rep.regionN)
+	}
+	if d := rep.duplicates[0]; d.id != b || d.firstLine != 5 || d.line != 6 {
+		t.Fatalf("duplicate = %+v", d)
+	}
+	if r := rep.regions[0]; r.start != 5 || r.end != 5 {
+		t.Fatalf("region = %+v, want line 5", r)
+	}
+	if rep.nodes[0] != 4 {
+		t.Fatalf("nodes = %v", rep.nodes)
+	}
+
+	var out bytes.Buffer
+	rep.print(&out)
+	if strings.Contains(out.String(), "Labels:") {
+		t.Fatalf("report without labels prints them:\n%s", out.String())
+	}
+}
+
+func TestAuditReportLabels(t *testing.T) {
+	node := auditNode(t, map[string]string{"region": "eu", "az": "b"})
+	parse, _ := idParser("decimal")
+	rep, err := audit(strings.NewReader(node.Generate().String()), parse, node, node.Layout().MaxStep()+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	rep.print(&out)
+	first, _, _ := strings.Cut(out.String(), "\n")
+	if strings.Join(strings.Fields(first), " ") != "Labels: az=b region=eu" {
+		t.Fatalf("first report line = %q, want sorted labels", first)
+	}
+}
+
+func TestAuditRejects(t *testing.T) {
+	node := auditNode(t, nil)
+	parse, _ := idParser("decimal")
+	for name, in := range map[string]string{
+		"empty":   "# nothing\n\n",
+		"garbage": "12\nnot-an-id\n",
+	} {
+		if _, err := audit(strings.NewReader(in), parse, node, 4096); err == nil {
+			t.Errorf("%s: audit succeeded", name)
+		}
+	}
+	if _, err := idParser("base2"); err == nil {
+		t.Error("idParser accepted an unknown format")
+	}
+}
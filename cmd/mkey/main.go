@@ -0,0 +1,80 @@
+// Command mkey is an operator tool for inspecting and planning mkey ID deployments
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/icehuntmen/mkey"
+)
+
+const usageText = `usage: mkey <command> [flags] [args]
+
+Commands:
+  audit <file>   check a list of IDs for duplicates, ordering and step usage
+  plan           recommend node, step and time bit allocations
+  sql            emit SQL functions decoding IDs for the layout
+  selftest       check the layout and this host's clock before going live
+
+Run "mkey <command> -h" for command flags.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usageText)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "audit":
+		err = runAudit(os.Args[2:])
+	case "plan":
+		err = runPlan(os.Args[2:])
+	case "sql":
+		err = runSQL(os.Args[2:])
+	case "selftest":
+		err = runSelfTest(os.Args[2:])
+	case "help", "-h", "--help":
+		fmt.Fprint(os.Stdout, usageText)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "mkey: unknown command %q\n\n%s", os.Args[1], usageText)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "mkey:", err)
+		os.Exit(1)
+	}
+}
+
+// layoutFlags registers the flags describing the ID layout on fs
+func layoutFlags(fs *flag.FlagSet) *mkey.Config {
+	cfg := mkey.NewConfig()
+	fs.Func("epoch", "epoch name ("+strings.Join(mkey.EpochNames(), ", ")+") or milliseconds since Unix epoch (default manticora)", func(s string) error {
+		epoch, err := mkey.ParseEpoch(s)
+		if err != nil {
+			return err
+		}
+		cfg.Epoch = epoch
+		return nil
+	})
+	fs.Func("node-bits", fmt.Sprintf("number of node bits (default %d)", cfg.NodeBits), uint8Flag(&cfg.NodeBits))
+	fs.Func("step-bits", fmt.Sprintf("number of step bits (default %d)", cfg.StepBits), uint8Flag(&cfg.StepBits))
+	return cfg
+}
+
+func uint8Flag(dst *uint8) func(string) error {
+	return func(s string) error {
+		v, err := strconv.ParseUint(s, 10, 8)
+		if err != nil {
+			return fmt.Errorf("invalid value %q", s)
+		}
+		*dst = uint8(v)
+		return nil
+	}
+}
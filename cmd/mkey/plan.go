@@ -0,0 +1,182 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/icehuntmen/mkey"
+)
+
+// maxLowBits mirrors the NodeBits + StepBits limit enforced by NewNodeWithConfig
+const maxLowBits = 22
+
+const msPerYear = 365.25 * 24 * 3600 * 1000
+
+type planOption struct {
+	name     string
+	nodeBits uint8
+	stepBits uint8
+}
+
+func runPlan(args []string) error {
+	fs := flag.NewFlagSet("plan", flag.ExitOnError)
+	rate := fs.String("rate", "4096/ms", "peak ID rate per node, e.g. 50000/s or 20/ms")
+	nodes := fs.Int64("nodes", 1024, "number of nodes that must generate concurrently")
+	lifetime := fs.String("lifetime", "50y", "required lifetime, e.g. 50y, 18mo, 90d or a Go duration")
+	epoch := fs.String("epoch", "", "epoch to plan for, a registered name or milliseconds (default: start of today, UTC)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: mkey plan [flags]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	perMs, err := parseRate(*rate)
+	if err != nil {
+		return err
+	}
+	life, err := parseLifetime(*lifetime)
+	if err != nil {
+		return err
+	}
+	if *nodes < 1 {
+		return fmt.Errorf("nodes must be positive")
+	}
+
+	now := time.Now().UTC()
+	ep := now.Truncate(24 * time.Hour).UnixMilli()
+	if *epoch != "" {
+		if ep, err = mkey.ParseEpoch(*epoch); err != nil {
+			return err
+		}
+	}
+
+	minNode := bitsFor(uint64(*nodes - 1))
+	minStep := bitsFor(uint64(math.Ceil(perMs)) - 1)
+	if minNode+minStep > maxLowBits {
+		return fmt.Errorf("%d nodes at %.0f IDs/ms need %d node and step bits, more than the %d available",
+			*nodes, perMs, minNode+minStep, maxLowBits)
+	}
+
+	options := []planOption{
+		{"minimal", minNode, minStep},
+		{"headroom", minNode + 1, minStep + 1},
+		{"default", mkey.DefaultNodeBits, mkey.DefaultStepBits},
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Requirements:\t%.2f IDs/ms per node, %d nodes, %.1f years\n", perMs, *nodes, life/msPerYear)
+	fmt.Fprintf(tw, "Epoch:\t%d (%s)\n\n", ep, time.UnixMilli(ep).UTC().Format(time.RFC3339))
+	fmt.Fprintln(tw, "LAYOUT\tNODE\tSTEP\tTIME\tMAX NODES\tIDS/MS/NODE\tEXHAUSTED\tOK")
+
+	var best *planOption
+	for i := range options {
+		o := &options[i]
+		if o.nodeBits+o.stepBits > maxLowBits || o.nodeBits > mkey.MaxNodeBits || o.stepBits > mkey.MaxStepBits {
+			continue
+		}
+		timeBits := 63 - int(o.nodeBits+o.stepBits)
+		exhaust := float64(ep) + math.Ldexp(1, timeBits)
+		ok := int64(1)<<o.nodeBits >= *nodes &&
+			float64(int64(1)<<o.stepBits) >= perMs &&
+			exhaust-float64(now.UnixMilli()) >= life
+		// Prefer headroom over the bare minimum when both fit
+		if ok && o.name != "default" && (best == nil || o.name == "headroom") {
+			best = o
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%s\t%s\n", o.name, o.nodeBits, o.stepBits, timeBits,
+			int64(1)<<o.nodeBits, int64(1)<<o.stepBits, formatExhaustion(exhaust), yesNo(ok))
+	}
+	tw.Flush()
+
+	if best == nil {
+		return fmt.Errorf("no layout satisfies the lifetime requirement; use a later epoch or fewer bits")
+	}
+	printConfig(os.Stdout, best, ep)
+	return nil
+}
+
+// bitsFor returns the number of bits needed to represent v
+func bitsFor(v uint64) uint8 {
+	return uint8(bits.Len64(v))
+}
+
+// parseRate parses rates like 50000/s, 20/ms, 1e6/m or 3000000/h into IDs per millisecond.
+// A bare number is taken as IDs per second.
+func parseRate(s string) (float64, error) {
+	num, unit, found := strings.Cut(s, "/")
+	if !found {
+		unit = "s"
+	}
+	v, err := strconv.ParseFloat(num, 64)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("invalid rate %q", s)
+	}
+	switch unit {
+	case "ms":
+		return v, nil
+	case "s":
+		return v / 1e3, nil
+	case "m", "min":
+		return v / 60e3, nil
+	case "h":
+		return v / 3600e3, nil
+	}
+	return 0, fmt.Errorf("invalid rate unit %q", unit)
+}
+
+// parseLifetime parses lifetimes like 50y, 18mo, 90d or any time.ParseDuration value into milliseconds
+func parseLifetime(s string) (float64, error) {
+	for _, u := range []struct {
+		suffix string
+		ms     float64
+	}{
+		{"y", msPerYear},
+		{"mo", msPerYear / 12},
+		{"w", 7 * 24 * 3600 * 1000},
+		{"d", 24 * 3600 * 1000},
+	} {
+		if num, ok := strings.CutSuffix(s, u.suffix); ok {
+			v, err := strconv.ParseFloat(num, 64)
+			if err != nil || v <= 0 {
+				return 0, fmt.Errorf("invalid lifetime %q", s)
+			}
+			return v * u.ms, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid lifetime %q", s)
+	}
+	return float64(d.Milliseconds()), nil
+}
+
+func formatExhaustion(ms float64) string {
+	if ms >= float64(time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC).UnixMilli()) {
+		return "after 9999"
+	}
+	return time.UnixMilli(int64(ms)).UTC().Format("2006-01-02")
+}
+
+func yesNo(ok bool) string {
+	if ok {
+		return "yes"
+	}
+	return "no"
+}
+
+func printConfig(w io.Writer, o *planOption, epoch int64) {
+	fmt.Fprintf(w, "\nRecommended (%s):\n\n", o.name)
+	fmt.Fprintf(w, "\tcfg := &mkey.Config{\n")
+	fmt.Fprintf(w, "\t\tEpoch:    %d,\n", epoch)
+	fmt.Fprintf(w, "\t\tNodeBits: %d,\n", o.nodeBits)
+	fmt.Fprintf(w, "\t\tStepBits: %d,\n", o.stepBits)
+	fmt.Fprintf(w, "\t}\n")
+}
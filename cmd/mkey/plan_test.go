@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+	}{
+		{"20/ms", 20},
+		{"50000/s", 50},
+		{"50000", 50},
+		{"60000/m", 1},
+		{"60000/min", 1},
+		{"3600000/h", 1},
+		{"1e6/s", 1000},
+	}
+	for _, tt := range tests {
+		got, err := parseRate(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("parseRate(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", "fast", "0/s", "-5/ms", "10/day"} {
+		if _, err := parseRate(in); err == nil {
+			t.Errorf("parseRate(%q) succeeded", in)
+		}
+	}
+}
+
+func TestParseLifetime(t *testing.T) {
+	const day = 24 * 3600 * 1000
+	tests := []struct {
+		in   string
+		want float64
+	}{
+		{"1y", msPerYear},
+		{"12mo", msPerYear},
+		{"2w", 14 * day},
+		{"90d", 90 * day},
+		{"36h", 1.5 * day},
+	}
+	for _, tt := range tests {
+		got, err := parseLifetime(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("parseLifetime(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", "forever", "0y", "-3d", "-1h"} {
+		if _, err := parseLifetime(in); err == nil {
+			t.Errorf("parseLifetime(%q) succeeded", in)
+		}
+	}
+}
+
+func TestBitsFor(t *testing.T) {
+	for v, want := range map[uint64]uint8{0: 0, 1: 1, 2: 2, 3: 2, 1023: 10, 1024: 11} {
+		if got := bitsFor(v); got != want {
+			t.Errorf("bitsFor(%d) = %d, want %d", v, got, want)
+		}
+	}
+}
+
+func TestFormatExhaustion(t *testing.T) {
+	if got := formatExhaustion(0); got != "1970-01-01" {
+		t.Errorf("formatExhaustion(0) = %q", got)
+	}
+	if got := formatExhaustion(1e18); got != "after 9999" {
+		t.Errorf("formatExhaustion(1e18) = %q", got)
+	}
+}
+
+func TestRunPlanRejects(t *testing.T) {
+	for name, args := range map[string][]string{
+		"too many bits": {"-nodes", "1048576", "-rate", "4096/ms"},
+		"no nodes":      {"-nodes", "0"},
+		"bad rate":      {"-rate", "x"},
+		"bad lifetime":  {"-lifetime", "x"},
+		"too long":      {"-nodes", "1024", "-rate", "4096/ms", "-lifetime", "100000y"},
+	} {
+		if err := runPlan(args); err == nil {
+			t.Errorf("%s: runPlan succeeded", name)
+		}
+	}
+}
+
+func TestPrintConfig(t *testing.T) {
+	var b bytes.Buffer
+	printConfig(&b, &planOption{name: "headroom", nodeBits: 11, stepBits: 13}, 1700000000000)
+	for _, want := range []string{"Recommended (headroom)", "Epoch:    1700000000000,", "NodeBits: 11,", "StepBits: 13,"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("config lacks %q:\n%s", want, b.String())
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/icehuntmen/mkey"
+)
+
+func runSelfTest(args []string) error {
+	fs := flag.NewFlagSet("selftest", flag.ExitOnError)
+	cfg := layoutFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: mkey selftest [flags]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	rep, err := mkey.SelfTest(cfg)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Layout:\tnode %d bits, step %d bits, time %d bits\n", rep.Layout.NodeBits, rep.Layout.StepBits, rep.Layout.TimeBits())
+	fmt.Fprintf(tw, "Exhausted:\t%s\n", rep.Exhausted.UTC().Format(time.DateOnly))
+	fmt.Fprintf(tw, "Clock resolution:\t%s\n", rep.ClockResolution)
+	fmt.Fprintf(tw, "Clock regressions:\t%d\n", rep.ClockRegressions)
+	fmt.Fprintf(tw, "Throughput:\t%.0f IDs/ms (capacity %d IDs/ms per node)\n", rep.Throughput, rep.StepCapacity)
+	tw.Flush()
+
+	if rep.OK() {
+		fmt.Println("OK")
+		return nil
+	}
+	fmt.Println("Problems:")
+	for _, p := range rep.Problems {
+		fmt.Println("  -", p)
+	}
+	return fmt.Errorf("%d problems found", len(rep.Problems))
+}
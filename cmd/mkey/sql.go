@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/icehuntmen/mkey/sqlgen"
+)
+
+func runSQL(args []string) error {
+	fs := flag.NewFlagSet("sql", flag.ExitOnError)
+	cfg := layoutFlags(fs)
+	dialect := fs.String("dialect", string(sqlgen.Postgres), "SQL dialect: postgres, mysql, clickhouse or bigquery")
+	prefix := fs.String("prefix", sqlgen.DefaultPrefix, "function name prefix")
+	column := fs.String("column", "", "print inline expressions for this column instead of functions")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: mkey sql [flags]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *column != "" {
+		e, err := sqlgen.Expr(sqlgen.Dialect(*dialect), cfg.Layout(), *column)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(os.Stdout, "time: %s\nnode: %s\nstep: %s\n", e.Time, e.Node, e.Step)
+		return err
+	}
+
+	out, err := sqlgen.Functions(sqlgen.Dialect(*dialect), cfg.Layout(), *prefix)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(os.Stdout, out)
+	return err
+}
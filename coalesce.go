@@ -0,0 +1,111 @@
+package mkey
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Coalescer hands out one ID per key within a TTL window, so retried or
+// concurrent requests carrying the same idempotency key get identical IDs
+// without an external store. State is in-memory and per process.
+type Coalescer struct {
+	node *Node
+	ttl  time.Duration
+
+	mu        sync.Mutex
+	entries   map[string]*coalesced
+	lastSweep time.Time
+}
+
+// coalesced is the entry of one key. It is inserted before its ID is
+// generated, so concurrent callers for the key wait on done instead of
+// holding the Coalescer lock during generation.
+type coalesced struct {
+	done    chan struct{}
+	id      ID
+	expires time.Time
+}
+
+// NewCoalescer creates a Coalescer issuing IDs from node and remembering
+// them for ttl
+func NewCoalescer(node *Node, ttl time.Duration) (*Coalescer, error) {
+	if node == nil {
+		return nil, errors.New("node must not be nil")
+	}
+	if ttl <= 0 {
+		return nil, errors.New("ttl must be positive")
+	}
+	return &Coalescer{
+		node:      node,
+		ttl:       ttl,
+		entries:   make(map[string]*coalesced),
+		lastSweep: time.Now(),
+	}, nil
+}
+
+// GenerateFor returns the ID issued for key within the TTL window, or a new
+// one if there is none. The window starts with the first call for the key;
+// calls for a key whose ID is still being generated wait for it, while calls
+// for other keys proceed. Like Node.Generate it panics after the node is closed.
+func (c *Coalescer) GenerateFor(key string) ID {
+	now := time.Now()
+
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok && now.Before(e.expires) {
+		c.mu.Unlock()
+		<-e.done
+		if e.id == Nil {
+			panic(closedPanic)
+		}
+		return e.id
+	}
+
+	// Drop expired keys at most once per window to bound memory
+	if now.Sub(c.lastSweep) >= c.ttl {
+		for k, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		c.lastSweep = now
+	}
+
+	e := &coalesced{done: make(chan struct{}), expires: now.Add(c.ttl)}
+	c.entries[key] = e
+	c.mu.Unlock()
+
+	c.issue(key, e)
+	return e.id
+}
+
+// issue generates the ID of e and wakes its waiters. If generation panics
+// the entry is dropped, so later calls for the key do not wait on it.
+func (c *Coalescer) issue(key string, e *coalesced) {
+	defer close(e.done)
+	defer func() {
+		if e.id == Nil {
+			c.mu.Lock()
+			if c.entries[key] == e {
+				delete(c.entries, key)
+			}
+			c.mu.Unlock()
+		}
+	}()
+	e.id = c.node.Generate()
+}
+
+// Forget removes key so the next GenerateFor issues a fresh ID
+func (c *Coalescer) Forget(key string) {
+	c.mu.Lock()
+	delete(c.entries, key)
+	c.mu.Unlock()
+}
+
+// Len returns the number of keys currently remembered, including expired
+// keys that have not been swept yet
+func (c *Coalescer) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
@@ -0,0 +1,164 @@
+package mkey
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCoalescerSameKey(t *testing.T) {
+	n, err := NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewCoalescer(n, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := c.GenerateFor("a")
+	if got := c.GenerateFor("a"); got != a {
+		t.Fatalf("second call for a = %d, want %d", got, a)
+	}
+	b := c.GenerateFor("b")
+	if b == a {
+		t.Fatal("different keys got the same ID")
+	}
+	if c.Len() != 2 {
+		t.Fatalf("Len = %d, want 2", c.Len())
+	}
+
+	c.Forget("a")
+	if got := c.GenerateFor("a"); got == a {
+		t.Fatal("Forget did not drop the key")
+	}
+}
+
+func TestCoalescerExpiry(t *testing.T) {
+	n, err := NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewCoalescer(n, 20*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := c.GenerateFor("a")
+	c.GenerateFor("b")
+	time.Sleep(30 * time.Millisecond)
+
+	if got := c.GenerateFor("a"); got == a {
+		t.Fatal("expired key kept its ID")
+	}
+	// The sweep on that call dropped the expired b
+	if c.Len() != 1 {
+		t.Fatalf("Len = %d after sweep, want 1", c.Len())
+	}
+}
+
+func TestCoalescerConcurrentCallers(t *testing.T) {
+	n, err := NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewCoalescer(n, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	ids := make([]ID, 64)
+	for i := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ids[i] = c.GenerateFor("k")
+		}()
+	}
+	wg.Wait()
+	for _, id := range ids {
+		if id != ids[0] {
+			t.Fatalf("concurrent callers got %d and %d", ids[0], id)
+		}
+	}
+	if g := n.Stats().Generated; g != 1 {
+		t.Fatalf("%d IDs generated for one key, want 1", g)
+	}
+}
+
+// A key whose generation blocks must not hold up other keys
+func TestCoalescerDoesNotSerializeKeys(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	cfg := NewConfig()
+	cfg.Clock = clock
+	cfg.DetectClockBack = true
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewCoalescer(n, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := c.GenerateFor("a")
+
+	// Generation for b waits out the regression
+	clock.Advance(-time.Second)
+	slow := make(chan ID, 2)
+	for range 2 {
+		go func() { slow <- c.GenerateFor("b") }()
+	}
+	for n.waitSince.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	fast := make(chan ID)
+	go func() { fast <- c.GenerateFor("a") }()
+	select {
+	case got := <-fast:
+		if got != a {
+			t.Fatalf("a = %d, want %d", got, a)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("lookup of a blocked behind generation for b")
+	}
+
+	clock.Advance(2 * time.Second)
+	b1, b2 := <-slow, <-slow
+	if b1 != b2 || b1 == a {
+		t.Fatalf("b callers got %d and %d", b1, b2)
+	}
+}
+
+func TestCoalescerClosedNode(t *testing.T) {
+	n, err := NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewCoalescer(n, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.Close()
+
+	for range 2 {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("GenerateFor on a closed node did not panic")
+				}
+			}()
+			c.GenerateFor("a")
+		}()
+	}
+	if c.Len() != 0 {
+		t.Fatalf("failed key left %d entries", c.Len())
+	}
+
+	if _, err := NewCoalescer(nil, time.Hour); err == nil {
+		t.Fatal("accepted a nil node")
+	}
+	if _, err := NewCoalescer(n, 0); err == nil {
+		t.Fatal("accepted a zero ttl")
+	}
+}
@@ -0,0 +1,54 @@
+package mkey
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// coarseTick is how often Config.CoarseTime refreshes the cached millisecond
+const coarseTick = 500 * time.Microsecond
+
+// coarseTime caches a node's clock reading in milliseconds since the epoch.
+// The cache only moves forward: it holds the latest of the ticker's readings
+// and the precise readings taken while waiting for the next millisecond.
+type coarseTime struct {
+	ms   atomic.Int64
+	stop chan struct{}
+	once sync.Once
+}
+
+// startCoarseTime seeds the cache with read and refreshes it every
+// coarseTick until stop is closed
+func startCoarseTime(read func() int64) *coarseTime {
+	c := &coarseTime{stop: make(chan struct{})}
+	c.ms.Store(read())
+	go func() {
+		t := time.NewTicker(coarseTick)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				c.raise(read())
+			case <-c.stop:
+				return
+			}
+		}
+	}()
+	return c
+}
+
+// close stops the ticker; it may be called by both Node.Close and the
+// cleanup of a collected node
+func (c *coarseTime) close() {
+	c.once.Do(func() { close(c.stop) })
+}
+
+func (c *coarseTime) raise(ms int64) {
+	for {
+		cur := c.ms.Load()
+		if ms <= cur || c.ms.CompareAndSwap(cur, ms) {
+			return
+		}
+	}
+}
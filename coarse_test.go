@@ -0,0 +1,113 @@
+package mkey
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCoarseTimeRaise(t *testing.T) {
+	var reading atomic.Int64
+	reading.Store(100)
+	c := startCoarseTime(reading.Load)
+	defer c.close()
+
+	if got := c.ms.Load(); got != 100 {
+		t.Fatalf("seeded cache = %d, want 100", got)
+	}
+	c.raise(90)
+	if got := c.ms.Load(); got != 100 {
+		t.Fatalf("cache moved back to %d", got)
+	}
+	c.raise(120)
+	if got := c.ms.Load(); got != 120 {
+		t.Fatalf("cache = %d after raise(120)", got)
+	}
+
+	// the ticker picks up readings ahead of the cache
+	reading.Store(200)
+	deadline := time.Now().Add(time.Second)
+	for c.ms.Load() != 200 {
+		if time.Now().After(deadline) {
+			t.Fatalf("cache stuck at %d, want the ticker to refresh it to 200", c.ms.Load())
+		}
+		time.Sleep(coarseTick)
+	}
+}
+
+func TestCoarseTimeClose(t *testing.T) {
+	c := startCoarseTime(func() int64 { return 1 })
+	c.close()
+	c.close()
+	select {
+	case <-c.stop:
+	default:
+		t.Fatal("close did not stop the ticker")
+	}
+}
+
+func TestCoarseTimeNode(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	cfg := NewConfig()
+	cfg.Clock = clock
+	cfg.CoarseTime = true
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer n.Close()
+	l := n.Layout()
+
+	if got, want := l.Time(n.Generate()), clock.Now().UnixMilli(); got != want {
+		t.Fatalf("ID time = %d, want %d", got, want)
+	}
+
+	clock.Advance(10 * time.Millisecond)
+	want := clock.Now().UnixMilli()
+	deadline := time.Now().Add(time.Second)
+	for l.Time(n.Generate()) != want {
+		if time.Now().After(deadline) {
+			t.Fatal("IDs never caught up with the advanced clock")
+		}
+		time.Sleep(coarseTick)
+	}
+
+	if err := n.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-n.coarse.stop:
+	default:
+		t.Fatal("Close did not stop the coarse time ticker")
+	}
+}
+
+func TestCoarseTimeExhaustion(t *testing.T) {
+	cfg := NewConfig()
+	cfg.StepBits = 2
+	cfg.CoarseTime = true
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer n.Close()
+
+	// exhausting a millisecond waits on precise clock readings, so IDs keep
+	// increasing past the cached millisecond
+	l := n.Layout()
+	var prev ID
+	perMilli := map[int64]int{}
+	for i := range 40 {
+		id := n.Generate()
+		if id <= prev {
+			t.Fatalf("ID %d = %d, not above %d", i, id, prev)
+		}
+		perMilli[l.Time(id)]++
+		prev = id
+	}
+	for ms, count := range perMilli {
+		if count > 4 {
+			t.Fatalf("%d IDs in millisecond %d, want at most 4", count, ms)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package mkey
+
+import "encoding/binary"
+
+// Compose packs two IDs into a 128-bit composite key, a in the high half and
+// b in the low half, both big-endian. Comparing keys bytewise orders them by
+// a and then by b, so a KV store can hold e.g. (conversationID, messageID)
+// under a single key and range-scan one conversation. Ordering holds for the
+// non-negative IDs every Node issues.
+func Compose(a, b ID) [16]byte {
+	var k [16]byte
+	binary.BigEndian.PutUint64(k[:8], uint64(a))
+	binary.BigEndian.PutUint64(k[8:], uint64(b))
+	return k
+}
+
+// Decompose128 splits a key built by Compose back into its two IDs
+func Decompose128(k [16]byte) (a, b ID) {
+	return ID(binary.BigEndian.Uint64(k[:8])), ID(binary.BigEndian.Uint64(k[8:]))
+}
+
+// ComposePrefix returns the first half of every composite key whose high ID
+// is a, for range scans over all keys sharing it
+func ComposePrefix(a ID) [8]byte {
+	var p [8]byte
+	binary.BigEndian.PutUint64(p[:], uint64(a))
+	return p
+}
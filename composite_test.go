@@ -0,0 +1,39 @@
+package mkey
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestCompose(t *testing.T) {
+	for range 1000 {
+		a, b := ID(rand.Int64()), ID(rand.Int64())
+		k := Compose(a, b)
+		if ga, gb := Decompose128(k); ga != a || gb != b {
+			t.Fatalf("Decompose128(Compose(%d, %d)) = %d, %d", a, b, ga, gb)
+		}
+		p := ComposePrefix(a)
+		if !bytes.HasPrefix(k[:], p[:]) {
+			t.Fatalf("key %x does not start with prefix %x", k, p)
+		}
+	}
+}
+
+func TestComposeOrder(t *testing.T) {
+	keys := [][2]ID{
+		{1, 1 << 62},
+		{2, 0},
+		{2, 1},
+		{2, 256},
+		{256, 1},
+		{1<<63 - 1, 0},
+	}
+	for i := 1; i < len(keys); i++ {
+		prev := Compose(keys[i-1][0], keys[i-1][1])
+		cur := Compose(keys[i][0], keys[i][1])
+		if bytes.Compare(prev[:], cur[:]) >= 0 {
+			t.Errorf("Compose%v does not sort before Compose%v", keys[i-1], keys[i])
+		}
+	}
+}
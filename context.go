@@ -0,0 +1,57 @@
+package mkey
+
+import (
+	"context"
+	"log/slog"
+)
+
+// DefaultLogKey is the attribute key used by NewLogHandler when none is given
+const DefaultLogKey = "request_id"
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying id, e.g. as a request correlation ID
+func NewContext(ctx context.Context, id ID) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the ID stored in ctx by NewContext
+func FromContext(ctx context.Context) (ID, bool) {
+	id, ok := ctx.Value(contextKey{}).(ID)
+	return id, ok
+}
+
+// NewLogHandler wraps h so every record logged with a context carrying an ID
+// gets that ID as an attribute named key (DefaultLogKey if empty).
+// Use the slog *Context methods, e.g. logger.InfoContext(ctx, ...).
+func NewLogHandler(h slog.Handler, key string) slog.Handler {
+	if key == "" {
+		key = DefaultLogKey
+	}
+	return &logHandler{h: h, key: key}
+}
+
+type logHandler struct {
+	h   slog.Handler
+	key string
+}
+
+func (l *logHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return l.h.Enabled(ctx, level)
+}
+
+func (l *logHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id, ok := FromContext(ctx); ok {
+		r = r.Clone()
+		r.AddAttrs(slog.String(l.key, id.String()))
+	}
+	return l.h.Handle(ctx, r)
+}
+
+func (l *logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &logHandler{h: l.h.WithAttrs(attrs), key: l.key}
+}
+
+func (l *logHandler) WithGroup(name string) slog.Handler {
+	return &logHandler{h: l.h.WithGroup(name), key: l.key}
+}
@@ -0,0 +1,58 @@
+package mkey
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestContext(t *testing.T) {
+	if _, ok := FromContext(t.Context()); ok {
+		t.Fatal("FromContext found an ID in an empty context")
+	}
+	ctx := NewContext(t.Context(), 42)
+	if id, ok := FromContext(ctx); !ok || id != 42 {
+		t.Fatalf("FromContext = %v, %v; want 42, true", id, ok)
+	}
+}
+
+func TestLogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	base := slog.NewJSONHandler(&buf, nil)
+	logged := func() map[string]any {
+		t.Helper()
+		var m map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+			t.Fatal(err)
+		}
+		buf.Reset()
+		return m
+	}
+
+	id := ID(1234567)
+	ctx := NewContext(t.Context(), id)
+
+	l := slog.New(NewLogHandler(base, ""))
+	l.InfoContext(ctx, "hello")
+	if got := logged()[DefaultLogKey]; got != id.String() {
+		t.Fatalf("%s = %v, want %q", DefaultLogKey, got, id.String())
+	}
+
+	l.InfoContext(context.Background(), "hello")
+	if m := logged(); m[DefaultLogKey] != nil {
+		t.Fatalf("record without an ID got %v", m[DefaultLogKey])
+	}
+
+	l = slog.New(NewLogHandler(base, "trace")).With("svc", "api").WithGroup("g")
+	l.InfoContext(ctx, "hello", "k", 1)
+	m := logged()
+	if m["svc"] != "api" {
+		t.Fatalf("WithAttrs dropped: %v", m)
+	}
+	g, _ := m["g"].(map[string]any)
+	if g["trace"] != id.String() || g["k"] != float64(1) {
+		t.Fatalf("group = %v, want trace and k", g)
+	}
+}
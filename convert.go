@@ -0,0 +1,225 @@
+package mkey
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+const encodeULIDMap = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULIDLength is the length of a ULID string
+const ULIDLength = 26
+
+var decodeULIDMap [256]byte
+
+func init() {
+	initDecodeMap(encodeULIDMap, &decodeULIDMap)
+	for i := 10; i < len(encodeULIDMap); i++ {
+		decodeULIDMap[encodeULIDMap[i]-'A'+'a'] = byte(i)
+	}
+}
+
+// UUID is a 16-byte RFC 9562 UUID
+type UUID [16]byte
+
+// String returns the canonical 8-4-4-4-12 hex form
+func (u UUID) String() string {
+	var b [36]byte
+	hex.Encode(b[0:8], u[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], u[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], u[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], u[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], u[10:])
+	return string(b[:])
+}
+
+// ParseUUID parses a UUID in canonical 8-4-4-4-12 form
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, errors.New("invalid UUID format")
+	}
+	src := s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	if _, err := hex.Decode(u[:], []byte(src)); err != nil {
+		return u, errors.New("invalid UUID format")
+	}
+	return u, nil
+}
+
+// ToUUIDv7 converts id into a version 7 UUID. The UUID timestamp is the ID's
+// creation time and the ID itself is stored in the random bits, so the UUIDs
+// sort like the IDs and FromUUIDv7 recovers the ID exactly. Under a layout with
+// priority bits the UUIDs sort by time first, ignoring the priority; it is
+// still restored on the way back.
+func ToUUIDv7(id ID, l Layout) UUID {
+	var u UUID
+	ms := uint64(l.Time(id))
+	binary.BigEndian.PutUint64(u[8:], uint64(id))
+	u[8] = 0x80 | (u[8] & 0x3F)
+	u[0], u[1], u[2], u[3], u[4], u[5] = byte(ms>>40), byte(ms>>32), byte(ms>>24), byte(ms>>16), byte(ms>>8), byte(ms)
+	u[6] = 0x70
+	u[7] = byte(uint64(id) >> 62)
+	return u
+}
+
+// FromUUIDv7 converts a version 7 UUID into an ID under layout l. UUIDs made by
+// ToUUIDv7 round-trip exactly; for other UUIDs node and step are taken from the
+// low random bits, so distinct UUIDs from the same millisecond may collide.
+func FromUUIDv7(u UUID, l Layout) (ID, error) {
+	if u[6]>>4 != 7 || u[8]>>6 != 2 {
+		return 0, errors.New("not a version 7 UUID")
+	}
+	ms := int64(u[0])<<40 | int64(u[1])<<32 | int64(u[2])<<24 | int64(u[3])<<16 | int64(u[4])<<8 | int64(u[5])
+	// The variant overwrites the top two ID bits, which ToUUIDv7 keeps in u[7]
+	low := binary.BigEndian.Uint64(u[8:])&^(3<<62) | uint64(u[7]&3)<<62
+	return composeConverted(ms, low, l)
+}
+
+// ToULID converts id into a ULID string. As with ToUUIDv7 the ID is stored in
+// the random part, so FromULID recovers it exactly, priority included.
+func ToULID(id ID, l Layout) string {
+	var b [16]byte
+	ms := uint64(l.Time(id))
+	binary.BigEndian.PutUint64(b[0:8], ms<<16)
+	binary.BigEndian.PutUint64(b[8:], uint64(id))
+
+	var s [ULIDLength]byte
+	for i := range s {
+		s[i] = encodeULIDMap[bitsAt(&b, 5*i-2, 5)]
+	}
+	return string(s[:])
+}
+
+// FromULID converts a ULID string into an ID under layout l, with the same
+// caveats as FromUUIDv7 for ULIDs not produced by ToULID
+func FromULID(s string, l Layout) (ID, error) {
+	if len(s) != ULIDLength {
+		return 0, fmt.Errorf("ULID must be %d characters", ULIDLength)
+	}
+	if decodeULIDMap[s[0]] > 7 {
+		return 0, errors.New("ULID overflows 128 bits")
+	}
+
+	var b [16]byte
+	for i := 0; i < len(s); i++ {
+		v := decodeULIDMap[s[i]]
+		if v == 0xFF {
+			return 0, errors.New("invalid ULID character")
+		}
+		setBitsAt(&b, 5*i-2, 5, v)
+	}
+	ms := int64(binary.BigEndian.Uint64(b[0:8]) >> 16)
+	return composeConverted(ms, binary.BigEndian.Uint64(b[8:]), l)
+}
+
+// composeConverted builds an ID from a Unix millisecond timestamp and the low
+// 64 bits of a UUID or ULID, keeping the node and step bits from low. If low
+// is an ID of that timestamp, as stored by ToUUIDv7 and ToULID, its priority
+// is kept too; other inputs get priority 0.
+func composeConverted(ms int64, low uint64, l Layout) (ID, error) {
+	t := ms - l.Epoch
+	if t < 0 {
+		return 0, errors.New("timestamp is before the layout epoch")
+	}
+	shift := l.TimeShift()
+	if t > l.TimeMask() {
+		return 0, errors.New("timestamp overflows the layout")
+	}
+	id := t<<shift | int64(low&(1<<shift-1))
+	if low>>63 == 0 && l.Time(ID(low)) == ms {
+		id |= int64(low) &^ (1<<(63-l.PriorityBits) - 1)
+	}
+	return ID(id), nil
+}
+
+// bitsAt returns n bits of b starting at bit offset off from the most
+// significant bit; bits before the start of b read as zero
+func bitsAt(b *[16]byte, off, n int) byte {
+	var v byte
+	for i := off; i < off+n; i++ {
+		v <<= 1
+		if i >= 0 && b[i/8]&(0x80>>(i%8)) != 0 {
+			v |= 1
+		}
+	}
+	return v
+}
+
+// setBitsAt is the inverse of bitsAt
+func setBitsAt(b *[16]byte, off, n int, v byte) {
+	for i := off + n - 1; i >= off; i-- {
+		if i >= 0 && v&1 != 0 {
+			b[i/8] |= 0x80 >> (i % 8)
+		}
+		v >>= 1
+	}
+}
+
+// ConvertError reports the input position of a failed bulk conversion
+type ConvertError struct {
+	Index int
+	Err   error
+}
+
+func (e *ConvertError) Error() string {
+	return fmt.Sprintf("item %d: %v", e.Index, e.Err)
+}
+
+func (e *ConvertError) Unwrap() error {
+	return e.Err
+}
+
+// ToUUIDv7Slice converts ids with ToUUIDv7
+func ToUUIDv7Slice(ids []ID, l Layout) []UUID {
+	out := make([]UUID, len(ids))
+	for i, id := range ids {
+		out[i] = ToUUIDv7(id, l)
+	}
+	return out
+}
+
+// FromUUIDv7Slice converts uuids with FromUUIDv7. Failed items are left as
+// zero and reported together as *ConvertError values joined with errors.Join.
+func FromUUIDv7Slice(uuids []UUID, l Layout) ([]ID, error) {
+	out := make([]ID, len(uuids))
+	var errs []error
+	for i, u := range uuids {
+		id, err := FromUUIDv7(u, l)
+		if err != nil {
+			errs = append(errs, &ConvertError{Index: i, Err: err})
+			continue
+		}
+		out[i] = id
+	}
+	return out, errors.Join(errs...)
+}
+
+// ToULIDSlice converts ids with ToULID
+func ToULIDSlice(ids []ID, l Layout) []string {
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[i] = ToULID(id, l)
+	}
+	return out
+}
+
+// FromULIDSlice converts ulids with FromULID, aggregating errors like FromUUIDv7Slice
+func FromULIDSlice(ulids []string, l Layout) ([]ID, error) {
+	out := make([]ID, len(ulids))
+	var errs []error
+	for i, s := range ulids {
+		id, err := FromULID(s, l)
+		if err != nil {
+			errs = append(errs, &ConvertError{Index: i, Err: err})
+			continue
+		}
+		out[i] = id
+	}
+	return out, errors.Join(errs...)
+}
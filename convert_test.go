@@ -0,0 +1,157 @@
+package mkey
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestConvertRoundTrip(t *testing.T) {
+	layouts := map[string]func(*Config){
+		"default":  func(*Config) {},
+		"priority": func(c *Config) { c.PriorityBits, c.NodeBits = 2, 8 },
+		"shard":    func(c *Config) { c.ShardBits, c.NodeBits = 4, 6 },
+		"ttl":      func(c *Config) { c.ExpiryBits, c.NodeBits = 8, 2 },
+	}
+	for name, opt := range layouts {
+		t.Run(name, func(t *testing.T) {
+			cfg := NewConfig()
+			cfg.Node = 3
+			opt(cfg)
+			n, err := NewNodeWithConfig(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			l := n.layout
+
+			ids := []ID{n.Generate(), n.Generate()}
+			for p := uint8(1); p <= l.MaxPriority(); p++ {
+				id, err := n.GenerateWithPriority(p)
+				if err != nil {
+					t.Fatal(err)
+				}
+				ids = append(ids, id)
+			}
+			for _, id := range ids {
+				u, err := FromUUIDv7(ToUUIDv7(id, l), l)
+				if err != nil || u != id {
+					t.Errorf("UUIDv7 round trip of %d = %d, %v", id, u, err)
+				}
+				if got := ToUUIDv7(id, l); got[6]>>4 != 7 || got[8]>>6 != 2 {
+					t.Errorf("ToUUIDv7(%d) = %s is not a version 7 UUID", id, got)
+				}
+				s := ToULID(id, l)
+				v, err := FromULID(s, l)
+				if err != nil || v != id {
+					t.Errorf("ULID round trip of %d = %d, %v", id, v, err)
+				}
+				if v, err := FromULID(strings.ToLower(s), l); err != nil || v != id {
+					t.Errorf("lower case ULID round trip of %d = %d, %v", id, v, err)
+				}
+			}
+		})
+	}
+}
+
+func TestConvertSortsLikeIDs(t *testing.T) {
+	n, err := NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := n.Generate()
+	for range 1000 {
+		id := n.Generate()
+		if ToUUIDv7(prev, n.layout).String() >= ToUUIDv7(id, n.layout).String() {
+			t.Fatalf("UUIDs of %d and %d do not sort like the IDs", prev, id)
+		}
+		if ToULID(prev, n.layout) >= ToULID(id, n.layout) {
+			t.Fatalf("ULIDs of %d and %d do not sort like the IDs", prev, id)
+		}
+		prev = id
+	}
+}
+
+func TestFromUUIDv7Foreign(t *testing.T) {
+	cfg := NewConfig()
+	cfg.PriorityBits, cfg.NodeBits = 2, 8
+	l := cfg.Layout()
+
+	// A UUID from another generator: ms is in range, the random bits are not
+	// an ID of that millisecond, so the priority stays 0
+	u, err := ParseUUID("019a2b3c-4d5e-7fff-bfff-ffffffffffff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := FromUUIDv7(u, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := l.Priority(id); p != 0 {
+		t.Fatalf("foreign UUID got priority %d", p)
+	}
+	if got, want := l.Time(id), int64(0x019a2b3c4d5e); got != want {
+		t.Fatalf("time = %d, want %d", got, want)
+	}
+
+	if _, err := FromUUIDv7(UUID{}, l); err == nil {
+		t.Fatal("accepted a non-v7 UUID")
+	}
+	if _, err := FromULID("8ZZZZZZZZZZZZZZZZZZZZZZZZZ", l); err == nil {
+		t.Fatal("accepted an overflowing ULID")
+	}
+	if _, err := FromULID("0000000000000000000000000U", l); err == nil {
+		t.Fatal("accepted an invalid ULID character")
+	}
+}
+
+func TestConvertSlices(t *testing.T) {
+	n, err := NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := n.layout
+	ids, err := n.GenerateBatch(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	back, err := FromUUIDv7Slice(ToUUIDv7Slice(ids, l), l)
+	if err != nil || !slices.Equal(back, ids) {
+		t.Fatalf("UUIDv7 slice round trip = %v, %v", back, err)
+	}
+	back, err = FromULIDSlice(ToULIDSlice(ids, l), l)
+	if err != nil || !slices.Equal(back, ids) {
+		t.Fatalf("ULID slice round trip = %v, %v", back, err)
+	}
+
+	// Failed items are zero and reported by index
+	ulids := ToULIDSlice(ids[:3], l)
+	ulids[1] = "bad"
+	out, err := FromULIDSlice(ulids, l)
+	var ce *ConvertError
+	if !errors.As(err, &ce) || ce.Index != 1 {
+		t.Fatalf("error = %v, want a ConvertError for item 1", err)
+	}
+	if out[0] != ids[0] || out[1] != 0 || out[2] != ids[2] {
+		t.Fatalf("partial result = %v", out)
+	}
+	uuids := ToUUIDv7Slice(ids[:2], l)
+	uuids[0] = UUID{}
+	if _, err := FromUUIDv7Slice(uuids, l); !errors.As(err, &ce) || ce.Index != 0 {
+		t.Fatalf("error = %v, want a ConvertError for item 0", err)
+	}
+}
+
+func TestUUIDString(t *testing.T) {
+	const s = "019a2b3c-4d5e-7fff-bfff-ffffffffffff"
+	u, err := ParseUUID(s)
+	if err != nil || u.String() != s {
+		t.Fatalf("ParseUUID(%q).String() = %q, %v", s, u.String(), err)
+	}
+	for _, bad := range []string{"", "019a2b3c4d5e7fffbfffffffffffffff", "019a2b3c-4d5e-7fff-bfff-fffffffffffg"} {
+		if _, err := ParseUUID(bad); err == nil {
+			t.Errorf("ParseUUID(%q) succeeded", bad)
+		}
+	}
+}
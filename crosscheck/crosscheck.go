@@ -0,0 +1,248 @@
+// Package crosscheck validates ID layouts by differential testing: every ID
+// is composed and decomposed both with the shifts and masks mkey uses and
+// with an independent arithmetic implementation, and the results compared.
+//
+// It is meant for validating custom layouts before deploying them, e.g. from
+// a test or a startup check:
+//
+//	if err := crosscheck.Compare([]mkey.Layout{layout}, 10000); err != nil {
+//		log.Fatal(err)
+//	}
+package crosscheck
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/icehuntmen/mkey"
+)
+
+// maxReported caps the mismatches kept per Compare call
+const maxReported = 32
+
+// Mismatch is one component decoded differently by the two code paths
+type Mismatch struct {
+	Layout mkey.Layout
+	ID     mkey.ID
+
+	// Field is the component that differs, e.g. "time" or "node"
+	Field string
+
+	// Shift is the value from the shift-based path (mkey itself),
+	// Arith the value from the arithmetic reference
+	Shift, Arith int64
+}
+
+func (m Mismatch) String() string {
+	return fmt.Sprintf("layout %+v: id %d: %s: shift %d != arith %d", m.Layout, m.ID, m.Field, m.Shift, m.Arith)
+}
+
+// Error reports the mismatches found by Compare
+type Error struct {
+	// Mismatches holds up to 32 mismatches; Total counts all of them
+	Mismatches []Mismatch
+	Total      int
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "crosscheck: %d mismatches", e.Total)
+	for _, m := range e.Mismatches {
+		b.WriteString("\n\t")
+		b.WriteString(m.String())
+	}
+	return b.String()
+}
+
+// components are the fields of an ID in layout order
+type components struct {
+	priority, time, shard, tombstone, expiry, node, step int64
+}
+
+// Compare checks n random component tuples and n IDs generated by a real
+// Node for each layout. It returns an error for invalid layouts and an *Error
+// if any ID composes or decodes differently between the two code paths.
+func Compare(layouts []mkey.Layout, n int) error {
+	if n <= 0 {
+		return errors.New("n must be positive")
+	}
+
+	// A fixed seed keeps failures reproducible
+	rng := rand.New(rand.NewPCG(uint64(n), 0x6d6b6579))
+	e := &Error{}
+	for _, l := range layouts {
+		if err := l.Validate(); err != nil {
+			return fmt.Errorf("layout %+v: %w", l, err)
+		}
+		compareSynthetic(e, l, rng, n)
+		if err := compareGenerated(e, l, rng, n); err != nil {
+			return err
+		}
+	}
+
+	if e.Total > 0 {
+		return e
+	}
+	return nil
+}
+
+// compareSynthetic composes random components both ways and decodes the
+// result both ways
+func compareSynthetic(e *Error, l mkey.Layout, rng *rand.Rand, n int) {
+	for range n {
+		c := components{
+			priority: randBits(rng, l.PriorityBits),
+			time:     randBits(rng, l.TimeBits()),
+			shard:    randBits(rng, l.ShardBits),
+			expiry:   randBits(rng, l.ExpiryBits),
+			node:     randBits(rng, l.NodeBits),
+			step:     randBits(rng, l.StepBits),
+		}
+		if l.TombstoneBit {
+			c.tombstone = randBits(rng, 1)
+		}
+
+		id := composeShift(l, c)
+		if arith := composeArith(l, c); arith != id {
+			e.add(Mismatch{Layout: l, ID: id, Field: "compose", Shift: int64(id), Arith: int64(arith)})
+			continue
+		}
+		compareDecoded(e, l, id, c)
+	}
+}
+
+// compareGenerated decodes IDs from a real node both ways
+func compareGenerated(e *Error, l mkey.Layout, rng *rand.Rand, n int) error {
+	node := randBits(rng, l.NodeBits)
+	g, err := mkey.NewNodeWithConfig(&mkey.Config{
+		Epoch:        l.Epoch,
+		NodeBits:     l.NodeBits,
+		StepBits:     l.StepBits,
+		Node:         node,
+		ExpiryBits:   l.ExpiryBits,
+		ExpiryUnit:   l.ExpiryUnit,
+		PriorityBits: l.PriorityBits,
+		TombstoneBit: l.TombstoneBit,
+		ShardBits:    l.ShardBits,
+		ChildBits:    l.ChildBits,
+	})
+	if err != nil {
+		return fmt.Errorf("layout %+v: %w", l, err)
+	}
+
+	for range n {
+		id := g.Generate()
+		c := decomposeArith(l, id)
+		if c.node != node {
+			e.add(Mismatch{Layout: l, ID: id, Field: "generated node", Shift: node, Arith: c.node})
+		}
+		compareDecoded(e, l, id, c)
+	}
+	return nil
+}
+
+// compareDecoded decodes id with mkey and with the reference and checks both
+// against the expected components
+func compareDecoded(e *Error, l mkey.Layout, id mkey.ID, want components) {
+	got := decomposeArith(l, id)
+	check := func(field string, shift, arith, expected int64) {
+		if shift != arith || arith != expected {
+			e.add(Mismatch{Layout: l, ID: id, Field: field, Shift: shift, Arith: arith})
+		}
+	}
+
+	check("time", l.Time(id)-l.Epoch, got.time, want.time)
+	check("node", l.NodeID(id), got.node, want.node)
+	check("step", l.Step(id), got.step, want.step)
+	check("priority", int64(l.Priority(id)), got.priority, want.priority)
+	check("shard", l.Shard(id), got.shard, want.shard)
+	check("tombstone", boolBit(id.IsTombstone(l)), got.tombstone, want.tombstone)
+	check("expiry", expiryOffset(l, id), got.expiry, want.expiry)
+}
+
+// composeShift builds an ID the way mkey does, with shifts and ORs
+func composeShift(l mkey.Layout, c components) mkey.ID {
+	return mkey.ID(c.priority<<(63-l.PriorityBits) |
+		c.time<<l.TimeShift() |
+		c.shard<<(l.TimeShift()-l.ShardBits) |
+		c.tombstone<<(l.ExpiryBits+l.NodeBits+l.StepBits) |
+		c.expiry<<(l.NodeBits+l.StepBits) |
+		c.node<<l.StepBits |
+		c.step)
+}
+
+// composeArith builds an ID with multiplication and addition only
+func composeArith(l mkey.Layout, c components) mkey.ID {
+	v := c.priority
+	v = v*pow2(l.TimeBits()) + c.time
+	v = v*pow2(l.ShardBits) + c.shard
+	if l.TombstoneBit {
+		v = v*2 + c.tombstone
+	}
+	v = v*pow2(l.ExpiryBits) + c.expiry
+	v = v*pow2(l.NodeBits) + c.node
+	v = v*pow2(l.StepBits) + c.step
+	return mkey.ID(v)
+}
+
+// decomposeArith splits an ID with division and remainder only
+func decomposeArith(l mkey.Layout, id mkey.ID) components {
+	v := int64(id)
+	var c components
+	c.step, v = v%pow2(l.StepBits), v/pow2(l.StepBits)
+	c.node, v = v%pow2(l.NodeBits), v/pow2(l.NodeBits)
+	c.expiry, v = v%pow2(l.ExpiryBits), v/pow2(l.ExpiryBits)
+	if l.TombstoneBit {
+		c.tombstone, v = v%2, v/2
+	}
+	c.shard, v = v%pow2(l.ShardBits), v/pow2(l.ShardBits)
+	c.time, v = v%pow2(l.TimeBits()), v/pow2(l.TimeBits())
+	c.priority = v
+	return c
+}
+
+// expiryOffset recovers the expiry field through the public API
+func expiryOffset(l mkey.Layout, id mkey.ID) int64 {
+	at := id.ExpiresAt(l)
+	if at.IsZero() {
+		return 0
+	}
+	unit := l.ExpiryUnit
+	if unit == 0 {
+		unit = time.Second
+	}
+	return int64(at.Sub(l.Timestamp(id)) / unit)
+}
+
+// pow2 returns 2^k by repeated multiplication, independent of shifts
+func pow2(k uint8) int64 {
+	v := int64(1)
+	for range k {
+		v *= 2
+	}
+	return v
+}
+
+func randBits(rng *rand.Rand, bits uint8) int64 {
+	if bits == 0 {
+		return 0
+	}
+	return int64(rng.Uint64() >> (64 - bits))
+}
+
+func boolBit(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (e *Error) add(m Mismatch) {
+	if len(e.Mismatches) < maxReported {
+		e.Mismatches = append(e.Mismatches, m)
+	}
+	e.Total++
+}
@@ -0,0 +1,61 @@
+package crosscheck
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/icehuntmen/mkey"
+)
+
+func TestCompare(t *testing.T) {
+	base := mkey.DefaultLayout()
+	layouts := []mkey.Layout{base}
+	for _, tweak := range []func(*mkey.Layout){
+		func(l *mkey.Layout) { l.NodeBits, l.StepBits = 6, 16 },
+		func(l *mkey.Layout) { l.StepBits = 8; l.ExpiryBits = 4; l.ExpiryUnit = time.Minute },
+		func(l *mkey.Layout) { l.StepBits = 10; l.PriorityBits = 2 },
+		func(l *mkey.Layout) { l.StepBits = 11; l.TombstoneBit = true },
+		func(l *mkey.Layout) { l.StepBits = 8; l.ShardBits = 4; l.ChildBits = 2 },
+	} {
+		l := base
+		tweak(&l)
+		layouts = append(layouts, l)
+	}
+	if err := Compare(layouts, 500); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCompareInvalid(t *testing.T) {
+	if err := Compare([]mkey.Layout{mkey.DefaultLayout()}, 0); err == nil {
+		t.Error("n = 0 accepted")
+	}
+	bad := mkey.DefaultLayout()
+	bad.StepBits = 30
+	err := Compare([]mkey.Layout{bad}, 10)
+	var ce *Error
+	if err == nil || errors.As(err, &ce) {
+		t.Errorf("invalid layout: err = %v, want a validation error", err)
+	}
+}
+
+func TestMismatchReport(t *testing.T) {
+	l := mkey.DefaultLayout()
+	e := &Error{}
+	for i := range maxReported + 5 {
+		id := composeShift(l, components{time: 1, node: 2, step: int64(i)})
+		// expect the wrong node so every ID mismatches once
+		compareDecoded(e, l, id, components{time: 1, node: 3, step: int64(i)})
+	}
+	if e.Total != maxReported+5 || len(e.Mismatches) != maxReported {
+		t.Fatalf("Total %d, kept %d; want %d, %d", e.Total, len(e.Mismatches), maxReported+5, maxReported)
+	}
+	if m := e.Mismatches[0]; m.Field != "node" || m.Shift != 2 || m.Arith != 2 {
+		t.Fatalf("Mismatches[0] = %+v", m)
+	}
+	if msg := e.Error(); !strings.HasPrefix(msg, "crosscheck: 37 mismatches\n\t") || strings.Count(msg, "\n") != maxReported {
+		t.Fatalf("Error() = %q", msg)
+	}
+}
@@ -0,0 +1,63 @@
+package mkey
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// ErrNotInitialized is returned by the package-level functions before Init succeeded
+	ErrNotInitialized = errors.New("default node is not initialized; call mkey.Init first")
+
+	// ErrAlreadyInitialized is returned by Init after the default node was created
+	ErrAlreadyInitialized = errors.New("default node is already initialized")
+)
+
+var defaultNode struct {
+	once sync.Once
+	node atomic.Pointer[Node]
+	err  error
+}
+
+// Init creates the process-wide default node used by Generate. Only the first
+// call has an effect; later calls return ErrAlreadyInitialized, or the first
+// call's error if it failed.
+func Init(cfg *Config) error {
+	first := false
+	defaultNode.once.Do(func() {
+		first = true
+		n, err := NewNodeWithConfig(cfg)
+		if err != nil {
+			defaultNode.err = err
+			return
+		}
+		defaultNode.node.Store(n)
+	})
+
+	if first {
+		return defaultNode.err
+	}
+	if defaultNode.err != nil {
+		return defaultNode.err
+	}
+	return ErrAlreadyInitialized
+}
+
+// Default returns the node created by Init
+func Default() (*Node, error) {
+	n := defaultNode.node.Load()
+	if n == nil {
+		return nil, ErrNotInitialized
+	}
+	return n, nil
+}
+
+// Generate creates an ID on the default node
+func Generate() (ID, error) {
+	n := defaultNode.node.Load()
+	if n == nil {
+		return 0, ErrNotInitialized
+	}
+	return n.Generate(), nil
+}
@@ -0,0 +1,75 @@
+package mkey
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+// resetDefault clears the default node so each test starts uninitialized
+func resetDefault(t *testing.T) {
+	reset := func() {
+		defaultNode.once = sync.Once{}
+		defaultNode.node.Store(nil)
+		defaultNode.err = nil
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+func TestDefaultNode(t *testing.T) {
+	resetDefault(t)
+
+	if _, err := Generate(); !errors.Is(err, ErrNotInitialized) {
+		t.Fatalf("Generate before Init: err = %v", err)
+	}
+	if _, err := Default(); !errors.Is(err, ErrNotInitialized) {
+		t.Fatalf("Default before Init: err = %v", err)
+	}
+
+	cfg := NewConfig()
+	cfg.Node = 9
+	if err := Init(cfg); err != nil {
+		t.Fatal(err)
+	}
+	n, err := Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.Layout().NodeID(a) != 9 {
+		t.Fatalf("Generate used node %d, want 9", n.Layout().NodeID(a))
+	}
+	if b := n.Generate(); b <= a {
+		t.Fatal("Default and Generate do not share the node")
+	}
+
+	cfg.Node = 10
+	if err := Init(cfg); !errors.Is(err, ErrAlreadyInitialized) {
+		t.Fatalf("second Init: err = %v, want ErrAlreadyInitialized", err)
+	}
+	if d, _ := Default(); d != n {
+		t.Fatal("second Init replaced the default node")
+	}
+}
+
+func TestDefaultNodeInitError(t *testing.T) {
+	resetDefault(t)
+
+	cfg := NewConfig()
+	cfg.Node = -1
+	first := Init(cfg)
+	if first == nil {
+		t.Fatal("Init with an invalid node succeeded")
+	}
+	cfg.Node = 1
+	if err := Init(cfg); err != first {
+		t.Fatalf("Init after failure = %v, want the first error %v", err, first)
+	}
+	if _, err := Generate(); !errors.Is(err, ErrNotInitialized) {
+		t.Fatalf("Generate after failed Init: err = %v", err)
+	}
+}
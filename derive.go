@@ -0,0 +1,83 @@
+package mkey
+
+import (
+	"crypto/hkdf"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// deriveInfoPrefix separates node IDs from any other keys derived from the
+// same secret
+const deriveInfoPrefix = "mkey/node-id/"
+
+// DeriveNodeID deterministically derives a node ID for serviceName from a
+// secret shared by the fleet, using HKDF-SHA256. The result is stable across
+// restarts and hosts, never zero, and lies in [1, 2^bits-1]. Distinct names
+// collide with the probability reported by NodeIDCollisionProbability.
+func DeriveNodeID(secret, serviceName string, bits uint8) (int64, error) {
+	if bits == 0 || bits > MaxNodeBits {
+		return 0, fmt.Errorf("bits must be between 1 and %d", MaxNodeBits)
+	}
+	if secret == "" {
+		return 0, errors.New("secret must not be empty")
+	}
+	if serviceName == "" {
+		return 0, errors.New("service name must not be empty")
+	}
+
+	key, err := hkdf.Key(sha256.New, []byte(secret), nil, deriveInfoPrefix+serviceName, 8)
+	if err != nil {
+		return 0, fmt.Errorf("deriving node ID: %w", err)
+	}
+	max := uint64(1)<<bits - 1
+
+	// Map into [1, max] so a derived ID is never the unset value
+	return 1 + int64(binary.BigEndian.Uint64(key)%max), nil
+}
+
+// NodeIDCollisionProbability returns the probability that at least two of
+// services distinct names derive the same node ID with the given node bits
+func NodeIDCollisionProbability(services int, bits uint8) float64 {
+	if services < 2 {
+		return 0
+	}
+	slots := float64(int64(1)<<bits - 1)
+	if float64(services) > slots {
+		return 1
+	}
+
+	// Birthday problem: 1 - prod_{i<services} (slots-i)/slots
+	unique := 1.0
+	for i := 1; i < services; i++ {
+		unique *= (slots - float64(i)) / slots
+	}
+	return 1 - unique
+}
+
+// DeriveNodeIDs derives node IDs for a set of service names and reports
+// which names collide. The returned map holds every name's ID; collisions
+// maps each shared ID to the names that derived it.
+func DeriveNodeIDs(secret string, serviceNames []string, bits uint8) (ids map[string]int64, collisions map[int64][]string, err error) {
+	ids = make(map[string]int64, len(serviceNames))
+	byID := make(map[int64][]string, len(serviceNames))
+	for _, name := range serviceNames {
+		id, err := DeriveNodeID(secret, name, bits)
+		if err != nil {
+			return nil, nil, err
+		}
+		ids[name] = id
+		byID[id] = append(byID[id], name)
+	}
+
+	for id, names := range byID {
+		if len(names) > 1 {
+			if collisions == nil {
+				collisions = make(map[int64][]string)
+			}
+			collisions[id] = names
+		}
+	}
+	return ids, collisions, nil
+}
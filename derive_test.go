@@ -0,0 +1,92 @@
+package mkey
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestDeriveNodeID(t *testing.T) {
+	a, err := DeriveNodeID("fleet secret", "billing", DefaultNodeBits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := DeriveNodeID("fleet secret", "billing", DefaultNodeBits); a != b {
+		t.Fatalf("DeriveNodeID not stable: %d, %d", a, b)
+	}
+	if a < 1 || a > 1<<DefaultNodeBits-1 {
+		t.Fatalf("DeriveNodeID = %d, out of range", a)
+	}
+
+	// different names and secrets spread over the space
+	seen := map[int64]bool{a: true}
+	for _, in := range [][2]string{{"fleet secret", "orders"}, {"fleet secret", "users"}, {"other secret", "billing"}} {
+		id, err := DeriveNodeID(in[0], in[1], DefaultNodeBits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[id] = true
+	}
+	if len(seen) < 3 {
+		t.Fatalf("only %d distinct IDs from 4 inputs", len(seen))
+	}
+
+	if id, _ := DeriveNodeID("s", "x", 1); id != 1 {
+		t.Fatalf("DeriveNodeID with one bit = %d, want 1", id)
+	}
+	for name, f := range map[string]func() (int64, error){
+		"no bits":    func() (int64, error) { return DeriveNodeID("s", "x", 0) },
+		"many bits":  func() (int64, error) { return DeriveNodeID("s", "x", MaxNodeBits+1) },
+		"no secret":  func() (int64, error) { return DeriveNodeID("", "x", 10) },
+		"no service": func() (int64, error) { return DeriveNodeID("s", "", 10) },
+	} {
+		if _, err := f(); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
+
+func TestNodeIDCollisionProbability(t *testing.T) {
+	tests := []struct {
+		services int
+		bits     uint8
+		want     float64
+	}{
+		{0, 10, 0},
+		{1, 10, 0},
+		{2, 2, 1.0 / 3},
+		{3, 2, 1 - 2.0/3*1.0/3},
+		{4, 2, 1},
+	}
+	for _, tt := range tests {
+		if got := NodeIDCollisionProbability(tt.services, tt.bits); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("NodeIDCollisionProbability(%d, %d) = %v, want %v", tt.services, tt.bits, got, tt.want)
+		}
+	}
+	if p := NodeIDCollisionProbability(50, DefaultNodeBits); p < 0.6 || p > 0.75 {
+		t.Errorf("50 services on 10 bits: %v, want about 0.7", p)
+	}
+}
+
+func TestDeriveNodeIDs(t *testing.T) {
+	names := []string{"a", "b", "c"}
+	ids, collisions, err := DeriveNodeIDs("s", names, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 3 || ids["a"] != 1 {
+		t.Fatalf("ids = %v", ids)
+	}
+	got := slices.Sorted(slices.Values(collisions[1]))
+	if len(collisions) != 1 || !slices.Equal(got, names) {
+		t.Fatalf("collisions = %v, want all names on 1", collisions)
+	}
+
+	ids, collisions, err = DeriveNodeIDs("s", names, MaxNodeBits)
+	if err != nil || len(ids) != 3 || collisions != nil {
+		t.Fatalf("16 bits: ids %v, collisions %v, err %v", ids, collisions, err)
+	}
+	if _, _, err := DeriveNodeIDs("", names, 10); err == nil {
+		t.Fatal("empty secret accepted")
+	}
+}
@@ -0,0 +1,114 @@
+package mkey
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrNotLeader is returned when a replica that does not hold the issuing lease
+// is asked to issue IDs
+var ErrNotLeader = errors.New("replica is not the elected issuer")
+
+// Elector reports whether this replica currently holds the issuing lease for
+// a node ID shared by several replicas. Implementations typically wrap a
+// distributed lock with a lease (etcd, Consul, a database row lock).
+type Elector interface {
+	IsLeader() bool
+}
+
+// Forwarder obtains IDs from the current leader on behalf of a standby,
+// usually by calling the leader's ElectedNode.Serve over RPC
+type Forwarder interface {
+	Forward(count int) ([]ID, error)
+}
+
+// ElectionConfig configures an ElectedNode
+type ElectionConfig struct {
+	Elector   Elector
+	Forwarder Forwarder
+
+	// Handoff is how long a replica waits after becoming leader before it
+	// issues IDs. It must cover the clock skew between replicas so the new
+	// leader never reuses a millisecond the previous leader issued in.
+	Handoff time.Duration
+}
+
+// ElectedNode produces a strictly serialized ID stream for a node ID shared by
+// several replicas: the elected leader issues from its local Node while
+// standbys forward requests to it
+type ElectedNode struct {
+	node *Node
+	cfg  ElectionConfig
+
+	mu      sync.Mutex
+	leading bool
+	readyAt time.Time
+}
+
+// NewElectedNode wraps node, which must use the node ID shared by all replicas
+func NewElectedNode(node *Node, cfg ElectionConfig) (*ElectedNode, error) {
+	if cfg.Elector == nil {
+		return nil, errors.New("Elector is required")
+	}
+	if cfg.Forwarder == nil {
+		return nil, errors.New("Forwarder is required")
+	}
+	return &ElectedNode{node: node, cfg: cfg}, nil
+}
+
+// IsLeader reports whether this replica currently issues IDs itself
+func (e *ElectedNode) IsLeader() bool {
+	return e.cfg.Elector.IsLeader()
+}
+
+// Generate returns the next ID of the serialized stream, issuing it locally
+// on the leader or forwarding to the leader on a standby
+func (e *ElectedNode) Generate() (ID, error) {
+	ids, err := e.GenerateBatch(1)
+	if err != nil {
+		return 0, err
+	}
+	return ids[0], nil
+}
+
+// GenerateBatch returns count consecutive IDs of the serialized stream
+func (e *ElectedNode) GenerateBatch(count int) ([]ID, error) {
+	ids, err := e.Serve(count)
+	if errors.Is(err, ErrNotLeader) {
+		return e.cfg.Forwarder.Forward(count)
+	}
+	return ids, err
+}
+
+// Serve issues IDs locally if this replica is the leader and returns
+// ErrNotLeader otherwise. It is the handler standbys' Forwarders should reach.
+func (e *ElectedNode) Serve(count int) ([]ID, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.cfg.Elector.IsLeader() {
+		e.leading = false
+		return nil, ErrNotLeader
+	}
+	if !e.leading {
+		e.leading = true
+		e.readyAt = time.Now().Add(e.cfg.Handoff)
+	}
+	if wait := time.Until(e.readyAt); wait > 0 {
+		time.Sleep(wait)
+	}
+
+	ids, err := e.node.GenerateBatch(count)
+	if err != nil {
+		return nil, err
+	}
+
+	// The lease may have been lost while generating; discard rather than
+	// risk interleaving with a new leader
+	if !e.cfg.Elector.IsLeader() {
+		e.leading = false
+		return nil, ErrNotLeader
+	}
+	return ids, nil
+}
@@ -0,0 +1,123 @@
+package mkey
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type flagElector struct{ leader atomic.Bool }
+
+func (f *flagElector) IsLeader() bool { return f.leader.Load() }
+
+// replicaForwarder forwards to another replica's Serve, as an RPC client would
+type replicaForwarder struct{ to *ElectedNode }
+
+func (f *replicaForwarder) Forward(count int) ([]ID, error) {
+	if f.to == nil {
+		return nil, ErrNotLeader
+	}
+	return f.to.Serve(count)
+}
+
+func newReplicas(t *testing.T, handoff time.Duration) (a, b *ElectedNode, ea, eb *flagElector) {
+	t.Helper()
+	ea, eb = &flagElector{}, &flagElector{}
+	fa, fb := &replicaForwarder{}, &replicaForwarder{}
+	for i, r := range []**ElectedNode{&a, &b} {
+		n, err := NewNode(7)
+		if err != nil {
+			t.Fatal(err)
+		}
+		el, fw := Elector(ea), fb
+		if i == 1 {
+			el, fw = eb, fa
+		}
+		e, err := NewElectedNode(n, ElectionConfig{Elector: el, Forwarder: fw, Handoff: handoff})
+		if err != nil {
+			t.Fatal(err)
+		}
+		*r = e
+	}
+	fa.to, fb.to = a, b
+	return a, b, ea, eb
+}
+
+func TestElectedNodeForwardsToLeader(t *testing.T) {
+	a, b, ea, _ := newReplicas(t, 0)
+	ea.leader.Store(true)
+
+	if !a.IsLeader() || b.IsLeader() {
+		t.Fatal("IsLeader does not follow the elector")
+	}
+	var prev ID
+	for i := range 100 {
+		r := a
+		if i%2 == 1 {
+			r = b
+		}
+		id, err := r.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id <= prev {
+			t.Fatalf("stream not serialized: %d after %d", id, prev)
+		}
+		prev = id
+	}
+	if _, err := b.Serve(1); !errors.Is(err, ErrNotLeader) {
+		t.Fatalf("standby Serve = %v, want ErrNotLeader", err)
+	}
+}
+
+func TestElectedNodeHandoff(t *testing.T) {
+	a, b, ea, eb := newReplicas(t, 30*time.Millisecond)
+	ea.leader.Store(true)
+	last, err := a.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Failover: the new leader waits out the handoff before issuing
+	ea.leader.Store(false)
+	eb.leader.Store(true)
+	start := time.Now()
+	ids, err := a.GenerateBatch(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Fatalf("new leader issued after %v, before the handoff", elapsed)
+	}
+	if ids[0] <= last {
+		t.Fatalf("new leader issued %d, not after %d", ids[0], last)
+	}
+
+	// Once leading, no further delay
+	start = time.Now()
+	if _, err := b.Generate(); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed >= 30*time.Millisecond {
+		t.Fatalf("steady-state leader waited %v", elapsed)
+	}
+}
+
+func TestElectedNodeNoLeader(t *testing.T) {
+	a, _, _, _ := newReplicas(t, 0)
+	if _, err := a.Generate(); !errors.Is(err, ErrNotLeader) {
+		t.Fatalf("Generate without a leader = %v, want ErrNotLeader", err)
+	}
+
+	n, err := NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewElectedNode(n, ElectionConfig{Forwarder: &replicaForwarder{}}); err == nil {
+		t.Error("accepted a missing Elector")
+	}
+	if _, err := NewElectedNode(n, ElectionConfig{Elector: &flagElector{}}); err == nil {
+		t.Error("accepted a missing Forwarder")
+	}
+}
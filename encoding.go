@@ -0,0 +1,378 @@
+package mkey
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+const (
+	encodeHexMap       = "0123456789abcdef"
+	encodeBase32StdMap = "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567"
+
+	// encodeBase62Map is in ASCII order so fixed-width Base62 sorts like the IDs
+	encodeBase62Map = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+const (
+	// HexWidth is the length of a zero-padded hex ID covering every 64-bit value
+	HexWidth = 16
+
+	// Base32StdWidth is the length of a zero-padded standard base32 ID covering every 64-bit value
+	Base32StdWidth = 13
+
+	// Base62Width is the length of a zero-padded Base62 ID covering every 64-bit value
+	Base62Width = 11
+)
+
+var (
+	decodeHexMap       [256]byte
+	decodeBase32StdMap [256]byte
+	decodeBase62Map    [256]byte
+)
+
+func init() {
+	initDecodeMap(encodeHexMap, &decodeHexMap)
+	for i := 10; i < 16; i++ {
+		decodeHexMap['A'+i-10] = byte(i)
+	}
+	initDecodeMap(encodeBase62Map, &decodeBase62Map)
+	initDecodeMap(encodeBase32StdMap, &decodeBase32StdMap)
+	for i := 0; i < 26; i++ {
+		decodeBase32StdMap['a'+i] = byte(i)
+	}
+}
+
+// LetterCase selects the case of letters in encoded output
+type LetterCase uint8
+
+const (
+	// CaseDefault uses the conventional case of the encoding:
+	// lower for hex, upper for standard base32
+	CaseDefault LetterCase = iota
+
+	// CaseLower forces lower case letters
+	CaseLower
+
+	// CaseUpper forces upper case letters
+	CaseUpper
+)
+
+// FormatOptions controls the width and letter case of Hex and Base32Std output
+type FormatOptions struct {
+	// Width is the minimum output length; shorter values are left-padded with
+	// the encoding's zero digit. Use HexWidth or Base32StdWidth for fixed-width output.
+	Width int
+
+	// Case selects the letter case
+	Case LetterCase
+}
+
+// Hex returns a lower case hexadecimal string representation
+func (f ID) Hex() string {
+	return f.HexWith(FormatOptions{})
+}
+
+// HexWith returns a hexadecimal string formatted according to o
+func (f ID) HexWith(o FormatOptions) string {
+	return formatPow2(uint64(f), 4, encodeHexMap, o, o.Case == CaseUpper)
+}
+
+// Base32Std returns a base32 string using the RFC 4648 alphabet, without padding
+// characters. The alphabet puts letters before digits, so the output does not
+// sort like the IDs even at fixed width; use Hex or Base62 for sortable keys.
+func (f ID) Base32Std() string {
+	return f.Base32StdWith(FormatOptions{})
+}
+
+// Base32StdWith returns a standard base32 string formatted according to o
+func (f ID) Base32StdWith(o FormatOptions) string {
+	return formatPow2(uint64(f), 5, encodeBase32StdMap, o, o.Case != CaseLower)
+}
+
+// formatPow2 encodes v in base 2^bits using alphabet, which is defined in lower
+// or upper case depending on the encoding
+func formatPow2(v uint64, bits uint, alphabet string, o FormatOptions, upper bool) string {
+	var buf [64]byte
+	mask := uint64(1)<<bits - 1
+
+	i := len(buf)
+	for {
+		i--
+		buf[i] = alphabet[v&mask]
+		v >>= bits
+		if v == 0 {
+			break
+		}
+	}
+	for len(buf)-i < o.Width && i > 0 {
+		i--
+		buf[i] = alphabet[0]
+	}
+
+	s := string(buf[i:])
+	if upper {
+		return strings.ToUpper(s)
+	}
+	return strings.ToLower(s)
+}
+
+// ParseHex parses a hexadecimal ID in either case, with or without zero padding
+func ParseHex(b []byte) (ID, error) {
+	return parsePow2(b, 4, &decodeHexMap, "invalid hex character")
+}
+
+// ParseBase32Std parses a standard base32 ID in either case, with or without zero padding
+func ParseBase32Std(b []byte) (ID, error) {
+	return parsePow2(b, 5, &decodeBase32StdMap, "invalid base32 character")
+}
+
+func parsePow2(b []byte, bits uint, decodeMap *[256]byte, invalid string) (ID, error) {
+	if len(b) == 0 {
+		return 0, errors.New("empty ID")
+	}
+
+	var id uint64
+	for _, c := range b {
+		if decodeMap[c] == 0xFF {
+			return 0, errors.New(invalid)
+		}
+		if id>>(64-bits) != 0 {
+			return 0, errors.New("ID overflows 64 bits")
+		}
+		id = id<<bits | uint64(decodeMap[c])
+	}
+	return ID(id), nil
+}
+
+// Base62 returns a base62 string using the digits, upper case and lower case
+// letters in ASCII order
+func (f ID) Base62() string {
+	return formatBase62(uint64(f), 0)
+}
+
+// formatBase62 encodes v in base62, left-padded with '0' to width
+func formatBase62(v uint64, width int) string {
+	var buf [Base62Width]byte
+	i := len(buf)
+	for {
+		i--
+		buf[i] = encodeBase62Map[v%62]
+		v /= 62
+		if v == 0 {
+			break
+		}
+	}
+	for len(buf)-i < width && i > 0 {
+		i--
+		buf[i] = encodeBase62Map[0]
+	}
+	return string(buf[i:])
+}
+
+// ParseBase62 parses a base62 ID, with or without zero padding
+func ParseBase62(b []byte) (ID, error) {
+	v, err := parseBase62(b)
+	return ID(v), err
+}
+
+func parseBase62(b []byte) (uint64, error) {
+	if len(b) == 0 {
+		return 0, errors.New("empty ID")
+	}
+
+	var v uint64
+	for _, c := range b {
+		d := decodeBase62Map[c]
+		if d == 0xFF {
+			return 0, errors.New("invalid base62 character")
+		}
+		if v > (math.MaxUint64-uint64(d))/62 {
+			return 0, errors.New("ID overflows 64 bits")
+		}
+		v = v*62 + uint64(d)
+	}
+	return v, nil
+}
+
+// Encoding names a string representation of IDs
+type Encoding uint8
+
+const (
+	// EncodingDecimal is the decimal form returned by ID.String
+	EncodingDecimal Encoding = iota
+	EncodingBase2
+	EncodingBase32
+	EncodingBase32Std
+	EncodingBase58
+	EncodingBase62
+	EncodingBase64
+	EncodingHex
+)
+
+var encodingNames = [...]string{
+	EncodingDecimal:   "decimal",
+	EncodingBase2:     "base2",
+	EncodingBase32:    "base32",
+	EncodingBase32Std: "base32std",
+	EncodingBase58:    "base58",
+	EncodingBase62:    "base62",
+	EncodingBase64:    "base64",
+	EncodingHex:       "hex",
+}
+
+// String returns the encoding name as accepted by ParseEncoding
+func (e Encoding) String() string {
+	if int(e) < len(encodingNames) {
+		return encodingNames[e]
+	}
+	return "Encoding(" + strconv.Itoa(int(e)) + ")"
+}
+
+// ParseEncoding returns the encoding with the given name, e.g. from a config file
+func ParseEncoding(name string) (Encoding, error) {
+	for i, n := range encodingNames {
+		if strings.EqualFold(n, name) {
+			return Encoding(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown encoding %q", name)
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (e Encoding) MarshalText() ([]byte, error) {
+	if int(e) >= len(encodingNames) {
+		return nil, fmt.Errorf("unknown encoding %d", e)
+	}
+	return []byte(e.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (e *Encoding) UnmarshalText(b []byte) error {
+	v, err := ParseEncoding(string(b))
+	if err != nil {
+		return err
+	}
+	*e = v
+	return nil
+}
+
+// Encode returns id in this encoding
+func (e Encoding) Encode(id ID) string {
+	switch e {
+	case EncodingBase2:
+		return id.Base2()
+	case EncodingBase32:
+		return id.Base32()
+	case EncodingBase32Std:
+		return id.Base32Std()
+	case EncodingBase58:
+		return id.Base58()
+	case EncodingBase62:
+		return id.Base62()
+	case EncodingBase64:
+		return id.Base64()
+	case EncodingHex:
+		return id.Hex()
+	}
+	return id.String()
+}
+
+// Parse parses s in this encoding
+func (e Encoding) Parse(s string) (ID, error) {
+	switch e {
+	case EncodingDecimal:
+		v, err := strconv.ParseInt(s, 10, 64)
+		return ID(v), err
+	case EncodingBase2:
+		v, err := strconv.ParseInt(s, 2, 64)
+		return ID(v), err
+	case EncodingBase32:
+		return ParseBase32([]byte(s))
+	case EncodingBase32Std:
+		return ParseBase32Std([]byte(s))
+	case EncodingBase58:
+		return ParseBase58([]byte(s))
+	case EncodingBase62:
+		return ParseBase62([]byte(s))
+	case EncodingBase64:
+		return ParseBase64([]byte(s))
+	case EncodingHex:
+		return ParseHex([]byte(s))
+	}
+	return 0, fmt.Errorf("unknown encoding %d", e)
+}
+
+// encodingMaxLen holds the length of the longest non-negative ID, 2^63-1, in each encoding
+var encodingMaxLen = [...]int{
+	EncodingDecimal:   19,
+	EncodingBase2:     63,
+	EncodingBase32:    13,
+	EncodingBase32Std: Base32StdWidth,
+	EncodingBase58:    11,
+	EncodingBase62:    Base62Width,
+	EncodingBase64:    11,
+	EncodingHex:       HexWidth,
+}
+
+// MaxLen returns the longest string Encode produces for a non-negative ID,
+// which covers every ID a Node issues. Use it to size VARCHAR columns and
+// fixed buffers. It returns 0 for an unknown encoding.
+func (e Encoding) MaxLen() int {
+	if int(e) < len(encodingMaxLen) {
+		return encodingMaxLen[e]
+	}
+	return 0
+}
+
+// Len returns len(e.Encode(id)) without encoding the ID
+func (e Encoding) Len(id ID) int {
+	if id < 0 {
+		return len(e.Encode(id))
+	}
+	v := uint64(id)
+	n := bits.Len64(v)
+
+	switch e {
+	case EncodingBase2:
+		return max(n, 1)
+	case EncodingBase32Std:
+		return max((n+4)/5, 1)
+	case EncodingHex:
+		return max((n+3)/4, 1)
+	case EncodingBase64:
+		// Leading zero bytes are trimmed, so zero encodes to ""
+		return base64.RawURLEncoding.EncodedLen((n + 7) / 8)
+	case EncodingBase32:
+		return digits(v, 32)
+	case EncodingBase58:
+		return digits(v, 58)
+	case EncodingBase62:
+		return digits(v, 62)
+	}
+	return digits(v, 10)
+}
+
+// digits returns the number of base-b digits of v, at least 1
+func digits(v, b uint64) int {
+	n := 1
+	for v >= b {
+		v /= b
+		n++
+	}
+	return n
+}
+
+// Format returns id in the node's Config.DefaultEncoding
+func (n *Node) Format(id ID) string {
+	return n.encoding.Encode(id)
+}
+
+// Parse parses s in the node's Config.DefaultEncoding
+func (n *Node) Parse(s string) (ID, error) {
+	return n.encoding.Parse(s)
+}
@@ -0,0 +1,189 @@
+package mkey
+
+import (
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestHexWith(t *testing.T) {
+	tests := []struct {
+		id   ID
+		o    FormatOptions
+		want string
+	}{
+		{0, FormatOptions{}, "0"},
+		{0xABC, FormatOptions{}, "abc"},
+		{0xABC, FormatOptions{Case: CaseUpper}, "ABC"},
+		{0xABC, FormatOptions{Width: 6}, "000abc"},
+		{0xABC, FormatOptions{Width: HexWidth, Case: CaseUpper}, "0000000000000ABC"},
+		{0xABC, FormatOptions{Width: 2}, "abc"},
+		{-1, FormatOptions{}, "ffffffffffffffff"},
+	}
+	for _, tt := range tests {
+		if got := tt.id.HexWith(tt.o); got != tt.want {
+			t.Errorf("%d.HexWith(%+v) = %q, want %q", tt.id, tt.o, got, tt.want)
+		}
+	}
+}
+
+func TestBase32StdWith(t *testing.T) {
+	tests := []struct {
+		id   ID
+		o    FormatOptions
+		want string
+	}{
+		{0, FormatOptions{}, "A"},
+		{1, FormatOptions{}, "B"},
+		{26, FormatOptions{}, "2"},
+		{32, FormatOptions{}, "BA"},
+		{32, FormatOptions{Case: CaseLower}, "ba"},
+		{32, FormatOptions{Width: 4}, "AABA"},
+		{-1, FormatOptions{}, "P777777777777"},
+	}
+	for _, tt := range tests {
+		if got := tt.id.Base32StdWith(tt.o); got != tt.want {
+			t.Errorf("%d.Base32StdWith(%+v) = %q, want %q", tt.id, tt.o, got, tt.want)
+		}
+	}
+	if len(ID(-1).Base32Std()) != Base32StdWidth {
+		t.Errorf("Base32StdWidth %d does not cover every value", Base32StdWidth)
+	}
+}
+
+func TestPow2RoundTrip(t *testing.T) {
+	for range 10000 {
+		id := ID(rand.Uint64())
+		if got := id.Hex(); got != strconv.FormatUint(uint64(id), 16) {
+			t.Fatalf("%d.Hex() = %q", id, got)
+		}
+		for _, o := range []FormatOptions{{}, {Width: HexWidth, Case: CaseUpper}} {
+			s := id.HexWith(o)
+			if got, err := ParseHex([]byte(s)); err != nil || got != id {
+				t.Fatalf("ParseHex(%q) = %d, %v, want %d", s, got, err, id)
+			}
+		}
+		for _, o := range []FormatOptions{{}, {Width: Base32StdWidth, Case: CaseLower}} {
+			s := id.Base32StdWith(o)
+			if got, err := ParseBase32Std([]byte(s)); err != nil || got != id {
+				t.Fatalf("ParseBase32Std(%q) = %d, %v, want %d", s, got, err, id)
+			}
+		}
+	}
+}
+
+func TestParsePow2Rejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		parse func([]byte) (ID, error)
+		in    string
+	}{
+		{"hex empty", ParseHex, ""},
+		{"hex invalid", ParseHex, "12g"},
+		{"hex overflow", ParseHex, "1" + strings.Repeat("0", 16)},
+		{"base32 empty", ParseBase32Std, ""},
+		{"base32 invalid", ParseBase32Std, "AB1"},
+		{"base32 padding char", ParseBase32Std, "AB="},
+		{"base32 overflow", ParseBase32Std, "Q" + strings.Repeat("A", 12)},
+	}
+	for _, tt := range tests {
+		if id, err := tt.parse([]byte(tt.in)); err == nil {
+			t.Errorf("%s: parsed %q as %d", tt.name, tt.in, id)
+		}
+	}
+}
+
+func TestEncodingRoundTrip(t *testing.T) {
+	ids := []ID{0, 1, 61, 62, 1 << 40, 1<<63 - 1}
+	for i := range len(encodingNames) {
+		e := Encoding(i)
+		got, err := ParseEncoding(strings.ToUpper(e.String()))
+		if err != nil || got != e {
+			t.Fatalf("ParseEncoding(%q) = %v, %v", e.String(), got, err)
+		}
+		for _, id := range ids {
+			if id == 0 && e == EncodingBase64 {
+				continue // zero encodes to ""
+			}
+			s := e.Encode(id)
+			if back, err := e.Parse(s); err != nil || back != id {
+				t.Errorf("%v: Parse(%q) = %d, %v; want %d", e, s, back, err, id)
+			}
+		}
+	}
+
+	if _, err := ParseEncoding("base36"); err == nil {
+		t.Error("ParseEncoding accepted an unknown name")
+	}
+	bad := Encoding(len(encodingNames))
+	if _, err := bad.MarshalText(); err == nil {
+		t.Error("MarshalText accepted an unknown encoding")
+	}
+	if _, err := bad.Parse("1"); err == nil {
+		t.Error("Parse accepted an unknown encoding")
+	}
+	if bad.String() != "Encoding(8)" {
+		t.Errorf("String() = %q", bad.String())
+	}
+}
+
+func TestEncodingText(t *testing.T) {
+	var e Encoding
+	if err := e.UnmarshalText([]byte("base62")); err != nil || e != EncodingBase62 {
+		t.Fatalf("UnmarshalText = %v, %v", e, err)
+	}
+	if b, err := e.MarshalText(); err != nil || string(b) != "base62" {
+		t.Fatalf("MarshalText = %q, %v", b, err)
+	}
+	if err := e.UnmarshalText([]byte("nope")); err == nil || e != EncodingBase62 {
+		t.Fatalf("UnmarshalText of a bad name = %v, left %v", err, e)
+	}
+}
+
+func TestNodeFormat(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Node = 1
+	cfg.DefaultEncoding = EncodingBase62
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := n.Generate()
+	s := n.Format(id)
+	if s != id.Base62() {
+		t.Fatalf("Format = %q, want Base62 %q", s, id.Base62())
+	}
+	if back, err := n.Parse(s); err != nil || back != id {
+		t.Fatalf("Parse(%q) = %v, %v", s, back, err)
+	}
+
+	plain, _ := NewNode(2)
+	if id := plain.Generate(); plain.Format(id) != id.String() {
+		t.Fatal("default node does not format as decimal")
+	}
+}
+
+func TestEncodingLen(t *testing.T) {
+	ids := []ID{0, 1, 31, 32, 57, 58, 61, 62, 255, 256, 1 << 40, 1<<63 - 1, -1}
+	for range 1000 {
+		ids = append(ids, ID(rand.Int64()>>rand.IntN(63)))
+	}
+	for i := range len(encodingNames) {
+		e := Encoding(i)
+		for _, id := range ids {
+			if got, want := e.Len(id), len(e.Encode(id)); got != want {
+				t.Errorf("%v.Len(%d) = %d, want %d", e, id, got, want)
+			}
+			if id >= 0 && e.Len(id) > e.MaxLen() {
+				t.Errorf("%v: %d encodes longer than MaxLen %d", e, id, e.MaxLen())
+			}
+		}
+		if got := len(e.Encode(1<<63 - 1)); got != e.MaxLen() {
+			t.Errorf("%v.MaxLen() = %d, but the largest ID takes %d", e, e.MaxLen(), got)
+		}
+	}
+	if got := Encoding(len(encodingNames)).MaxLen(); got != 0 {
+		t.Errorf("MaxLen of an unknown encoding = %d", got)
+	}
+}
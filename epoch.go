@@ -0,0 +1,77 @@
+package mkey
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Well-known epochs in milliseconds since the Unix epoch
+const (
+	// TwitterEpoch is the epoch of Twitter snowflake IDs (Nov 04 2010 01:42:54.657 UTC)
+	TwitterEpoch int64 = 1288834974657
+
+	// DiscordEpoch is the epoch of Discord snowflake IDs (Jan 01 2015 00:00:00 UTC)
+	DiscordEpoch int64 = 1420070400000
+)
+
+var (
+	epochsMu sync.RWMutex
+	epochs   = map[string]int64{
+		"manticora": DefaultEpoch,
+		"twitter":   TwitterEpoch,
+		"discord":   DiscordEpoch,
+		"unix":      0,
+	}
+)
+
+// RegisterEpoch adds a named epoch, so configs and CLI flags can refer to it
+// by name. Names are case-insensitive and cannot be redefined.
+func RegisterEpoch(name string, epoch int64) error {
+	key := strings.ToLower(name)
+	if key == "" {
+		return errors.New("epoch name must not be empty")
+	}
+	if _, err := strconv.ParseInt(key, 10, 64); err == nil {
+		return fmt.Errorf("epoch name %q must not be a number", name)
+	}
+
+	epochsMu.Lock()
+	defer epochsMu.Unlock()
+	if _, ok := epochs[key]; ok {
+		return fmt.Errorf("epoch %q is already registered", name)
+	}
+	epochs[key] = epoch
+	return nil
+}
+
+// LookupEpoch returns the epoch registered under name
+func LookupEpoch(name string) (int64, bool) {
+	epochsMu.RLock()
+	defer epochsMu.RUnlock()
+	epoch, ok := epochs[strings.ToLower(name)]
+	return epoch, ok
+}
+
+// ParseEpoch accepts either a registered epoch name or milliseconds since the Unix epoch
+func ParseEpoch(s string) (int64, error) {
+	if epoch, ok := LookupEpoch(s); ok {
+		return epoch, nil
+	}
+	epoch, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unknown epoch %q", s)
+	}
+	return epoch, nil
+}
+
+// EpochNames returns the registered epoch names in sorted order
+func EpochNames() []string {
+	epochsMu.RLock()
+	defer epochsMu.RUnlock()
+	return slices.Sorted(maps.Keys(epochs))
+}
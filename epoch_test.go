@@ -0,0 +1,77 @@
+package mkey
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestEpochPresets(t *testing.T) {
+	for name, want := range map[string]int64{
+		"manticora": DefaultEpoch,
+		"Twitter":   TwitterEpoch,
+		"DISCORD":   DiscordEpoch,
+		"unix":      0,
+	} {
+		if got, ok := LookupEpoch(name); !ok || got != want {
+			t.Errorf("LookupEpoch(%q) = %d, %v; want %d", name, got, ok, want)
+		}
+	}
+	if _, ok := LookupEpoch("nope"); ok {
+		t.Error("LookupEpoch found an unregistered name")
+	}
+
+	if got, err := ParseEpoch("discord"); err != nil || got != DiscordEpoch {
+		t.Errorf("ParseEpoch(discord) = %d, %v", got, err)
+	}
+	if got, err := ParseEpoch("1700000000000"); err != nil || got != 1700000000000 {
+		t.Errorf("ParseEpoch(ms) = %d, %v", got, err)
+	}
+	if _, err := ParseEpoch("yesterday"); err == nil {
+		t.Error("ParseEpoch accepted an unknown name")
+	}
+}
+
+func TestRegisterEpoch(t *testing.T) {
+	const name = "test-epoch-482"
+	if err := RegisterEpoch("Test-Epoch-482", 1700000000000); err != nil {
+		t.Fatal(err)
+	}
+	// registrations are process-wide, so remove it for repeated runs
+	t.Cleanup(func() {
+		epochsMu.Lock()
+		defer epochsMu.Unlock()
+		delete(epochs, name)
+	})
+	if got, ok := LookupEpoch(name); !ok || got != 1700000000000 {
+		t.Fatalf("LookupEpoch = %d, %v", got, ok)
+	}
+	if !slices.Contains(EpochNames(), name) || !slices.IsSorted(EpochNames()) {
+		t.Fatalf("EpochNames() = %v", EpochNames())
+	}
+
+	for _, bad := range []string{name, "TWITTER", "", "12345"} {
+		if err := RegisterEpoch(bad, 1); err == nil {
+			t.Errorf("RegisterEpoch(%q) succeeded", bad)
+		}
+	}
+
+	cfg := NewConfig()
+	cfg.EpochName = name
+	cfg.Node = 1
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := n.Layout(); l.Epoch != 1700000000000 {
+		t.Fatalf("node epoch = %d, want the registered epoch", l.Epoch)
+	}
+	if d := time.Since(n.Layout().Timestamp(n.Generate())); d < 0 || d > time.Minute {
+		t.Fatalf("ID time off by %v", d)
+	}
+
+	cfg.EpochName = "nope"
+	if _, err := NewNodeWithConfig(cfg); err == nil {
+		t.Fatal("unknown EpochName accepted")
+	}
+}
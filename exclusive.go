@@ -0,0 +1,72 @@
+package mkey
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+)
+
+// ErrNodeInUse is returned by NewNodeWithConfig with Config.Exclusive when a
+// live node in the process already uses the same node ID and layout
+var ErrNodeInUse = errors.New("node ID is already in use in this process")
+
+// exclusiveKey identifies the ID space of a node
+type exclusiveKey struct {
+	fingerprint uint32
+	node        int64
+}
+
+// exclusiveToken is the registry entry of one node; entries are compared by
+// token so a collected node never removes its successor's entry
+type exclusiveToken struct{}
+
+var (
+	exclusiveMu    sync.Mutex
+	exclusiveNodes = map[exclusiveKey]*exclusiveToken{}
+)
+
+// registerExclusive records n in the process-wide registry of
+// Config.Exclusive nodes. A duplicate fails with ErrNodeInUse unless
+// onDuplicate is set, in which case it is reported and n is not registered.
+func registerExclusive(n *Node, onDuplicate func(node int64, l Layout)) error {
+	tok, err := claimExclusive(n.layout, n.node, onDuplicate)
+	if tok == nil {
+		return err
+	}
+	n.exclusive = tok
+	key := exclusiveKey{fingerprint: n.layout.Fingerprint(), node: n.node}
+	runtime.AddCleanup(n, func(tok *exclusiveToken) { releaseExclusive(key, tok) }, tok)
+	return nil
+}
+
+// claimExclusive adds the registry entry for node under l and returns its
+// token, or nil if there already is one, see registerExclusive
+func claimExclusive(l Layout, node int64, onDuplicate func(node int64, l Layout)) (*exclusiveToken, error) {
+	key := exclusiveKey{fingerprint: l.Fingerprint(), node: node}
+
+	exclusiveMu.Lock()
+	_, dup := exclusiveNodes[key]
+	var tok *exclusiveToken
+	if !dup {
+		tok = &exclusiveToken{}
+		exclusiveNodes[key] = tok
+	}
+	exclusiveMu.Unlock()
+
+	if dup {
+		if onDuplicate == nil {
+			return nil, ErrNodeInUse
+		}
+		onDuplicate(node, l)
+	}
+	return tok, nil
+}
+
+// releaseExclusive removes the registry entry made for tok
+func releaseExclusive(key exclusiveKey, tok *exclusiveToken) {
+	exclusiveMu.Lock()
+	defer exclusiveMu.Unlock()
+	if exclusiveNodes[key] == tok {
+		delete(exclusiveNodes, key)
+	}
+}
@@ -0,0 +1,60 @@
+package mkey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// expiryUnit returns ExpiryUnit or its default of one second
+func (l Layout) expiryUnit() time.Duration {
+	if l.ExpiryUnit == 0 {
+		return time.Second
+	}
+	return l.ExpiryUnit
+}
+
+// MaxTTL returns the longest TTL the layout can encode, 0 if it has no expiry bits
+func (l Layout) MaxTTL() time.Duration {
+	return time.Duration(int64(1)<<l.ExpiryBits-1) * l.expiryUnit()
+}
+
+// expiryOffset returns the expiry field of id
+func (l Layout) expiryOffset(id ID) int64 {
+	return (int64(id) >> (l.NodeBits + l.StepBits)) & (-1 ^ (-1 << l.ExpiryBits))
+}
+
+// GenerateWithTTL creates an ID that carries its own expiration, ttl after
+// the creation time rounded up to the layout's ExpiryUnit
+func (n *Node) GenerateWithTTL(ttl time.Duration) (ID, error) {
+	if n.layout.ExpiryBits == 0 {
+		return 0, errors.New("layout has no expiry bits")
+	}
+	if ttl <= 0 {
+		return 0, errors.New("ttl must be positive")
+	}
+	if max := n.layout.MaxTTL(); ttl > max {
+		return 0, fmt.Errorf("ttl must be <= %s", max)
+	}
+
+	unit := n.layout.expiryUnit()
+	offset := int64((ttl + unit - 1) / unit)
+	return n.generateContext(context.Background(), offset<<(n.layout.NodeBits+n.layout.StepBits))
+}
+
+// ExpiresAt returns the expiration time embedded in the ID,
+// or the zero time if the ID does not expire
+func (f ID) ExpiresAt(l Layout) time.Time {
+	offset := l.expiryOffset(f)
+	if offset == 0 {
+		return time.Time{}
+	}
+	return l.Timestamp(f).Add(time.Duration(offset) * l.expiryUnit())
+}
+
+// Expired reports whether the ID carries an expiration that has passed
+func (f ID) Expired(l Layout) bool {
+	exp := f.ExpiresAt(l)
+	return !exp.IsZero() && !time.Now().Before(exp)
+}
@@ -0,0 +1,81 @@
+package mkey
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGenerateWithTTL(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	cfg := NewConfig()
+	cfg.Clock = clock
+	cfg.ExpiryBits, cfg.NodeBits = 8, 2
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := n.layout
+	if l.MaxTTL() != 255*time.Second {
+		t.Fatalf("MaxTTL = %v, want 255s", l.MaxTTL())
+	}
+
+	id, err := n.GenerateWithTTL(1500 * time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The TTL rounds up to the one-second unit
+	if got := id.ExpiresAt(l).Sub(l.Timestamp(id)); got != 2*time.Second {
+		t.Fatalf("expires %v after creation, want 2s", got)
+	}
+	if l.NodeID(id) != cfg.Node {
+		t.Fatalf("expiry field leaked into the node ID: %d", l.NodeID(id))
+	}
+
+	plain := n.Generate()
+	if !plain.ExpiresAt(l).IsZero() || plain.Expired(l) {
+		t.Fatal("plain ID reports an expiry")
+	}
+	if id.Expired(l) {
+		t.Fatal("fresh ID already expired")
+	}
+}
+
+func TestExpired(t *testing.T) {
+	cfg := NewConfig()
+	cfg.ExpiryBits, cfg.NodeBits = 4, 4
+	cfg.ExpiryUnit = time.Millisecond
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := n.GenerateWithTTL(5 * time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if !id.Expired(n.layout) {
+		t.Fatalf("ID expiring at %v not expired at %v", id.ExpiresAt(n.layout), time.Now())
+	}
+}
+
+func TestGenerateWithTTLRejects(t *testing.T) {
+	n, err := NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.GenerateWithTTL(time.Second); err == nil {
+		t.Error("layout without expiry bits accepted a TTL")
+	}
+
+	cfg := NewConfig()
+	cfg.ExpiryBits, cfg.NodeBits = 8, 2
+	e, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ttl := range []time.Duration{0, -time.Second, e.layout.MaxTTL() + time.Second} {
+		if _, err := e.GenerateWithTTL(ttl); err == nil {
+			t.Errorf("accepted ttl %v", ttl)
+		}
+	}
+}
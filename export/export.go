@@ -0,0 +1,244 @@
+// Package export pushes node metrics to a Prometheus Pushgateway or an OTLP
+// collector, for short-lived batch jobs that finish before any scrape:
+//
+//	p := &export.Pusher{URL: "http://pushgateway:9091", Job: "import", Nodes: []*mkey.Node{node}}
+//	go p.Run(ctx, 10*time.Second)
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/icehuntmen/mkey"
+)
+
+// Format selects the protocol a Pusher speaks
+type Format uint8
+
+const (
+	// Pushgateway PUTs the Prometheus text format to a Pushgateway, replacing
+	// the metrics of the job's group
+	Pushgateway Format = iota
+
+	// OTLP POSTs cumulative sums to an OTLP/HTTP collector as JSON
+	OTLP
+)
+
+// Pusher pushes the stats of a set of nodes. Its methods must not be called
+// concurrently.
+type Pusher struct {
+	// URL is the Pushgateway base URL, e.g. http://pushgateway:9091, or the
+	// OTLP metrics endpoint, e.g. http://collector:4318/v1/metrics
+	URL    string
+	Format Format
+
+	// Job is the Pushgateway job and the OTLP service.name
+	Job string
+
+	Nodes []*mkey.Node
+
+	// Client is used for requests, http.DefaultClient if nil
+	Client *http.Client
+
+	// start is the start time of the OTLP sums, set on the first push
+	start time.Time
+}
+
+// Push sends the current stats once
+func (p *Pusher) Push(ctx context.Context) error {
+	if p.Job == "" {
+		return errors.New("export: Job must be set")
+	}
+	stats := make([]mkey.Stats, len(p.Nodes))
+	for i, n := range p.Nodes {
+		stats[i] = n.Stats()
+	}
+
+	var (
+		method, target, contentType string
+		body                        bytes.Buffer
+	)
+	switch p.Format {
+	case Pushgateway:
+		method = http.MethodPut
+		target = strings.TrimSuffix(p.URL, "/") + "/metrics/job/" + url.PathEscape(p.Job)
+		contentType = "text/plain; version=0.0.4"
+		if err := mkey.WritePrometheus(&body, stats...); err != nil {
+			return err
+		}
+	case OTLP:
+		method, target, contentType = http.MethodPost, p.URL, "application/json"
+		if p.start.IsZero() {
+			p.start = time.Now()
+		}
+		if err := json.NewEncoder(&body).Encode(p.otlp(stats, time.Now())); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("export: unknown format %d", p.Format)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("export: push to %s: %s: %s", target, resp.Status, bytes.TrimSpace(msg))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// Run pushes now and then every interval until ctx is done, and once more
+// before returning so the final counts are delivered. It returns the first
+// push error or ctx.Err(); the final push uses a fresh context.
+func (p *Pusher) Run(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return errors.New("export: interval must be positive")
+	}
+	if err := p.Push(ctx); err != nil {
+		return err
+	}
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			final, cancel := context.WithTimeout(context.Background(), interval)
+			defer cancel()
+			if err := p.Push(final); err != nil {
+				return err
+			}
+			return ctx.Err()
+		case <-t.C:
+			if err := p.Push(ctx); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// The types below mirror the OTLP/HTTP JSON encoding of
+// ExportMetricsServiceRequest, limited to cumulative integer sums
+
+type otlpRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpMetric struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Sum         otlpSum `json:"sum"`
+}
+
+type otlpSum struct {
+	// AggregationTemporality 2 is CUMULATIVE
+	AggregationTemporality int             `json:"aggregationTemporality"`
+	IsMonotonic            bool            `json:"isMonotonic"`
+	DataPoints             []otlpDataPoint `json:"dataPoints"`
+}
+
+// otlpDataPoint holds 64-bit values as strings, as the JSON mapping requires
+type otlpDataPoint struct {
+	Attributes        []otlpAttribute `json:"attributes"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	TimeUnixNano      string          `json:"timeUnixNano"`
+	AsInt             string          `json:"asInt"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+func (p *Pusher) otlp(stats []mkey.Stats, now time.Time) otlpRequest {
+	start := strconv.FormatInt(p.start.UnixNano(), 10)
+	ts := strconv.FormatInt(now.UnixNano(), 10)
+
+	metrics := mkey.Metrics()
+	ms := make([]otlpMetric, len(metrics))
+	for i, m := range metrics {
+		points := make([]otlpDataPoint, len(stats))
+		for j, s := range stats {
+			points[j] = otlpDataPoint{
+				Attributes:        otlpAttributes(s),
+				StartTimeUnixNano: start,
+				TimeUnixNano:      ts,
+				AsInt:             strconv.FormatUint(m.Value(s), 10),
+			}
+		}
+		ms[i] = otlpMetric{
+			Name:        m.Name,
+			Description: m.Help,
+			Sum:         otlpSum{AggregationTemporality: 2, IsMonotonic: m.Kind == "counter", DataPoints: points},
+		}
+	}
+
+	return otlpRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource: otlpResource{Attributes: []otlpAttribute{
+			{Key: "service.name", Value: otlpValue{StringValue: p.Job}},
+		}},
+		ScopeMetrics: []otlpScopeMetrics{{
+			Scope:   otlpScope{Name: "github.com/icehuntmen/mkey"},
+			Metrics: ms,
+		}},
+	}}}
+}
+
+// otlpAttributes returns the node ID and labels as sorted attributes
+func otlpAttributes(s mkey.Stats) []otlpAttribute {
+	attrs := []otlpAttribute{{Key: "node", Value: otlpValue{StringValue: strconv.FormatInt(s.Node, 10)}}}
+	for k, v := range s.Labels {
+		if k != "node" {
+			attrs = append(attrs, otlpAttribute{Key: k, Value: otlpValue{StringValue: v}})
+		}
+	}
+	slices.SortFunc(attrs[1:], func(a, b otlpAttribute) int { return strings.Compare(a.Key, b.Key) })
+	return attrs
+}
@@ -0,0 +1,123 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/icehuntmen/mkey"
+)
+
+type captured struct {
+	method, path, contentType string
+	body                      []byte
+}
+
+func testServer(t *testing.T, status int) (*httptest.Server, *captured) {
+	t.Helper()
+	var c captured
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.method, c.path, c.contentType = r.Method, r.URL.EscapedPath(), r.Header.Get("Content-Type")
+		c.body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+		io.WriteString(w, "rejected\n")
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &c
+}
+
+func labelledNode(t *testing.T) *mkey.Node {
+	t.Helper()
+	cfg := mkey.NewConfig()
+	cfg.Node = 5
+	cfg.Labels = map[string]string{"region": "eu", "az": "b"}
+	n, err := mkey.NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.Generate()
+	n.Generate()
+	return n
+}
+
+func TestPushPushgateway(t *testing.T) {
+	srv, c := testServer(t, http.StatusOK)
+	p := &Pusher{URL: srv.URL + "/", Job: "nightly import", Nodes: []*mkey.Node{labelledNode(t)}}
+	if err := p.Push(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if c.method != http.MethodPut || c.path != "/metrics/job/nightly%20import" {
+		t.Fatalf("request = %s %s", c.method, c.path)
+	}
+	if !strings.HasPrefix(c.contentType, "text/plain") {
+		t.Fatalf("content type = %q", c.contentType)
+	}
+	want := `mkey_ids_generated_total{node="5",az="b",region="eu"} 2`
+	if !strings.Contains(string(c.body), want) {
+		t.Fatalf("body lacks %q:\n%s", want, c.body)
+	}
+}
+
+func TestPushOTLP(t *testing.T) {
+	srv, c := testServer(t, http.StatusOK)
+	p := &Pusher{URL: srv.URL + "/v1/metrics", Format: OTLP, Job: "import", Nodes: []*mkey.Node{labelledNode(t)}}
+	if err := p.Push(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if c.method != http.MethodPost || c.path != "/v1/metrics" || c.contentType != "application/json" {
+		t.Fatalf("request = %s %s %s", c.method, c.path, c.contentType)
+	}
+
+	var req otlpRequest
+	if err := json.Unmarshal(c.body, &req); err != nil {
+		t.Fatal(err)
+	}
+	rm := req.ResourceMetrics[0]
+	if rm.Resource.Attributes[0].Value.StringValue != "import" {
+		t.Fatalf("service.name = %+v", rm.Resource.Attributes)
+	}
+	got := rm.ScopeMetrics[0].Metrics
+	want := mkey.Metrics()
+	if len(got) != len(want) {
+		t.Fatalf("%d metrics pushed, want %d", len(got), len(want))
+	}
+	for i, m := range want {
+		if got[i].Name != m.Name || got[i].Description != m.Help || got[i].Sum.IsMonotonic != (m.Kind == "counter") {
+			t.Errorf("metric %d = %+v, want %s", i, got[i], m.Name)
+		}
+	}
+	dp := got[0].Sum.DataPoints[0]
+	if dp.AsInt != "2" {
+		t.Fatalf("generated = %s, want 2", dp.AsInt)
+	}
+	var keys []string
+	for _, a := range dp.Attributes {
+		keys = append(keys, a.Key+"="+a.Value.StringValue)
+	}
+	if strings.Join(keys, ",") != "node=5,az=b,region=eu" {
+		t.Fatalf("attributes = %v", keys)
+	}
+}
+
+func TestPushErrors(t *testing.T) {
+	srv, _ := testServer(t, http.StatusBadRequest)
+	p := &Pusher{URL: srv.URL, Job: "import"}
+	err := p.Push(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "rejected") {
+		t.Fatalf("Push = %v, want the server's message", err)
+	}
+
+	if err := (&Pusher{URL: srv.URL}).Push(context.Background()); err == nil {
+		t.Fatal("pushed without a Job")
+	}
+	if err := (&Pusher{URL: srv.URL, Job: "j", Format: 9}).Push(context.Background()); err == nil {
+		t.Fatal("pushed with an unknown format")
+	}
+	if err := (&Pusher{URL: srv.URL, Job: "j"}).Run(context.Background(), 0); err == nil {
+		t.Fatal("ran with a zero interval")
+	}
+}
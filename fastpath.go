@@ -0,0 +1,64 @@
+package mkey
+
+import "math"
+
+// stateLocked marks the packed state word as owned by a caller holding n.mu
+const stateLocked = math.MinInt64
+
+// fastRetries bounds how often the fast path retries a lost CAS before
+// falling back to the mutex, so heavy contention queues instead of spinning
+const fastRetries = 4
+
+// lock acquires n.mu and takes ownership of the packed state word, loading it
+// into n.time and n.step. While the word is locked the fast path stands aside.
+func (n *Node) lock() {
+	n.mu.Lock()
+	for {
+		s := n.state.Load()
+		if n.state.CompareAndSwap(s, s|stateLocked) {
+			n.time, n.step = s>>n.nodeShift, s&n.stepMask
+			return
+		}
+	}
+}
+
+// unlock publishes n.time and n.step to the packed state word and releases n.mu
+func (n *Node) unlock() {
+	n.state.Store(n.time<<n.nodeShift | n.step)
+	n.mu.Unlock()
+}
+
+// generateFast issues an ID with a single CAS on the packed state word when
+// the clock has moved on or the current millisecond has free steps. It
+// reports false when the caller must take the locked path: the clock went
+// back, the step space up to limit is used up, another caller holds the lock,
+// or the CAS kept losing.
+func (n *Node) generateFast(fields, limit int64) (ID, bool) {
+	for range fastRetries {
+		s := n.state.Load()
+		if s < 0 {
+			return 0, false
+		}
+		t, step := s>>n.nodeShift, s&n.stepMask
+
+		now := max(n.now(), n.floor.Load())
+		switch {
+		case now > t:
+			step = 0
+		case now == t && step < limit:
+			step++
+		default:
+			return 0, false
+		}
+		if !n.state.CompareAndSwap(s, now<<n.nodeShift|step) {
+			continue
+		}
+
+		n.generated.Add(1)
+		n.secIDs.add(now/1000, 1)
+		id := ID(now<<n.timeShift | fields | n.node<<n.nodeShift | n.stepValue(now, step))
+		n.raiseWatermark(id)
+		return id, true
+	}
+	return 0, false
+}
@@ -0,0 +1,94 @@
+package mkey
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFastPathConcurrent(t *testing.T) {
+	for _, stepBits := range []uint8{4, DefaultStepBits} {
+		cfg := NewConfig()
+		cfg.Node = 2
+		cfg.StepBits = stepBits
+		n, err := NewNodeWithConfig(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the mkeydebug build records provenance under the lock
+		if n.fast == ProvenanceEnabled {
+			t.Fatalf("plain node uses the fast path: %v, want %v", n.fast, !ProvenanceEnabled)
+		}
+
+		const workers, per = 8, 2000
+		out := make([][]ID, workers)
+		var wg sync.WaitGroup
+		for w := range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ids := make([]ID, per)
+				for i := range ids {
+					ids[i] = n.Generate()
+				}
+				out[w] = ids
+			}()
+		}
+		wg.Wait()
+
+		seen := make(map[ID]bool, workers*per)
+		for _, ids := range out {
+			for i, id := range ids {
+				if seen[id] {
+					t.Fatalf("step bits %d: duplicate %v", stepBits, id)
+				}
+				seen[id] = true
+				if i > 0 && id <= ids[i-1] {
+					t.Fatalf("step bits %d: IDs of one goroutine not increasing", stepBits)
+				}
+			}
+		}
+		if got := n.Stats().Generated; got != workers*per {
+			t.Fatalf("Generated = %d, want %d", got, workers*per)
+		}
+	}
+}
+
+func TestFastPathStandsAside(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	cfg := NewConfig()
+	cfg.Node = 2
+	cfg.StepBits = 1
+	cfg.Clock = clock
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, ok := n.generateFast(0, n.stepLimit)
+	if !ok {
+		t.Fatal("fast path refused a fresh node")
+	}
+	if b, ok := n.generateFast(0, n.stepLimit); !ok || b != a+1 {
+		t.Fatalf("second fast ID = %v, %v; want %v", b, ok, a+1)
+	}
+	if _, ok := n.generateFast(0, n.stepLimit); ok {
+		t.Fatal("fast path issued past the step space")
+	}
+
+	clock.Advance(time.Millisecond)
+	n.lock()
+	if _, ok := n.generateFast(0, n.stepLimit); ok {
+		t.Fatal("fast path issued while the state was locked")
+	}
+	n.unlock()
+
+	// the locked path sees what the fast path issued
+	id := n.Generate()
+	if l := n.Layout(); l.Time(id) != l.Time(a)+1 || l.Step(id) != 0 {
+		t.Fatalf("locked ID after fast IDs: %dms later, step %d", l.Time(id)-l.Time(a), l.Step(id))
+	}
+	if c, ok := n.generateFast(0, n.stepLimit); !ok || c != id+1 {
+		t.Fatalf("fast ID after a locked one = %v, %v; want %v", c, ok, id+1)
+	}
+}
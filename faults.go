@@ -0,0 +1,127 @@
+package mkey
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Faults injects failures into a Node for chaos testing of code that handles
+// IDs. It is set via Config.Faults and must be left nil in production.
+type Faults interface {
+	// ClockOffset is added to the node's clock; a negative offset larger than
+	// the time since the last ID simulates the clock moving backwards
+	ClockOffset() time.Duration
+
+	// ExhaustSequence reports whether the next generation should behave as if
+	// the current millisecond's step space were used up
+	ExhaustSequence() bool
+}
+
+// FaultInjector is a ready-made Faults whose failures are switched on and off
+// at runtime. It also wraps an Elector to simulate lease loss. All methods are
+// safe for concurrent use.
+type FaultInjector struct {
+	offset    atomic.Int64
+	exhaust   atomic.Int64
+	leaseLost atomic.Bool
+}
+
+// ClockOffset implements Faults
+func (f *FaultInjector) ClockOffset() time.Duration {
+	return time.Duration(f.offset.Load())
+}
+
+// ExhaustSequence implements Faults, consuming one pending exhaustion
+func (f *FaultInjector) ExhaustSequence() bool {
+	for {
+		n := f.exhaust.Load()
+		if n <= 0 {
+			return false
+		}
+		if f.exhaust.CompareAndSwap(n, n-1) {
+			return true
+		}
+	}
+}
+
+// RollbackClock moves the node's clock back by d; call RestoreClock to undo
+func (f *FaultInjector) RollbackClock(d time.Duration) {
+	f.offset.Add(-int64(d))
+}
+
+// RestoreClock removes any injected clock offset
+func (f *FaultInjector) RestoreClock() {
+	f.offset.Store(0)
+}
+
+// ExhaustNext forces the next count generations to wait for a fresh millisecond
+func (f *FaultInjector) ExhaustNext(count int) {
+	f.exhaust.Add(int64(count))
+}
+
+// LoseLease makes electors wrapped by Elector report no leadership
+func (f *FaultInjector) LoseLease() {
+	f.leaseLost.Store(true)
+}
+
+// RestoreLease undoes LoseLease
+func (f *FaultInjector) RestoreLease() {
+	f.leaseLost.Store(false)
+}
+
+// Elector wraps e so that it reports no leadership while the lease is lost
+func (f *FaultInjector) Elector(e Elector) Elector {
+	return faultyElector{inner: e, f: f}
+}
+
+type faultyElector struct {
+	inner Elector
+	f     *FaultInjector
+}
+
+func (e faultyElector) IsLeader() bool {
+	return !e.f.leaseLost.Load() && e.inner.IsLeader()
+}
+
+// now returns the milliseconds elapsed since the node's epoch, from the
+// cache with Config.CoarseTime
+func (n *Node) now() int64 {
+	if n.coarse != nil {
+		return n.coarse.ms.Load()
+	}
+	return n.preciseNow()
+}
+
+// preciseNow reads the clock even with Config.CoarseTime, moving the cache
+// forward to the reading
+func (n *Node) preciseNow() int64 {
+	ms := n.elapsed().Nanoseconds() / 1000000
+	if n.coarse != nil {
+		n.coarse.raise(ms)
+	}
+	return ms
+}
+
+// elapsed returns the time since the node's epoch as seen by the node
+func (n *Node) elapsed() time.Duration {
+	return elapsedSince(n.clock, n.epoch, n.jitter, n.faults)
+}
+
+// elapsedSince returns the time since epoch on clock, with the jitter lag and
+// injected clock faults applied
+func elapsedSince(clock Clock, epoch time.Time, jitter time.Duration, faults Faults) time.Duration {
+	d := clock.Since(epoch) - jitter
+	if faults != nil {
+		d += faults.ClockOffset()
+	}
+	return d
+}
+
+// injectExhaustion marks the current millisecond as used up when the fault
+// injector asks for it. Callers hold n.mu.
+func (n *Node) injectExhaustion(now int64) {
+	if n.faults != nil && n.faults.ExhaustSequence() {
+		n.time = now
+		n.step = n.stepMask
+	}
+}
@@ -0,0 +1,104 @@
+package mkey
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func newFaultyNode(t *testing.T, detectBack bool) (*Node, *FaultInjector, *ManualClock) {
+	t.Helper()
+	f := &FaultInjector{}
+	clock := NewManualClock(time.Now())
+	cfg := NewConfig()
+	cfg.Node = 1
+	cfg.Clock = clock
+	cfg.Faults = f
+	cfg.DetectClockBack = detectBack
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n, f, clock
+}
+
+func TestFaultClockRollback(t *testing.T) {
+	n, f, _ := newFaultyNode(t, true)
+	if _, err := n.TryGenerate(); err != nil {
+		t.Fatal(err)
+	}
+	f.RollbackClock(time.Second)
+	if _, err := n.TryGenerate(); !errors.Is(err, ErrClockMovedBack) {
+		t.Fatalf("after rollback: err = %v, want ErrClockMovedBack", err)
+	}
+	f.RestoreClock()
+	if f.ClockOffset() != 0 {
+		t.Fatalf("ClockOffset after restore = %v", f.ClockOffset())
+	}
+	if _, err := n.TryGenerate(); err != nil {
+		t.Fatalf("after restore: %v", err)
+	}
+}
+
+func TestFaultClockRollbackUndetected(t *testing.T) {
+	n, f, clock := newFaultyNode(t, false)
+	a := n.Generate()
+	f.RollbackClock(time.Second)
+	clock.Advance(time.Millisecond)
+
+	// without DetectClockBack the rollback reaches the IDs, which is what
+	// downstream chaos tests want to observe
+	l := n.Layout()
+	if d := l.Time(a) - l.Time(n.Generate()); d != 999 {
+		t.Fatalf("ID after a 1s rollback is %dms older, want 999", d)
+	}
+}
+
+func TestFaultExhaustSequence(t *testing.T) {
+	n, f, clock := newFaultyNode(t, false)
+	a, err := n.TryGenerate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.ExhaustNext(1)
+	if _, err := n.TryGenerate(); !errors.Is(err, ErrSequenceExhausted) {
+		t.Fatalf("forced exhaustion: err = %v, want ErrSequenceExhausted", err)
+	}
+	clock.Advance(time.Millisecond)
+	b, err := n.TryGenerate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := n.Layout()
+	if l.Time(b) != l.Time(a)+1 || l.Step(b) != 0 {
+		t.Fatalf("ID after exhaustion at ms %d step %d, want a fresh millisecond", l.Time(b)-l.Time(a), l.Step(b))
+	}
+
+	f.ExhaustNext(2)
+	if !f.ExhaustSequence() || !f.ExhaustSequence() || f.ExhaustSequence() {
+		t.Fatal("ExhaustNext(2) did not fire exactly twice")
+	}
+}
+
+type fixedElector bool
+
+func (e fixedElector) IsLeader() bool { return bool(e) }
+
+func TestFaultLeaseLoss(t *testing.T) {
+	f := &FaultInjector{}
+	e := f.Elector(fixedElector(true))
+	if !e.IsLeader() {
+		t.Fatal("wrapped leader does not lead")
+	}
+	f.LoseLease()
+	if e.IsLeader() {
+		t.Fatal("leader after LoseLease")
+	}
+	f.RestoreLease()
+	if !e.IsLeader() {
+		t.Fatal("no leader after RestoreLease")
+	}
+	if f.Elector(fixedElector(false)).IsLeader() {
+		t.Fatal("wrapping a standby made it lead")
+	}
+}
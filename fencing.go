@@ -0,0 +1,96 @@
+package mkey
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// ErrStaleToken is returned by Fence.Admit for a token older than one it has seen
+var ErrStaleToken = errors.New("fencing token is stale")
+
+// FencingToken is a Barrier ID handed to the holder of a distributed lock and
+// attached to every write it makes, so the protected resource can reject
+// writes from a holder whose lock has since passed to someone else.
+//
+// Tokens from one node are strictly increasing under every layout, since
+// each is a Barrier. Tokens from different nodes are only ordered if each
+// new lock holder starts issuing after the previous holder's last token,
+// i.e. the lock's handoff delay covers the clock skew between nodes (see
+// ElectionConfig.Handoff).
+type FencingToken ID
+
+// FencingToken issues a token greater than every ID the node has issued
+func (n *Node) FencingToken() FencingToken {
+	return FencingToken(n.Barrier())
+}
+
+// Newer reports whether t was issued after than
+func (t FencingToken) Newer(than FencingToken) bool {
+	return t > than
+}
+
+// ID returns the token as an ID
+func (t FencingToken) ID() ID {
+	return ID(t)
+}
+
+// String returns the token in decimal
+func (t FencingToken) String() string {
+	return strconv.FormatInt(int64(t), 10)
+}
+
+// ParseFencingToken parses a decimal token
+func ParseFencingToken(s string) (FencingToken, error) {
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid fencing token %q", s)
+	}
+	if v <= 0 {
+		return 0, fmt.Errorf("fencing token must be positive, got %d", v)
+	}
+	return FencingToken(v), nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (t FencingToken) MarshalText() ([]byte, error) {
+	return strconv.AppendInt(nil, int64(t), 10), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (t *FencingToken) UnmarshalText(b []byte) error {
+	v, err := ParseFencingToken(string(b))
+	if err != nil {
+		return err
+	}
+	*t = v
+	return nil
+}
+
+// Fence is the resource side of fencing: it remembers the highest token seen
+// and rejects writes carrying older ones. The zero value is ready to use.
+type Fence struct {
+	mu   sync.Mutex
+	last FencingToken
+}
+
+// Admit accepts t if it is not older than any token admitted before. Equal
+// tokens are accepted, since one lock holder makes many writes with one token.
+func (f *Fence) Admit(t FencingToken) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.last.Newer(t) {
+		return fmt.Errorf("%w: %s < %s", ErrStaleToken, t, f.last)
+	}
+	f.last = t
+	return nil
+}
+
+// Last returns the highest token admitted so far
+func (f *Fence) Last() FencingToken {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.last
+}
@@ -0,0 +1,103 @@
+package mkey
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFencingTokensIncrease(t *testing.T) {
+	for name, opt := range barrierConfigs {
+		t.Run(name, func(t *testing.T) {
+			cfg := NewConfig()
+			cfg.Node = 3
+			opt(cfg)
+			n, err := NewNodeWithConfig(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var prev FencingToken
+			for i := range 100 {
+				high := issueMixed(t, n, cfg)
+				tok := n.FencingToken()
+				if !tok.Newer(prev) {
+					t.Fatalf("round %d: token %s not newer than %s", i, tok, prev)
+				}
+				if tok.ID() <= high {
+					t.Fatalf("round %d: token %s <= issued ID %d", i, tok, high)
+				}
+				prev = tok
+			}
+		})
+	}
+}
+
+func TestFenceAdmit(t *testing.T) {
+	n, err := NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := n.FencingToken()
+	n.Generate()
+	cur := n.FencingToken()
+
+	var f Fence
+	if err := f.Admit(old); err != nil {
+		t.Fatalf("first token rejected: %v", err)
+	}
+	if err := f.Admit(cur); err != nil {
+		t.Fatalf("newer token rejected: %v", err)
+	}
+	if err := f.Admit(cur); err != nil {
+		t.Fatalf("repeated token rejected: %v", err)
+	}
+	if err := f.Admit(old); !errors.Is(err, ErrStaleToken) {
+		t.Fatalf("stale token: got %v, want ErrStaleToken", err)
+	}
+	if f.Last() != cur {
+		t.Fatalf("Last() = %s, want %s", f.Last(), cur)
+	}
+}
+
+// TestFenceStaleHolder replays a lock handoff: the new holder's token is
+// issued after the old holder issued IDs with fields that sort high, and the
+// old holder must still be fenced off
+func TestFenceStaleHolder(t *testing.T) {
+	cfg := NewConfig()
+	cfg.ExpiryBits, cfg.NodeBits = 8, 2
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var f Fence
+	stale := n.FencingToken()
+	if _, err := n.GenerateWithTTL(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	fresh := n.FencingToken()
+	if err := f.Admit(fresh); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Admit(stale); !errors.Is(err, ErrStaleToken) {
+		t.Fatalf("stale holder admitted: %v", err)
+	}
+}
+
+func TestParseFencingToken(t *testing.T) {
+	tok := FencingToken(12345)
+	b, err := tok.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got FencingToken
+	if err := got.UnmarshalText(b); err != nil || got != tok {
+		t.Fatalf("round trip: got %s, %v", got, err)
+	}
+	for _, s := range []string{"", "abc", "0", "-5"} {
+		if _, err := ParseFencingToken(s); err == nil {
+			t.Errorf("ParseFencingToken(%q) succeeded", s)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package mkey
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// FrameSize is the length of a binary ID frame: an 8-byte ID followed by
+// the 4-byte fingerprint of the layout it was generated with, both big endian
+const FrameSize = 12
+
+// ErrLayoutMismatch is returned when an ID was produced under a different layout
+var ErrLayoutMismatch = errors.New("layout fingerprint mismatch")
+
+// EncodeFrame returns the binary frame for id generated under layout l
+func EncodeFrame(id ID, l Layout) [FrameSize]byte {
+	var b [FrameSize]byte
+	binary.BigEndian.PutUint64(b[:8], uint64(id))
+	binary.BigEndian.PutUint32(b[8:], l.Fingerprint())
+	return b
+}
+
+// AppendFrame appends the binary frame for id to dst
+func AppendFrame(dst []byte, id ID, l Layout) []byte {
+	dst = binary.BigEndian.AppendUint64(dst, uint64(id))
+	return binary.BigEndian.AppendUint32(dst, l.Fingerprint())
+}
+
+// DecodeFrame decodes a frame and checks that it was produced under layout l.
+// A frame from another layout yields ErrLayoutMismatch.
+func DecodeFrame(b []byte, l Layout) (ID, error) {
+	id, fp, err := ReadFrame(b)
+	if err != nil {
+		return 0, err
+	}
+	if want := l.Fingerprint(); fp != want {
+		return 0, fmt.Errorf("%w: got %08x, want %08x", ErrLayoutMismatch, fp, want)
+	}
+	return id, nil
+}
+
+// ReadFrame decodes a frame without checking the fingerprint
+func ReadFrame(b []byte) (ID, uint32, error) {
+	if len(b) != FrameSize {
+		return 0, 0, fmt.Errorf("frame must be %d bytes, got %d", FrameSize, len(b))
+	}
+	return ID(binary.BigEndian.Uint64(b[:8])), binary.BigEndian.Uint32(b[8:]), nil
+}
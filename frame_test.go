@@ -0,0 +1,72 @@
+package mkey
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFrameRoundTrip(t *testing.T) {
+	n, err := NewNode(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := n.layout
+	id := n.Generate()
+
+	f := EncodeFrame(id, l)
+	if got := AppendFrame([]byte{0xAA}, id, l); string(got[1:]) != string(f[:]) || got[0] != 0xAA {
+		t.Fatalf("AppendFrame = %x, want aa%x", got, f)
+	}
+	got, err := DecodeFrame(f[:], l)
+	if err != nil || got != id {
+		t.Fatalf("DecodeFrame = %d, %v, want %d", got, err, id)
+	}
+	raw, fp, err := ReadFrame(f[:])
+	if err != nil || raw != id || fp != l.Fingerprint() {
+		t.Fatalf("ReadFrame = %d, %08x, %v", raw, fp, err)
+	}
+}
+
+func TestDecodeFrameMismatch(t *testing.T) {
+	l := DefaultLayout()
+	other := l
+	other.NodeBits, other.StepBits = 12, 10
+	f := EncodeFrame(42, other)
+
+	if _, err := DecodeFrame(f[:], l); !errors.Is(err, ErrLayoutMismatch) {
+		t.Fatalf("DecodeFrame under another layout = %v, want ErrLayoutMismatch", err)
+	}
+	for _, n := range []int{0, FrameSize - 1, FrameSize + 1} {
+		if _, err := DecodeFrame(make([]byte, n), l); err == nil {
+			t.Errorf("DecodeFrame accepted %d bytes", n)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	base := DefaultLayout()
+	if base.Fingerprint() != DefaultLayout().Fingerprint() {
+		t.Fatal("fingerprint is not deterministic")
+	}
+
+	variants := map[string]func(*Layout){
+		"epoch":     func(l *Layout) { l.Epoch++ },
+		"node bits": func(l *Layout) { l.NodeBits-- },
+		"step bits": func(l *Layout) { l.StepBits-- },
+		"expiry":    func(l *Layout) { l.ExpiryBits = 4 },
+		"priority":  func(l *Layout) { l.PriorityBits = 1 },
+		"tombstone": func(l *Layout) { l.TombstoneBit = true },
+		"shard":     func(l *Layout) { l.ShardBits = 2 },
+		"child":     func(l *Layout) { l.ChildBits = 2 },
+	}
+	seen := map[uint32]string{base.Fingerprint(): "base"}
+	for name, change := range variants {
+		l := base
+		change(&l)
+		fp := l.Fingerprint()
+		if prev, dup := seen[fp]; dup {
+			t.Errorf("%s has the fingerprint of %s", name, prev)
+		}
+		seen[fp] = name
+	}
+}
@@ -0,0 +1,12 @@
+package mkey
+
+import "context"
+
+// Generator is the part of Node that code issuing IDs usually needs. Accept
+// a Generator instead of a *Node to substitute a scripted one in tests, such
+// as mkeytest.Stub.
+type Generator interface {
+	Generate() ID
+	GenerateContext(ctx context.Context) (ID, error)
+	GenerateBatch(count int) ([]ID, error)
+}
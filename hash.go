@@ -0,0 +1,88 @@
+package mkey
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+// Hash64 returns a well-mixed 64-bit hash of the ID (the SplitMix64
+// finalizer). Raw IDs make poor shard keys because their low bits are the
+// step, which is usually small; every bit of Hash64 depends on every bit of the ID.
+func (f ID) Hash64() uint64 {
+	z := uint64(f) + 0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// JumpHash maps the ID to a bucket in [0, buckets) using jump consistent
+// hashing over Hash64, so growing the bucket count moves only 1/n of the keys.
+// It returns -1 if buckets is not positive.
+func (f ID) JumpHash(buckets int) int {
+	if buckets <= 0 {
+		return -1
+	}
+
+	key := f.Hash64()
+	var b, j int64 = -1, 0
+	for j < int64(buckets) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
+
+// SampleIn reports whether the ID is part of a consistent sample at the given
+// rate: for a fixed salt the same ID is always in or always out, and about
+// rate of all IDs are in. Different salts give independent samples, e.g. one
+// per experiment.
+func (f ID) SampleIn(rate float64, salt uint64) bool {
+	if rate <= 0 {
+		return false
+	}
+	if rate >= 1 {
+		return true
+	}
+	h := ID(f.Hash64() ^ salt).Hash64()
+	return h < uint64(math.Ldexp(rate, 64))
+}
+
+// ReversedBitsKey returns the ID with its bit order reversed, for row keys in
+// range-partitioned stores such as HBase or Bigtable. Reversal moves the fast
+// changing step, node and low timestamp bits to the front, so consecutive IDs
+// land on different regions instead of all hitting the newest one; store the
+// key big endian. Unlike Hash64 the mapping is invertible with
+// FromReversedBitsKey, at the cost of losing time-ordered scans.
+func (f ID) ReversedBitsKey() uint64 {
+	return bits.Reverse64(uint64(f))
+}
+
+// FromReversedBitsKey recovers the ID from a key made by ReversedBitsKey
+func FromReversedBitsKey(key uint64) ID {
+	return ID(bits.Reverse64(key))
+}
+
+// HashToID folds an external string identifier, e.g. a foreign key, into an
+// ID that can share an int64 column with IDs generated under l. The result
+// has the sign bit set, which no layout ever uses, so it cannot collide with
+// a generated ID and IsHashed tells the two apart. The remaining 63 bits are
+// a stable hash of s and of l's fingerprint; distinct strings collide with
+// the usual birthday odds, about one in a million for four million keys.
+// Every Encoding round-trips hashed IDs, and ParseAny accepts them with a
+// warning since they fit no layout.
+func HashToID(s string, l Layout) ID {
+	h := fnv.New64a()
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], l.Fingerprint())
+	h.Write(b[:])
+	h.Write([]byte(s))
+	return ID(ID(h.Sum64()).Hash64() | 1<<63)
+}
+
+// IsHashed reports whether the ID was made by HashToID
+func (f ID) IsHashed() bool {
+	return f < 0
+}
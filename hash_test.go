@@ -0,0 +1,218 @@
+package mkey
+
+import (
+	"math/bits"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHash64(t *testing.T) {
+	n, err := NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := n.Generate()
+	if a.Hash64() != a.Hash64() {
+		t.Fatal("Hash64 is not stable")
+	}
+
+	// flipping any single input bit flips about half the output bits
+	total := 0
+	for i := range 63 {
+		total += bits.OnesCount64(a.Hash64() ^ (a ^ 1<<i).Hash64())
+	}
+	if avg := float64(total) / 63; avg < 24 || avg > 40 {
+		t.Fatalf("average avalanche %.1f bits, want about 32", avg)
+	}
+}
+
+func TestJumpHash(t *testing.T) {
+	n, err := NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]ID, 10000)
+	for i := range ids {
+		ids[i] = n.Generate()
+	}
+
+	if got := ids[0].JumpHash(0); got != -1 {
+		t.Fatalf("JumpHash(0) = %d, want -1", got)
+	}
+	if got := ids[0].JumpHash(1); got != 0 {
+		t.Fatalf("JumpHash(1) = %d, want 0", got)
+	}
+
+	// roughly even buckets even though consecutive IDs differ only in the step
+	counts := make([]int, 10)
+	for _, id := range ids {
+		b := id.JumpHash(10)
+		if b < 0 || b >= 10 {
+			t.Fatalf("JumpHash(10) = %d", b)
+		}
+		counts[b]++
+	}
+	for b, c := range counts {
+		if c < 800 || c > 1200 {
+			t.Errorf("bucket %d got %d of %d IDs", b, c, len(ids))
+		}
+	}
+
+	// growing to 11 buckets moves only keys into the new bucket, about 1/11 of them
+	moved := 0
+	for _, id := range ids {
+		if was, now := id.JumpHash(10), id.JumpHash(11); was != now {
+			if now != 10 {
+				t.Fatalf("key moved from %d to old bucket %d", was, now)
+			}
+			moved++
+		}
+	}
+	if moved < 700 || moved > 1150 {
+		t.Fatalf("%d of %d keys moved, want about 1/11", moved, len(ids))
+	}
+}
+
+func TestSampleIn(t *testing.T) {
+	n, err := NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]ID, 20000)
+	for i := range ids {
+		ids[i] = n.Generate()
+	}
+
+	for _, id := range ids[:10] {
+		if id.SampleIn(0, 1) || id.SampleIn(-1, 1) {
+			t.Fatal("rate <= 0 sampled an ID")
+		}
+		if !id.SampleIn(1, 1) || !id.SampleIn(2, 1) {
+			t.Fatal("rate >= 1 dropped an ID")
+		}
+	}
+
+	in, both := 0, 0
+	for _, id := range ids {
+		a := id.SampleIn(0.1, 7)
+		if a != id.SampleIn(0.1, 7) {
+			t.Fatal("SampleIn is not consistent")
+		}
+		if a {
+			in++
+			if id.SampleIn(0.1, 8) {
+				both++
+			}
+		}
+		// a sample at a lower rate is a subset of one at a higher rate
+		if id.SampleIn(0.05, 7) && !a {
+			t.Fatal("0.05 sample is not contained in the 0.1 sample")
+		}
+	}
+	if in < 1700 || in > 2300 {
+		t.Fatalf("%d of %d IDs sampled at 0.1", in, len(ids))
+	}
+	// independent salts overlap in about rate² of the IDs
+	if both > 350 {
+		t.Fatalf("salts 7 and 8 share %d of %d sampled IDs", both, in)
+	}
+}
+
+func TestReversedBitsKey(t *testing.T) {
+	for _, id := range []ID{0, 1, 1 << 62, -1} {
+		if got := FromReversedBitsKey(id.ReversedBitsKey()); got != id {
+			t.Fatalf("FromReversedBitsKey(%v.ReversedBitsKey()) = %v", id, got)
+		}
+	}
+	if ID(1).ReversedBitsKey() != 1<<63 {
+		t.Fatal("low bit does not move to the top")
+	}
+
+	// consecutive IDs differ in the step, which now leads the key, so their
+	// leading bytes (the region prefix in a range-partitioned store) spread out
+	l := DefaultLayout()
+	at := time.Now()
+	prefixes := make(map[byte]bool)
+	for step := range int64(256) {
+		id, err := l.Compose(at, 1, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		prefixes[byte(id.ReversedBitsKey()>>56)] = true
+		if FromReversedBitsKey(id.ReversedBitsKey()) != id {
+			t.Fatal("round trip failed")
+		}
+	}
+	if len(prefixes) != 256 {
+		t.Fatalf("256 consecutive IDs share only %d leading bytes", len(prefixes))
+	}
+}
+
+func TestHashToID(t *testing.T) {
+	l := DefaultLayout()
+	a := HashToID("customer-42", l)
+	if a != HashToID("customer-42", l) {
+		t.Fatal("HashToID is not stable")
+	}
+	if !a.IsHashed() || a.Valid(l) {
+		t.Fatalf("HashToID = %v: hashed %v, valid %v", a, a.IsHashed(), a.Valid(l))
+	}
+	if HashToID("customer-43", l) == a {
+		t.Fatal("different strings gave the same ID")
+	}
+
+	other := l
+	other.Epoch = TwitterEpoch
+	if HashToID("customer-42", other) == a {
+		t.Fatal("HashToID does not depend on the layout")
+	}
+
+	n, _ := NewNode(1)
+	for range 1000 {
+		if n.Generate().IsHashed() {
+			t.Fatal("generated ID reported as hashed")
+		}
+	}
+
+	seen := make(map[ID]bool)
+	for i := range 10000 {
+		id := HashToID("key-"+strconv.Itoa(i), l)
+		if seen[id] {
+			t.Fatalf("collision among 10000 keys at %d", i)
+		}
+		seen[id] = true
+	}
+}
+
+func TestHashToIDEncodings(t *testing.T) {
+	l := DefaultLayout()
+	ids := []ID{HashToID("customer-42", l), HashToID("order-7", l), -1, -1 << 63}
+	for _, id := range ids {
+		for i := range len(encodingNames) {
+			e := Encoding(i)
+			s := e.Encode(id)
+			if len(s) != e.Len(id) {
+				t.Errorf("%v: Len(%v) = %d, want %d", e, id, e.Len(id), len(s))
+			}
+			if back, err := e.Parse(s); err != nil || back != id {
+				t.Errorf("%v: Parse(%q) = %v, %v; want %v", e, s, back, err, id)
+			}
+		}
+
+		if id == -1 {
+			continue // "-1" is also a valid base64 ID
+		}
+		for _, s := range []string{id.String(), "0x" + id.Hex(), JSONBase58Prefix + id.Base58()} {
+			got, info, err := ParseAnyInfo(s)
+			if err != nil || got != id {
+				t.Errorf("ParseAnyInfo(%q) = %v, %v; want %v", s, got, err, id)
+				continue
+			}
+			if !strings.Contains(strings.Join(info.Warnings, "\n"), "HashToID") {
+				t.Errorf("ParseAnyInfo(%q) warnings = %q, want a hashed ID warning", s, info.Warnings)
+			}
+		}
+	}
+}
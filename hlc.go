@@ -0,0 +1,108 @@
+package mkey
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrClockDrift is returned by HLC.Observe for remote IDs too far ahead of the local clock
+var ErrClockDrift = errors.New("remote ID is too far ahead of the local clock")
+
+// HLC generates IDs from a hybrid logical clock: the timestamp field is the
+// wall clock, but never less than one past the newest timestamp observed from
+// other nodes. Feeding it the IDs of received messages with Observe makes
+// every ID it issues afterwards sort above them, so ID order respects
+// causality across nodes as well as within one.
+//
+// Unlike Node it never waits: when the step space of a millisecond is used up
+// the logical clock moves on to the next one, running slightly ahead of the
+// wall clock under sustained load.
+type HLC struct {
+	mu       sync.Mutex
+	layout   Layout
+	epoch    time.Time
+	clock    Clock
+	node     int64
+	maxDrift int64
+
+	last int64 // timestamp of the last ID issued, ms since the epoch
+	seen int64 // newest remote timestamp observed
+	step int64
+}
+
+// NewHLC creates a hybrid logical clock generator with the layout and node ID
+// of cfg. Observe rejects remote IDs more than maxDrift ahead of the wall
+// clock; 0 disables the check.
+func NewHLC(cfg *Config, maxDrift time.Duration) (*HLC, error) {
+	layout := cfg.Layout()
+	if err := layout.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Node < 0 || cfg.Node > layout.MaxNode() {
+		return nil, fmt.Errorf("Node must be between 0 and %d", layout.MaxNode())
+	}
+	if maxDrift < 0 {
+		return nil, errors.New("maxDrift must not be negative")
+	}
+
+	clock := cfg.Clock
+	if clock == nil {
+		clock = SystemClock{}
+	}
+	return &HLC{
+		layout:   layout,
+		epoch:    time.UnixMilli(layout.Epoch),
+		clock:    clock,
+		node:     cfg.Node,
+		maxDrift: maxDrift.Milliseconds(),
+	}, nil
+}
+
+// Layout returns the layout of the IDs the clock issues
+func (h *HLC) Layout() Layout {
+	return h.layout
+}
+
+func (h *HLC) wall() int64 {
+	return h.clock.Since(h.epoch).Milliseconds()
+}
+
+// Generate returns an ID greater than every ID previously issued by h and
+// every ID passed to Observe
+func (h *HLC) Generate() ID {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	t := max(h.wall(), h.seen+1)
+	if t > h.last {
+		h.last = t
+		h.step = 0
+	} else {
+		h.step++
+		if h.step > h.layout.StepMask() {
+			h.last++
+			h.step = 0
+		}
+	}
+
+	return ID(h.last<<h.layout.TimeShift() | h.node<<h.layout.StepBits | h.step)
+}
+
+// Observe merges the timestamp of an ID received from another node into the
+// clock. The ID must use the same layout.
+func (h *HLC) Observe(remote ID) error {
+	t := h.layout.Time(remote) - h.layout.Epoch
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.maxDrift > 0 {
+		if ahead := t - h.wall(); ahead > h.maxDrift {
+			return fmt.Errorf("%w: %dms ahead", ErrClockDrift, ahead)
+		}
+	}
+	h.seen = max(h.seen, t)
+	return nil
+}
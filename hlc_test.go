@@ -0,0 +1,104 @@
+package mkey
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func newHLC(t *testing.T, node int64, clock Clock, maxDrift time.Duration) *HLC {
+	t.Helper()
+	cfg := NewConfig()
+	cfg.Node = node
+	cfg.StepBits = 2
+	cfg.Clock = clock
+	h, err := NewHLC(cfg, maxDrift)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestHLCNeverWaits(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	h := newHLC(t, 1, clock, 0)
+	l := h.Layout()
+
+	// a stopped clock and four steps per millisecond: the logical clock runs ahead
+	var last ID
+	for i := range 20 {
+		id := h.Generate()
+		if id <= last {
+			t.Fatalf("ID %d = %v does not follow %v", i, id, last)
+		}
+		if l.NodeID(id) != 1 {
+			t.Fatalf("node = %d", l.NodeID(id))
+		}
+		last = id
+	}
+	if ahead := l.Time(last) - clock.Now().UnixMilli(); ahead != 4 {
+		t.Fatalf("logical clock %dms ahead, want 4", ahead)
+	}
+
+	// once the wall clock passes the logical clock it takes over again
+	clock.Advance(time.Second)
+	if id := h.Generate(); l.Time(id) != clock.Now().UnixMilli() || l.Step(id) != 0 {
+		t.Fatalf("ID after the clock caught up at %d step %d", l.Time(id)-clock.Now().UnixMilli(), l.Step(id))
+	}
+}
+
+func TestHLCObserve(t *testing.T) {
+	start := time.Now()
+	slow := newHLC(t, 1, NewManualClock(start), 0)
+	fast := newHLC(t, 2, NewManualClock(start.Add(time.Second)), 0)
+
+	remote := fast.Generate()
+	local := slow.Generate()
+	if local > remote {
+		t.Fatal("slow clock already ahead")
+	}
+	if err := slow.Observe(remote); err != nil {
+		t.Fatal(err)
+	}
+	after := slow.Generate()
+	if after <= remote {
+		t.Fatalf("ID %v after Observe does not follow remote %v", after, remote)
+	}
+	if got := slow.Layout().Time(after) - slow.Layout().Time(remote); got != 1 {
+		t.Fatalf("ID after Observe is %dms past the remote, want 1", got)
+	}
+
+	// older observations never move the clock back
+	if err := slow.Observe(local); err != nil {
+		t.Fatal(err)
+	}
+	if id := slow.Generate(); id <= after {
+		t.Fatal("older Observe moved the clock back")
+	}
+}
+
+func TestHLCMaxDrift(t *testing.T) {
+	start := time.Now()
+	h := newHLC(t, 1, NewManualClock(start), 100*time.Millisecond)
+	near := newHLC(t, 2, NewManualClock(start.Add(50*time.Millisecond)), 0).Generate()
+	far := newHLC(t, 3, NewManualClock(start.Add(time.Second)), 0).Generate()
+
+	if err := h.Observe(near); err != nil {
+		t.Fatalf("Observe within drift: %v", err)
+	}
+	if err := h.Observe(far); !errors.Is(err, ErrClockDrift) {
+		t.Fatalf("Observe beyond drift: err = %v, want ErrClockDrift", err)
+	}
+	if got := h.Layout().Time(h.Generate()) - h.Layout().Time(near); got != 1 {
+		t.Fatalf("rejected observation moved the clock: %dms past near", got)
+	}
+
+	cfg := NewConfig()
+	if _, err := NewHLC(cfg, -time.Second); err == nil {
+		t.Error("negative maxDrift accepted")
+	}
+	cfg.Node = cfg.Layout().MaxNode() + 1
+	if _, err := NewHLC(cfg, 0); err == nil {
+		t.Error("out of range node accepted")
+	}
+}
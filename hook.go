@@ -0,0 +1,74 @@
+package mkey
+
+import (
+	"fmt"
+	"time"
+)
+
+// Hook observes a Node's generation, for metrics, tracing or logging without
+// wrapping every call site; set it with Config.Hook. Methods run on the
+// generating goroutine, possibly with the node's lock held, so they must be
+// fast and must not call back into the node.
+type Hook interface {
+	// OnGenerate is called for every ID issued, including each ID of a batch
+	OnGenerate(id ID)
+
+	// OnWait is called after a call blocked, for the next millisecond, a
+	// maintenance window, the rate limit or reserved steps, with how long
+	OnWait(d time.Duration)
+
+	// OnClockEvent is called when the node sees the clock misbehave or
+	// works around it
+	OnClockEvent(e ClockEvent)
+}
+
+// ClockEventKind classifies a ClockEvent
+type ClockEventKind uint8
+
+const (
+	// ClockBack means the clock read earlier than the last issued
+	// timestamp. Generation holds the last timestamp, slews or fails
+	// depending on Config.SlewLimit and Config.DetectClockBack.
+	ClockBack ClockEventKind = iota
+
+	// ClockBorrow means the node moved to the next millisecond ahead of the
+	// clock instead of waiting, see Config.BorrowLimit
+	ClockBorrow
+)
+
+// ClockEvent describes something the node noticed about its clock
+type ClockEvent struct {
+	Node int64
+	Kind ClockEventKind
+
+	// Delta is how far the clock read behind the last issued timestamp for
+	// ClockBack, or how far the node moved ahead of it for ClockBorrow
+	Delta time.Duration
+}
+
+func (e ClockEvent) String() string {
+	switch e.Kind {
+	case ClockBack:
+		return fmt.Sprintf("mkey: node %d clock moved back by %s", e.Node, e.Delta)
+	case ClockBorrow:
+		return fmt.Sprintf("mkey: node %d borrowed %s ahead of the clock", e.Node, e.Delta)
+	}
+	return fmt.Sprintf("mkey: node %d clock event %d", e.Node, e.Kind)
+}
+
+// clockNow returns the millisecond to issue in, reporting regressions to the
+// hook before Config.SlewLimit and Config.DetectClockBack apply. Callers hold
+// the lock.
+func (n *Node) clockNow() (int64, error) {
+	now := max(n.now(), n.floor.Load())
+	if now < n.time && n.hook != nil {
+		n.hook.OnClockEvent(ClockEvent{Node: n.node, Kind: ClockBack, Delta: time.Duration(n.time-now) * time.Millisecond})
+	}
+	return n.checkClockBack(n.slew(now))
+}
+
+func (n *Node) hookGenerate(id ID) {
+	if n.hook != nil {
+		n.hook.OnGenerate(id)
+	}
+}
@@ -0,0 +1,135 @@
+package mkey
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordHook records every call it receives
+type recordHook struct {
+	mu     sync.Mutex
+	ids    []ID
+	waits  []time.Duration
+	events []ClockEvent
+}
+
+func (h *recordHook) OnGenerate(id ID) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.ids = append(h.ids, id)
+}
+
+func (h *recordHook) OnWait(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.waits = append(h.waits, d)
+}
+
+func (h *recordHook) OnClockEvent(e ClockEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.events = append(h.events, e)
+}
+
+func TestHookGenerate(t *testing.T) {
+	h := &recordHook{}
+	cfg := NewConfig()
+	cfg.Hook = h
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []ID{n.Generate()}
+	batch, err := n.GenerateBatch(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = append(want, batch...)
+	if !slices.Equal(h.ids, want) {
+		t.Fatalf("OnGenerate saw %v, want %v", h.ids, want)
+	}
+	if len(h.waits) != 0 || len(h.events) != 0 {
+		t.Fatalf("unexpected waits %v or clock events %v", h.waits, h.events)
+	}
+}
+
+func TestHookWait(t *testing.T) {
+	h := &recordHook{}
+	cfg := NewConfig()
+	cfg.StepBits = 1
+	cfg.Hook = h
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 10 {
+		n.Generate()
+	}
+	if len(h.waits) == 0 {
+		t.Fatal("OnWait not called after exhausting the step space")
+	}
+	if uint64(len(h.waits)) != n.Stats().Waits {
+		t.Fatalf("OnWait called %d times, Stats counts %d waits", len(h.waits), n.Stats().Waits)
+	}
+	for _, d := range h.waits {
+		if d <= 0 || d > time.Second {
+			t.Fatalf("OnWait(%s), want the time spent waiting for the next millisecond", d)
+		}
+	}
+}
+
+func TestHookClockBack(t *testing.T) {
+	h := &recordHook{}
+	clock := NewManualClock(time.Now())
+	cfg := NewConfig()
+	cfg.Node = 3
+	cfg.Clock = clock
+	cfg.Hook = h
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.Generate()
+	clock.Advance(-5 * time.Millisecond)
+	n.Generate()
+
+	want := []ClockEvent{{Node: 3, Kind: ClockBack, Delta: 5 * time.Millisecond}}
+	if !slices.Equal(h.events, want) {
+		t.Fatalf("OnClockEvent saw %v, want %v", h.events, want)
+	}
+	if got := h.events[0].String(); got != "mkey: node 3 clock moved back by 5ms" {
+		t.Fatalf("String() = %q", got)
+	}
+}
+
+func TestHookClockBorrow(t *testing.T) {
+	h := &recordHook{}
+	cfg := NewConfig()
+	cfg.Node = 3
+	cfg.StepBits = 1
+	cfg.Clock = NewManualClock(time.Now())
+	cfg.BorrowLimit = 2 * time.Millisecond
+	cfg.Hook = h
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 6 {
+		n.Generate()
+	}
+
+	want := []ClockEvent{
+		{Node: 3, Kind: ClockBorrow, Delta: time.Millisecond},
+		{Node: 3, Kind: ClockBorrow, Delta: 2 * time.Millisecond},
+	}
+	if !slices.Equal(h.events, want) {
+		t.Fatalf("OnClockEvent saw %v, want %v", h.events, want)
+	}
+	if got := h.events[1].String(); !strings.Contains(got, "borrowed 2ms ahead") {
+		t.Fatalf("String() = %q", got)
+	}
+}
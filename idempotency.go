@@ -0,0 +1,93 @@
+package mkey
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strings"
+)
+
+// IdempotencyMACSize is the length of the truncated HMAC-SHA256 in an IdempotencyKey
+const IdempotencyMACSize = 16
+
+// IdempotencyKey pairs a generated ID with an HMAC over the request
+// attributes it was issued for, so a server can hand out keys and later
+// check that a retry carries the same attributes
+type IdempotencyKey struct {
+	ID  ID
+	MAC [IdempotencyMACSize]byte
+}
+
+// NewIdempotencyKey generates an ID on node and binds it to attrs.
+// Attributes are order-sensitive; pass e.g. method, path, tenant, body hash.
+func NewIdempotencyKey(node *Node, secret []byte, attrs ...string) IdempotencyKey {
+	k := IdempotencyKey{ID: node.Generate()}
+	k.MAC = idempotencyMAC(k.ID, secret, attrs)
+	return k
+}
+
+// Verify reports whether the key was issued with secret for exactly attrs.
+// The comparison is constant-time.
+func (k IdempotencyKey) Verify(secret []byte, attrs ...string) bool {
+	mac := idempotencyMAC(k.ID, secret, attrs)
+	return hmac.Equal(mac[:], k.MAC[:])
+}
+
+func idempotencyMAC(id ID, secret []byte, attrs []string) [IdempotencyMACSize]byte {
+	h := hmac.New(sha256.New, secret)
+	var buf [binary.MaxVarintLen64]byte
+	h.Write(id.Bytes())
+	for _, a := range attrs {
+		// Length-prefix each attribute so ("ab", "c") and ("a", "bc") differ
+		h.Write(buf[:binary.PutUvarint(buf[:], uint64(len(a)))])
+		h.Write([]byte(a))
+	}
+
+	var mac [IdempotencyMACSize]byte
+	copy(mac[:], h.Sum(nil))
+	return mac
+}
+
+// String returns the key as <base58 ID>.<base64url MAC>, safe for HTTP headers
+func (k IdempotencyKey) String() string {
+	return k.ID.Base58() + "." + base64.RawURLEncoding.EncodeToString(k.MAC[:])
+}
+
+// ParseIdempotencyKey parses the String form of a key. It does not verify it.
+func ParseIdempotencyKey(s string) (IdempotencyKey, error) {
+	var k IdempotencyKey
+
+	idPart, macPart, ok := strings.Cut(s, ".")
+	if !ok {
+		return k, errors.New("invalid idempotency key format")
+	}
+	id, err := ParseBase58([]byte(idPart))
+	if err != nil {
+		return k, err
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(macPart)
+	if err != nil || len(mac) != IdempotencyMACSize {
+		return k, errors.New("invalid idempotency key MAC")
+	}
+
+	k.ID = id
+	copy(k.MAC[:], mac)
+	return k, nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (k IdempotencyKey) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (k *IdempotencyKey) UnmarshalText(b []byte) error {
+	parsed, err := ParseIdempotencyKey(string(b))
+	if err != nil {
+		return err
+	}
+	*k = parsed
+	return nil
+}
@@ -0,0 +1,66 @@
+package mkey
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestIdempotencyKeyVerify(t *testing.T) {
+	n, err := NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := []byte("server secret")
+	k := NewIdempotencyKey(n, secret, "POST", "/orders", "tenant-1")
+
+	if !k.Verify(secret, "POST", "/orders", "tenant-1") {
+		t.Fatal("key does not verify with its own attributes")
+	}
+	for name, attrs := range map[string][]string{
+		"other path":   {"POST", "/refunds", "tenant-1"},
+		"reordered":    {"/orders", "POST", "tenant-1"},
+		"regrouped":    {"POST", "/", "orderstenant-1"},
+		"missing attr": {"POST", "/orders"},
+	} {
+		if k.Verify(secret, attrs...) {
+			t.Errorf("%s: verified", name)
+		}
+	}
+	if k.Verify([]byte("other secret"), "POST", "/orders", "tenant-1") {
+		t.Error("verified under another secret")
+	}
+
+	forged := k
+	forged.ID++
+	if forged.Verify(secret, "POST", "/orders", "tenant-1") {
+		t.Error("verified with a different ID")
+	}
+}
+
+func TestIdempotencyKeyEncoding(t *testing.T) {
+	n, err := NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := NewIdempotencyKey(n, []byte("s"), "a")
+
+	got, err := ParseIdempotencyKey(k.String())
+	if err != nil || got != k {
+		t.Fatalf("ParseIdempotencyKey(%q) = %+v, %v", k.String(), got, err)
+	}
+
+	b, err := json.Marshal(map[string]IdempotencyKey{"k": k})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]IdempotencyKey
+	if err := json.Unmarshal(b, &m); err != nil || m["k"] != k {
+		t.Fatalf("JSON round trip = %+v, %v", m, err)
+	}
+
+	for _, bad := range []string{"", "nodot", "0OIl." + k.String()[len(k.ID.Base58())+1:], k.ID.Base58() + ".short", k.ID.Base58() + ".!!!"} {
+		if _, err := ParseIdempotencyKey(bad); err == nil {
+			t.Errorf("ParseIdempotencyKey(%q) succeeded", bad)
+		}
+	}
+}
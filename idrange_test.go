@@ -0,0 +1,79 @@
+package mkey
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestGenerateRange(t *testing.T) {
+	n, _ := NewNode(4)
+	l := n.Layout()
+	before := n.Generate()
+
+	r, err := n.GenerateRange(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Count != 100 || r.First <= before || r.Last() != r.First+99 {
+		t.Fatalf("range = %+v after %v", r, before)
+	}
+	if l.Time(r.First) != l.Time(r.Last()) || l.NodeID(r.Last()) != 4 {
+		t.Fatal("range spans milliseconds or leaves the node")
+	}
+	if next := n.Generate(); next <= r.Last() {
+		t.Fatalf("Generate %v does not follow the range ending %v", next, r.Last())
+	}
+
+	ids := slices.Collect(r.All())
+	if len(ids) != 100 || ids[0] != r.First || ids[99] != r.Last() || r.At(42) != r.First+42 {
+		t.Fatalf("All/At disagree with the bounds")
+	}
+	for id := range r.All() {
+		if id != r.First {
+			t.Fatal("All ignored an early stop")
+		}
+		break
+	}
+	if !r.Contains(r.First) || !r.Contains(r.Last()) || r.Contains(r.Last()+1) || r.Contains(r.First-1) {
+		t.Fatal("Contains is wrong at the bounds")
+	}
+	if (IDRange{First: 5}).Contains(5) {
+		t.Fatal("empty range contains its First")
+	}
+
+	for _, i := range []int{-1, 100} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("At(%d) did not panic", i)
+				}
+			}()
+			r.At(i)
+		}()
+	}
+}
+
+func TestGenerateRangeInvalid(t *testing.T) {
+	n, _ := NewNode(4)
+	for _, c := range []int{0, -1, int(n.stepLimit) + 1} {
+		if _, err := n.GenerateRange(c); err == nil {
+			t.Errorf("GenerateRange(%d) succeeded", c)
+		}
+	}
+	for name, tweak := range map[string]func(*Config){
+		"ChildBits":       func(c *Config) { c.ChildBits = 2 },
+		"ScrambleSteps":   func(c *Config) { c.ScrambleSteps = true },
+		"RandomStepStart": func(c *Config) { c.RandomStepStart = true },
+	} {
+		cfg := NewConfig()
+		cfg.Node = 1
+		tweak(cfg)
+		n, err := NewNodeWithConfig(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := n.GenerateRange(10); err == nil {
+			t.Errorf("%s: GenerateRange succeeded", name)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package mkey
+
+import (
+	"bufio"
+	"io"
+	"iter"
+	"strconv"
+)
+
+// WriteIDsJSON writes ids to w as a JSON array, encoding each ID as it is
+// produced, for endpoints returning more IDs than are worth materializing.
+// EncodingDecimal writes bare numbers like ID.MarshalJSON; other encodings
+// write strings. Encoded IDs never need escaping.
+func WriteIDsJSON(w io.Writer, ids iter.Seq[ID], enc Encoding) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteByte('[')
+
+	var buf []byte
+	first := true
+	for id := range ids {
+		buf = buf[:0]
+		if !first {
+			buf = append(buf, ',')
+		}
+		first = false
+
+		if enc == EncodingDecimal {
+			buf = strconv.AppendInt(buf, int64(id), 10)
+		} else {
+			buf = append(buf, '"')
+			buf = append(buf, enc.Encode(id)...)
+			buf = append(buf, '"')
+		}
+		if _, err := bw.Write(buf); err != nil {
+			return err
+		}
+	}
+
+	bw.WriteByte(']')
+	return bw.Flush()
+}
@@ -0,0 +1,80 @@
+package mkey
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestWriteIDsJSON(t *testing.T) {
+	ids := []ID{1, 61, 62, 1 << 40, 1<<63 - 1}
+	for i := range len(encodingNames) {
+		enc := Encoding(i)
+		var buf bytes.Buffer
+		if err := WriteIDsJSON(&buf, slices.Values(ids), enc); err != nil {
+			t.Fatalf("%v: %v", enc, err)
+		}
+
+		var got []ID
+		if enc == EncodingDecimal {
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatalf("%v: %s is not a JSON array of IDs: %v", enc, buf.Bytes(), err)
+			}
+		} else {
+			var strs []string
+			if err := json.Unmarshal(buf.Bytes(), &strs); err != nil {
+				t.Fatalf("%v: %s is not a JSON array of strings: %v", enc, buf.Bytes(), err)
+			}
+			for _, s := range strs {
+				id, err := enc.Parse(s)
+				if err != nil {
+					t.Fatalf("%v: Parse(%q): %v", enc, s, err)
+				}
+				got = append(got, id)
+			}
+		}
+		if !slices.Equal(got, ids) {
+			t.Fatalf("%v: %s decodes to %v, want %v", enc, buf.Bytes(), got, ids)
+		}
+	}
+}
+
+func TestWriteIDsJSONEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteIDsJSON(&buf, slices.Values([]ID(nil)), EncodingBase58); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "[]" {
+		t.Fatalf("empty sequence wrote %q, want []", buf.String())
+	}
+}
+
+type failWriter struct{ err error }
+
+func (w failWriter) Write([]byte) (int, error) { return 0, w.err }
+
+func TestWriteIDsJSONWriteError(t *testing.T) {
+	werr := errors.New("connection reset")
+	yielded := 0
+	ids := func(yield func(ID) bool) {
+		for i := range 100000 {
+			yielded++
+			if !yield(ID(i)) {
+				return
+			}
+		}
+	}
+	if err := WriteIDsJSON(failWriter{werr}, ids, EncodingDecimal); !errors.Is(err, werr) {
+		t.Fatalf("WriteIDsJSON = %v, want the writer's error", err)
+	}
+	// the sequence is abandoned at the first failed write, not drained
+	if yielded == 100000 {
+		t.Fatal("WriteIDsJSON kept consuming IDs after the writer failed")
+	}
+
+	if err := WriteIDsJSON(failWriter{werr}, slices.Values([]ID{1}), EncodingDecimal); !errors.Is(err, werr) {
+		t.Fatalf("WriteIDsJSON of a short sequence = %v, want the writer's error from Flush", err)
+	}
+}
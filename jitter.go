@@ -0,0 +1,18 @@
+package mkey
+
+import "time"
+
+// MaxJitter is the largest supported Config.Jitter
+const MaxJitter = time.Second
+
+// jitterLag returns how far the node's timestamps lag its clock under
+// Config.Jitter: a whole number of milliseconds below jitter, derived from
+// the node ID so that every restart of the node gets the same lag and its
+// timestamps stay monotonic across restarts
+func jitterLag(node int64, jitter time.Duration) time.Duration {
+	ms := jitter.Milliseconds()
+	if ms <= 0 {
+		return 0
+	}
+	return time.Duration(ID(node).Hash64()%uint64(ms)) * time.Millisecond
+}
@@ -0,0 +1,62 @@
+package mkey
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJitterLag(t *testing.T) {
+	if jitterLag(5, 0) != 0 || jitterLag(5, 500*time.Microsecond) != 0 {
+		t.Fatal("sub-millisecond jitter lags")
+	}
+	lags := make(map[time.Duration]bool)
+	for node := range int64(64) {
+		lag := jitterLag(node, 100*time.Millisecond)
+		if lag < 0 || lag >= 100*time.Millisecond || lag%time.Millisecond != 0 {
+			t.Fatalf("jitterLag(%d) = %v", node, lag)
+		}
+		if jitterLag(node, 100*time.Millisecond) != lag {
+			t.Fatal("jitterLag is not stable")
+		}
+		lags[lag] = true
+	}
+	if len(lags) < 30 {
+		t.Fatalf("64 nodes got only %d distinct lags", len(lags))
+	}
+}
+
+func TestNodeJitter(t *testing.T) {
+	start := time.Now()
+	newNode := func(node int64) *Node {
+		cfg := NewConfig()
+		cfg.Node = node
+		cfg.Clock = NewManualClock(start)
+		cfg.Jitter = 100 * time.Millisecond
+		n, err := NewNodeWithConfig(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	// nodes in lockstep still issue at lags fixed per node
+	for node := range int64(8) {
+		n := newNode(node)
+		lag := start.UnixMilli() - n.Layout().Time(n.Generate())
+		if time.Duration(lag)*time.Millisecond != jitterLag(node, 100*time.Millisecond) {
+			t.Fatalf("node %d lags %dms, want %v", node, lag, jitterLag(node, 100*time.Millisecond))
+		}
+		// a restarted node keeps its lag
+		if again := newNode(node); start.UnixMilli()-again.Layout().Time(again.Generate()) != lag {
+			t.Fatalf("node %d changed its lag across restarts", node)
+		}
+	}
+
+	for _, j := range []time.Duration{-time.Millisecond, MaxJitter + time.Millisecond} {
+		cfg := NewConfig()
+		cfg.Jitter = j
+		if _, err := NewNodeWithConfig(cfg); err == nil {
+			t.Errorf("Jitter %v accepted", j)
+		}
+	}
+}
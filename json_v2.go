@@ -0,0 +1,21 @@
+//go:build go1.27 && goexperiment.jsonv2
+
+package mkey
+
+import "encoding/json/jsontext"
+
+// MarshalJSONTo implements json.MarshalerTo from encoding/json/v2, writing
+// the same bare number as MarshalJSON
+func (f ID) MarshalJSONTo(e *jsontext.Encoder) error {
+	return e.WriteToken(jsontext.Int(int64(f)))
+}
+
+// UnmarshalJSONFrom implements json.UnmarshalerFrom from encoding/json/v2.
+// It accepts the same forms as UnmarshalJSON, as enabled in JSONDecoding.
+func (f *ID) UnmarshalJSONFrom(d *jsontext.Decoder) error {
+	v, err := d.ReadValue()
+	if err != nil {
+		return err
+	}
+	return f.UnmarshalJSON(v)
+}
@@ -0,0 +1,36 @@
+//go:build go1.27 && goexperiment.jsonv2
+
+package mkey
+
+import (
+	"encoding/json/v2"
+	"testing"
+)
+
+func TestJSONv2(t *testing.T) {
+	type doc struct {
+		ID  ID
+		IDs []ID
+	}
+	in := doc{ID: 1234567890123, IDs: []ID{1, 1<<62 + 5}}
+	b, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"ID":1234567890123,"IDs":[1,4611686018427387909]}`; string(b) != want {
+		t.Fatalf("Marshal = %s, want %s", b, want)
+	}
+	var out doc
+	if err := json.Unmarshal(b, &out); err != nil || out.ID != in.ID || len(out.IDs) != 2 || out.IDs[1] != in.IDs[1] {
+		t.Fatalf("Unmarshal = %+v, %v", out, err)
+	}
+
+	// the v1 decoding options carry over
+	if err := json.Unmarshal([]byte(`{"ID":"42","IDs":[null]}`), &out); err != nil || out.ID != 42 || out.IDs[0] != Nil {
+		t.Fatalf("Unmarshal quoted/null = %+v, %v", out, err)
+	}
+	setJSONDecoding(t, JSONDecodeOptions{})
+	if err := json.Unmarshal([]byte(`{"ID":"42"}`), &out); err == nil {
+		t.Fatal("quoted ID accepted with strict JSONDecoding")
+	}
+}
@@ -0,0 +1,71 @@
+package mkey
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Lane selects which share of each millisecond's step space a call may use
+type Lane uint8
+
+const (
+	// LaneNormal leaves the last Config.ReservedSteps steps of every
+	// millisecond unused; all generation methods except GenerateLane use it
+	LaneNormal Lane = iota
+
+	// LaneHigh may use the whole step space, so interactive callers still
+	// get IDs without waiting while bulk jobs saturate the normal lane
+	LaneHigh
+)
+
+// GenerateLane creates an ID in the given lane. Callers in LaneNormal that
+// find their share of the millisecond used up wait for the next one without
+// holding the node's lock, so LaneHigh callers are never queued behind them.
+func (n *Node) GenerateLane(lane Lane) (ID, error) {
+	switch lane {
+	case LaneNormal:
+		return n.generateContext(context.Background(), 0)
+	case LaneHigh:
+		return n.generateUpTo(context.Background(), 0, n.stepMask)
+	}
+	return 0, fmt.Errorf("unknown lane %d", lane)
+}
+
+// laneFull reports whether a LaneNormal call that would use step counters up
+// to last must leave the rest of the millisecond n.time to LaneHigh. Callers
+// hold the lock.
+func (n *Node) laneFull(now, last int64) bool {
+	return n.stepLimit < n.stepMask && now == n.time && last > n.stepLimit && n.step < n.stepMask
+}
+
+// waitLane waits for the millisecond after n.time with the lock released, so
+// LaneHigh callers can take the reserved steps meanwhile. Contexts from
+// TryGenerate fail at once with ErrSequenceExhausted. Callers hold the lock,
+// which is held again on return.
+func (n *Node) waitLane(ctx context.Context) error {
+	if ctx.Value(noWaitKey{}) != nil {
+		return ErrSequenceExhausted
+	}
+	n.waits++
+	n.secWaits.add(n.time/1000, 1)
+	d := min(n.untilNextMilli(), time.Millisecond)
+
+	n.unlock()
+	defer n.lock()
+	if d <= 0 {
+		return nil
+	}
+
+	var err error
+	n.instrumentWait(ctx, waitReservedSteps, func() {
+		t := time.NewTimer(d)
+		defer t.Stop()
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	})
+	return err
+}
@@ -0,0 +1,122 @@
+package mkey
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func newLaneNode(t *testing.T) (*Node, *ManualClock) {
+	t.Helper()
+	clock := NewManualClock(time.Now())
+	cfg := NewConfig()
+	cfg.Node = 1
+	cfg.StepBits = 3
+	cfg.ReservedSteps = 2
+	cfg.Clock = clock
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n, clock
+}
+
+func TestLaneReservedSteps(t *testing.T) {
+	n, _ := newLaneNode(t)
+	l := n.Layout()
+
+	var ids []ID
+	for {
+		id, err := n.TryGenerate()
+		if errors.Is(err, ErrSequenceExhausted) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) != 6 {
+		t.Fatalf("normal lane issued %d IDs in a millisecond, want 8 steps less 2 reserved", len(ids))
+	}
+
+	for i := range 2 {
+		id, err := n.GenerateLane(LaneHigh)
+		if err != nil {
+			t.Fatalf("GenerateLane(LaneHigh) %d: %v", i, err)
+		}
+		if l.Time(id) != l.Time(ids[0]) {
+			t.Fatalf("high lane ID %d left the millisecond", i)
+		}
+		if id <= ids[len(ids)-1] {
+			t.Fatalf("high lane ID %d not above %d", id, ids[len(ids)-1])
+		}
+		ids = append(ids, id)
+	}
+	if got := l.Step(ids[len(ids)-1]); got != l.MaxStep() {
+		t.Fatalf("last high lane step = %d, want %d", got, l.MaxStep())
+	}
+}
+
+func TestLaneHighNotQueued(t *testing.T) {
+	n, clock := newLaneNode(t)
+	for range 6 {
+		n.Generate()
+	}
+
+	// a normal caller waiting for the next millisecond does not hold the
+	// lock, so high lane callers still get the reserved steps
+	normal := make(chan ID, 1)
+	go func() {
+		id, _ := n.GenerateLane(LaneNormal)
+		normal <- id
+	}()
+	time.Sleep(10 * time.Millisecond)
+	select {
+	case <-normal:
+		t.Fatal("normal lane took a reserved step")
+	default:
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := n.GenerateLane(LaneHigh)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("high lane call queued behind a waiting normal one")
+	}
+
+	clock.Advance(time.Millisecond)
+	select {
+	case id := <-normal:
+		if got, want := n.Layout().Time(id), clock.Now().UnixMilli(); got != want {
+			t.Fatalf("normal lane ID has time %d, want %d", got, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("normal lane call never got the next millisecond")
+	}
+}
+
+func TestGenerateLaneUnknown(t *testing.T) {
+	n, _ := newLaneNode(t)
+	if _, err := n.GenerateLane(Lane(9)); err == nil {
+		t.Fatal("GenerateLane accepted an unknown lane")
+	}
+}
+
+func TestReservedStepsRange(t *testing.T) {
+	for _, reserved := range []int{-1, 7} {
+		cfg := NewConfig()
+		cfg.StepBits = 3
+		cfg.ReservedSteps = reserved
+		if _, err := NewNodeWithConfig(cfg); err == nil {
+			t.Errorf("NewNodeWithConfig accepted ReservedSteps %d of 8 steps", reserved)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package mkey
+
+import (
+	"math/bits"
+	"time"
+)
+
+// Latencies are bucketed log-linearly: exactly below 16ns, then eight
+// buckets per power of two, for a relative error of at most 12.5%
+const (
+	latencySubBits = 3
+	latencyExact   = 1 << (latencySubBits + 1)
+	latencyBuckets = (63-latencySubBits)<<latencySubBits + 1<<latencySubBits
+)
+
+// LatencyHistogram is a distribution of generation latencies, including time
+// spent waiting for the next millisecond or a maintenance window to end
+type LatencyHistogram struct {
+	counts [latencyBuckets]uint64
+	total  uint64
+	max    time.Duration
+}
+
+// LatencyBucket is a histogram bucket holding latencies up to UpperBound
+type LatencyBucket struct {
+	UpperBound time.Duration
+	Count      uint64
+}
+
+func latencyIndex(d time.Duration) int {
+	v := uint64(max(d, 0))
+	if v < latencyExact {
+		return int(v)
+	}
+	exp := bits.Len64(v) - 1
+	m := v >> (exp - latencySubBits)
+	return (exp-latencySubBits)<<latencySubBits + int(m)
+}
+
+func latencyUpperBound(i int) time.Duration {
+	if i < latencyExact {
+		return time.Duration(i)
+	}
+	exp := i>>latencySubBits + latencySubBits - 1
+	m := uint64(i&(1<<latencySubBits-1) + 1<<latencySubBits)
+	return time.Duration((m+1)<<(exp-latencySubBits) - 1)
+}
+
+func (h *LatencyHistogram) record(d time.Duration) {
+	h.counts[latencyIndex(d)]++
+	h.total++
+	h.max = max(h.max, d)
+}
+
+// Count returns the number of recorded calls
+func (h *LatencyHistogram) Count() uint64 {
+	return h.total
+}
+
+// Max returns the largest recorded latency
+func (h *LatencyHistogram) Max() time.Duration {
+	return h.max
+}
+
+// Quantile returns an upper bound of the q-quantile latency, e.g. 0.99 for p99
+func (h *LatencyHistogram) Quantile(q float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	rank := uint64(q * float64(h.total))
+	var seen uint64
+	for i, c := range h.counts {
+		seen += c
+		if seen > rank {
+			return min(latencyUpperBound(i), h.max)
+		}
+	}
+	return h.max
+}
+
+// Buckets returns the non-empty buckets in increasing order
+func (h *LatencyHistogram) Buckets() []LatencyBucket {
+	var b []LatencyBucket
+	for i, c := range h.counts {
+		if c > 0 {
+			b = append(b, LatencyBucket{UpperBound: latencyUpperBound(i), Count: c})
+		}
+	}
+	return b
+}
+
+// latencyStart returns the start time of a call, or the zero time when the
+// histogram is disabled so the hot path skips reading the clock
+func (n *Node) latencyStart() time.Time {
+	if n.latency == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// recordLatency records the call that began at start. Callers hold n.mu.
+func (n *Node) recordLatency(start time.Time) {
+	if n.latency != nil {
+		n.latency.record(time.Since(start))
+	}
+}
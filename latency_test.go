@@ -0,0 +1,98 @@
+package mkey
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyBuckets(t *testing.T) {
+	// every duration falls in a bucket whose bound is at most 12.5% above it
+	for _, d := range []time.Duration{0, 1, 15, 16, 17, 100, 999, time.Microsecond, time.Millisecond, 3 * time.Second, time.Hour, 1<<63 - 1} {
+		i := latencyIndex(d)
+		if i < 0 || i >= latencyBuckets {
+			t.Fatalf("latencyIndex(%v) = %d, out of range", d, i)
+		}
+		ub := latencyUpperBound(i)
+		if ub < d || float64(ub-d) > float64(d)/8 {
+			t.Errorf("latencyUpperBound for %v = %v", d, ub)
+		}
+		if i > 0 && latencyUpperBound(i-1) >= d {
+			t.Errorf("%v also fits bucket %d (bound %v)", d, i-1, latencyUpperBound(i-1))
+		}
+	}
+	for i := 1; i < latencyBuckets; i++ {
+		if latencyUpperBound(i) <= latencyUpperBound(i-1) {
+			t.Fatalf("bucket bounds not increasing at %d", i)
+		}
+	}
+}
+
+func TestLatencyQuantile(t *testing.T) {
+	var h LatencyHistogram
+	if h.Quantile(0.5) != 0 || h.Count() != 0 {
+		t.Fatal("empty histogram is not zero")
+	}
+	for i := range 99 {
+		h.record(time.Duration(1000 + i))
+	}
+	h.record(time.Millisecond)
+
+	if h.Count() != 100 || h.Max() != time.Millisecond {
+		t.Fatalf("Count %d, Max %v", h.Count(), h.Max())
+	}
+	if p50 := h.Quantile(0.5); p50 < 1049 || p50 > 1049*9/8 {
+		t.Errorf("p50 = %v, want about 1.05µs", p50)
+	}
+	if p99 := h.Quantile(0.99); p99 != time.Millisecond {
+		t.Errorf("p99 = %v, want the 1ms outlier", p99)
+	}
+	if q := h.Quantile(1); q != time.Millisecond {
+		t.Errorf("Quantile(1) = %v, want Max", q)
+	}
+
+	var total uint64
+	b := h.Buckets()
+	for i, bk := range b {
+		total += bk.Count
+		if i > 0 && bk.UpperBound <= b[i-1].UpperBound {
+			t.Fatal("Buckets not increasing")
+		}
+	}
+	if total != 100 || b[len(b)-1].Count != 1 {
+		t.Fatalf("Buckets = %v", b)
+	}
+}
+
+func TestNodeLatencyHistogram(t *testing.T) {
+	plain, _ := NewNode(1)
+	plain.Generate()
+	if plain.Stats().Latency != nil {
+		t.Fatal("Latency set without Config.LatencyHistogram")
+	}
+
+	cfg := NewConfig()
+	cfg.Node = 1
+	cfg.StepBits = 1
+	cfg.LatencyHistogram = true
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// two IDs per millisecond, so waits for the next one show up
+	for range 10 {
+		n.Generate()
+	}
+	s := n.Stats()
+	if s.Latency == nil || s.Latency.Count() != 10 {
+		t.Fatalf("Latency = %+v, want 10 calls", s.Latency)
+	}
+	if s.Latency.Max() < 100*time.Microsecond {
+		t.Fatalf("Max = %v, want the wait for a millisecond included", s.Latency.Max())
+	}
+
+	// the snapshot does not change with later calls
+	n.Generate()
+	if s.Latency.Count() != 10 {
+		t.Fatal("Stats snapshot shares the live histogram")
+	}
+}
@@ -0,0 +1,290 @@
+package mkey
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"strings"
+	"time"
+)
+
+// Layout describes how the bits of an ID are allocated.
+// It carries everything needed to decode an ID without a Node.
+type Layout struct {
+	Epoch    int64
+	NodeBits uint8
+	StepBits uint8
+
+	// ExpiryBits reserves bits between the timestamp and the node for an
+	// expiry offset, counted in ExpiryUnit (default one second) from the
+	// creation time. An offset of zero means the ID never expires.
+	ExpiryBits uint8
+	ExpiryUnit time.Duration
+
+	// PriorityBits reserves the bits above the timestamp for a priority, so
+	// IDs sort by priority first and by time second
+	PriorityBits uint8
+
+	// TombstoneBit reserves the bit between the shard and expiry fields to
+	// mark an ID as the tombstone of the ID with the bit cleared (see ID.Tombstone)
+	TombstoneBit bool
+
+	// ShardBits reserves bits directly below the timestamp for a
+	// caller-chosen shard (see Node.GenerateBatchSharded)
+	ShardBits uint8
+
+	// ChildBits reserves the low bits of the step for child IDs (see
+	// Node.Child). Nodes only issue steps with these bits clear, so each
+	// millisecond holds 1<<ChildBits times fewer IDs.
+	ChildBits uint8
+}
+
+// DefaultLayout returns the layout used by NewNode
+func DefaultLayout() Layout {
+	return Layout{
+		Epoch:    DefaultEpoch,
+		NodeBits: DefaultNodeBits,
+		StepBits: DefaultStepBits,
+	}
+}
+
+// Layout returns the layout described by the configuration
+func (c *Config) Layout() Layout {
+	epoch := c.Epoch
+	if named, ok := LookupEpoch(c.EpochName); ok && c.EpochName != "" {
+		epoch = named
+	}
+	return Layout{
+		Epoch:        epoch,
+		NodeBits:     c.NodeBits,
+		StepBits:     c.StepBits,
+		ExpiryBits:   c.ExpiryBits,
+		ExpiryUnit:   c.ExpiryUnit,
+		PriorityBits: c.PriorityBits,
+		TombstoneBit: c.TombstoneBit,
+		ShardBits:    c.ShardBits,
+		ChildBits:    c.ChildBits,
+	}
+}
+
+// Layout returns the layout used by the node
+func (n *Node) Layout() Layout {
+	return n.layout
+}
+
+// Validate checks that the bit allocation is supported
+func (l Layout) Validate() error {
+	if l.NodeBits > MaxNodeBits {
+		return fmt.Errorf("NodeBits must be <= %d", MaxNodeBits)
+	}
+	if l.StepBits > MaxStepBits {
+		return fmt.Errorf("StepBits must be <= %d", MaxStepBits)
+	}
+	if l.PriorityBits > 8 {
+		return errors.New("PriorityBits must be <= 8")
+	}
+	if l.NodeBits+l.StepBits > 22 {
+		return errors.New("NodeBits + StepBits must be <= 22")
+	}
+	if l.NodeBits+l.StepBits+l.ExpiryBits > 22 {
+		return errors.New("NodeBits + StepBits + ExpiryBits must be <= 22")
+	}
+	if l.PriorityBits+l.NodeBits+l.StepBits+l.ExpiryBits > 22 {
+		return errors.New("PriorityBits + NodeBits + StepBits + ExpiryBits must be <= 22")
+	}
+	if l.TombstoneBit && l.PriorityBits+l.NodeBits+l.StepBits+l.ExpiryBits+1 > 22 {
+		return errors.New("PriorityBits + NodeBits + StepBits + ExpiryBits + TombstoneBit must be <= 22")
+	}
+	if l.ShardBits > 0 && l.PriorityBits+l.NodeBits+l.StepBits+l.ExpiryBits+l.tombstoneBits()+l.ShardBits > 22 {
+		return errors.New("PriorityBits + NodeBits + StepBits + ExpiryBits + TombstoneBit + ShardBits must be <= 22")
+	}
+	if l.ChildBits > l.StepBits {
+		return errors.New("ChildBits must be <= StepBits")
+	}
+	if l.ExpiryUnit < 0 {
+		return errors.New("ExpiryUnit must not be negative")
+	}
+	return nil
+}
+
+// TimeShift returns the bit position of the timestamp component
+func (l Layout) TimeShift() uint8 {
+	return l.ShardBits + l.tombstoneBits() + l.ExpiryBits + l.NodeBits + l.StepBits
+}
+
+// TimeBits returns the width of the timestamp component
+func (l Layout) TimeBits() uint8 {
+	return 63 - l.PriorityBits - l.TimeShift()
+}
+
+// TimeMask returns the mask of the timestamp component, before shifting
+func (l Layout) TimeMask() int64 {
+	return -1 ^ (-1 << l.TimeBits())
+}
+
+// NodeMask returns the mask of the node component, before shifting
+func (l Layout) NodeMask() int64 {
+	return -1 ^ (-1 << l.NodeBits)
+}
+
+// StepMask returns the mask of the step component
+func (l Layout) StepMask() int64 {
+	return -1 ^ (-1 << l.StepBits)
+}
+
+// MaxNode returns the largest node ID the layout can encode
+func (l Layout) MaxNode() int64 {
+	return l.NodeMask()
+}
+
+// MaxStep returns the largest step the layout can encode; a node issues at
+// most MaxStep()+1 IDs per millisecond, or 1<<ChildBits times fewer
+func (l Layout) MaxStep() int64 {
+	return l.StepMask()
+}
+
+// MaxID returns the largest ID the layout can produce with a timestamp at or
+// before at, with every other component at its maximum. It returns 0 if at
+// is before the epoch and is capped at the layout's last representable time.
+func (l Layout) MaxID(at time.Time) ID {
+	t := at.UnixMilli() - l.Epoch
+	if t < 0 {
+		return 0
+	}
+	t = min(t, l.TimeMask())
+
+	shift := l.TimeShift()
+	return ID(int64(l.MaxPriority())<<(63-l.PriorityBits) | t<<shift | (1<<shift - 1))
+}
+
+// Time returns the timestamp component of id in milliseconds since the Unix epoch
+func (l Layout) Time(id ID) int64 {
+	return (int64(id)>>l.TimeShift())&l.TimeMask() + l.Epoch
+}
+
+// Timestamp returns the timestamp component of id as a time.Time
+func (l Layout) Timestamp(id ID) time.Time {
+	return time.UnixMilli(l.Time(id))
+}
+
+// NodeID returns the node component of id
+func (l Layout) NodeID(id ID) int64 {
+	return (int64(id) >> l.StepBits) & l.NodeMask()
+}
+
+// Step returns the step component of id
+func (l Layout) Step(id ID) int64 {
+	return int64(id) & l.StepMask()
+}
+
+// Compose builds the ID with timestamp t, truncated to the millisecond, node
+// and step, so fixtures and backfills need not shift bits themselves. Every
+// other component is zero. It fails if the layout is invalid or a value is out
+// of the layout's range.
+func (l Layout) Compose(t time.Time, node, step int64) (ID, error) {
+	if err := l.Validate(); err != nil {
+		return 0, err
+	}
+	ms := t.UnixMilli() - l.Epoch
+	if ms < 0 || ms > l.TimeMask() {
+		return 0, fmt.Errorf("time must be between %s and %s",
+			time.UnixMilli(l.Epoch).UTC().Format(time.RFC3339Nano),
+			time.UnixMilli(l.Epoch+l.TimeMask()).UTC().Format(time.RFC3339Nano))
+	}
+	if node < 0 || node > l.MaxNode() {
+		return 0, fmt.Errorf("node must be between 0 and %d", l.MaxNode())
+	}
+	if step < 0 || step > l.MaxStep() {
+		return 0, fmt.Errorf("step must be between 0 and %d", l.MaxStep())
+	}
+	return ID(ms<<l.TimeShift() | node<<l.StepBits | step), nil
+}
+
+// Fingerprint returns a 32-bit checksum of the layout parameters.
+// Services exchanging IDs can compare fingerprints to detect mismatched configs.
+func (l Layout) Fingerprint() uint32 {
+	b := make([]byte, 10, 22)
+	binary.BigEndian.PutUint64(b[:8], uint64(l.Epoch))
+	b[8] = l.NodeBits
+	b[9] = l.StepBits
+
+	// Optional fields are only hashed when used, keeping fingerprints of
+	// plain layouts stable
+	if l.ExpiryBits > 0 {
+		b = append(b, l.ExpiryBits)
+		b = binary.BigEndian.AppendUint64(b, uint64(l.expiryUnit()))
+	}
+	if l.PriorityBits > 0 {
+		b = append(b, 'p', l.PriorityBits)
+	}
+	if l.TombstoneBit {
+		b = append(b, 't')
+	}
+	if l.ShardBits > 0 {
+		b = append(b, 's', l.ShardBits)
+	}
+	if l.ChildBits > 0 {
+		b = append(b, 'c', l.ChildBits)
+	}
+	return crc32.ChecksumIEEE(b)
+}
+
+// LayoutDiff describes one parameter that differs between two layouts
+type LayoutDiff struct {
+	Field string
+	Want  string
+	Got   string
+}
+
+// LayoutMismatchError lists every parameter that differs between two
+// layouts. It matches ErrLayoutMismatch with errors.Is.
+type LayoutMismatchError struct {
+	Diffs []LayoutDiff
+}
+
+func (e *LayoutMismatchError) Error() string {
+	var b strings.Builder
+	b.WriteString("incompatible layouts:")
+	for i, d := range e.Diffs {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, " %s %s != %s", d.Field, d.Want, d.Got)
+	}
+	return b.String()
+}
+
+// Is reports whether target is ErrLayoutMismatch
+func (e *LayoutMismatchError) Is(target error) bool {
+	return target == ErrLayoutMismatch
+}
+
+// CompatibleWith returns nil if IDs from other decode identically under l,
+// and a *LayoutMismatchError naming each differing parameter otherwise.
+// Services exchanging IDs can call it at startup with their peers' layouts.
+func (l Layout) CompatibleWith(other Layout) error {
+	var diffs []LayoutDiff
+	add := func(field string, want, got any) {
+		if want != got {
+			diffs = append(diffs, LayoutDiff{Field: field, Want: fmt.Sprint(want), Got: fmt.Sprint(got)})
+		}
+	}
+
+	add("Epoch", l.Epoch, other.Epoch)
+	add("NodeBits", l.NodeBits, other.NodeBits)
+	add("StepBits", l.StepBits, other.StepBits)
+	add("ExpiryBits", l.ExpiryBits, other.ExpiryBits)
+	if l.ExpiryBits > 0 || other.ExpiryBits > 0 {
+		add("ExpiryUnit", l.expiryUnit(), other.expiryUnit())
+	}
+	add("PriorityBits", l.PriorityBits, other.PriorityBits)
+	add("TombstoneBit", l.TombstoneBit, other.TombstoneBit)
+	add("ShardBits", l.ShardBits, other.ShardBits)
+	add("ChildBits", l.ChildBits, other.ChildBits)
+
+	if len(diffs) > 0 {
+		return &LayoutMismatchError{Diffs: diffs}
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package mkey
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCompatibleWith(t *testing.T) {
+	l := DefaultLayout()
+	if err := l.CompatibleWith(DefaultLayout()); err != nil {
+		t.Fatalf("identical layouts: %v", err)
+	}
+
+	// an unset ExpiryUnit only matters once expiry bits are in use
+	u := l
+	u.ExpiryUnit = time.Minute
+	if err := l.CompatibleWith(u); err != nil {
+		t.Fatalf("ExpiryUnit without ExpiryBits: %v", err)
+	}
+
+	other := l
+	other.NodeBits++
+	other.StepBits--
+	other.TombstoneBit = true
+	err := l.CompatibleWith(other)
+	if !errors.Is(err, ErrLayoutMismatch) {
+		t.Fatalf("err = %v, want ErrLayoutMismatch", err)
+	}
+	var me *LayoutMismatchError
+	if !errors.As(err, &me) {
+		t.Fatalf("err is %T, want *LayoutMismatchError", err)
+	}
+	want := []LayoutDiff{
+		{"NodeBits", "10", "11"},
+		{"StepBits", "12", "11"},
+		{"TombstoneBit", "false", "true"},
+	}
+	if len(me.Diffs) != len(want) {
+		t.Fatalf("Diffs = %v, want %v", me.Diffs, want)
+	}
+	for i := range want {
+		if me.Diffs[i] != want[i] {
+			t.Errorf("Diffs[%d] = %v, want %v", i, me.Diffs[i], want[i])
+		}
+	}
+	if got := err.Error(); got != "incompatible layouts: NodeBits 10 != 11, StepBits 12 != 11, TombstoneBit false != true" {
+		t.Errorf("Error() = %q", got)
+	}
+
+	e1, e2 := l, l
+	e1.ExpiryBits, e2.ExpiryBits = 4, 4
+	e2.ExpiryUnit = time.Minute
+	if err := e1.CompatibleWith(e2); !errors.As(err, &me) || len(me.Diffs) != 1 || me.Diffs[0].Field != "ExpiryUnit" {
+		t.Fatalf("expiry unit mismatch: %v", err)
+	}
+}
+
+func TestLayoutMax(t *testing.T) {
+	l := DefaultLayout()
+	if l.MaxNode() != 1023 || l.MaxStep() != 4095 {
+		t.Fatalf("MaxNode %d, MaxStep %d; want 1023, 4095", l.MaxNode(), l.MaxStep())
+	}
+	at := time.UnixMilli(l.Epoch + 12345)
+	max := l.MaxID(at)
+	if l.Time(max) != at.UnixMilli() || l.NodeID(max) != l.MaxNode() || l.Step(max) != l.MaxStep() {
+		t.Fatalf("MaxID(at) = %v: time %d node %d step %d", max, l.Time(max), l.NodeID(max), l.Step(max))
+	}
+	// every ID composed at or before at is bounded by MaxID
+	for _, c := range [][3]int64{{12345, 1023, 4095}, {12345, 0, 0}, {0, 1023, 4095}} {
+		id, err := l.Compose(time.UnixMilli(l.Epoch+c[0]), c[1], c[2])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id > max {
+			t.Errorf("Compose%v = %v exceeds MaxID %v", c, id, max)
+		}
+	}
+	next, _ := l.Compose(at.Add(time.Millisecond), 0, 0)
+	if next != max+1 {
+		t.Errorf("first ID after at = %v, want MaxID+1 = %v", next, max+1)
+	}
+
+	if got := l.MaxID(time.UnixMilli(l.Epoch - 1)); got != 0 {
+		t.Errorf("MaxID before the epoch = %v, want 0", got)
+	}
+	if got := l.MaxID(time.UnixMilli(1 << 62)); got != 1<<63-1 {
+		t.Errorf("MaxID past the end = %v, want the largest int64", got)
+	}
+
+	p := l
+	p.StepBits = 10
+	p.PriorityBits = 2
+	if got := p.MaxID(at); p.Priority(got) != p.MaxPriority() {
+		t.Errorf("MaxID priority = %d, want %d", p.Priority(got), p.MaxPriority())
+	}
+}
+
+func TestMaxSafeJSONID(t *testing.T) {
+	if ID(float64(MaxSafeJSONID)) != MaxSafeJSONID {
+		t.Fatal("MaxSafeJSONID does not survive a float64 round trip")
+	}
+	// 2^53+1 is the first integer a float64 cannot hold
+	if ID(float64(MaxSafeJSONID+2)) == MaxSafeJSONID+2 {
+		t.Fatal("MaxSafeJSONID is not the largest safe value")
+	}
+}
+
+func TestLayoutCompose(t *testing.T) {
+	cfg := NewConfig()
+	cfg.NodeBits = 8
+	cfg.StepBits = 14
+	l := cfg.Layout()
+
+	at := time.Date(2025, 6, 1, 12, 0, 0, 123_456_789, time.UTC)
+	id, err := l.Compose(at, 200, 9000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := l.Time(id), at.UnixMilli(); got != want {
+		t.Fatalf("Time = %d, want %d truncated to the millisecond", got, want)
+	}
+	if l.NodeID(id) != 200 || l.Step(id) != 9000 {
+		t.Fatalf("NodeID, Step = %d, %d, want 200, 9000", l.NodeID(id), l.Step(id))
+	}
+
+	// a composed ID is exactly what a node issues for the same fields
+	cfg.Node = 200
+	cfg.Clock = NewManualClock(at)
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := l.Compose(at, 200, 0)
+	if got := n.Generate(); got != want {
+		t.Fatalf("Generate = %d, Compose = %d", got, want)
+	}
+
+	maxTime := time.UnixMilli(l.Epoch + l.TimeMask())
+	if id, err := l.Compose(maxTime, l.MaxNode(), l.MaxStep()); err != nil || id != l.MaxID(maxTime) {
+		t.Fatalf("Compose at the limits = %d, %v, want %d", id, err, l.MaxID(maxTime))
+	}
+}
+
+func TestLayoutComposeRange(t *testing.T) {
+	l := DefaultLayout()
+	at := time.UnixMilli(l.Epoch).Add(time.Hour)
+	for name, compose := range map[string]func() (ID, error){
+		"time before epoch": func() (ID, error) { return l.Compose(time.UnixMilli(l.Epoch-1), 0, 0) },
+		"time past the end": func() (ID, error) { return l.Compose(time.UnixMilli(l.Epoch+l.TimeMask()+1), 0, 0) },
+		"negative node":     func() (ID, error) { return l.Compose(at, -1, 0) },
+		"node too large":    func() (ID, error) { return l.Compose(at, l.MaxNode()+1, 0) },
+		"negative step":     func() (ID, error) { return l.Compose(at, 0, -1) },
+		"step too large":    func() (ID, error) { return l.Compose(at, 0, l.MaxStep()+1) },
+	} {
+		if id, err := compose(); err == nil {
+			t.Errorf("%s: Compose = %d, want an error", name, id)
+		}
+	}
+
+	bad := l
+	bad.StepBits = MaxStepBits + 1
+	if _, err := bad.Compose(at, 0, 0); err == nil {
+		t.Error("Compose accepted an invalid layout")
+	}
+}
+
+func TestValidatePriorityBits(t *testing.T) {
+	l := Layout{Epoch: DefaultEpoch, NodeBits: 4, StepBits: 4, PriorityBits: 8}
+	if err := l.Validate(); err != nil {
+		t.Fatalf("8 priority bits: %v", err)
+	}
+	if l.MaxPriority() != 255 {
+		t.Fatalf("MaxPriority = %d, want 255", l.MaxPriority())
+	}
+	// GenerateWithPriority takes a uint8, so a ninth bit could never be set
+	l.PriorityBits = 9
+	if err := l.Validate(); err == nil {
+		t.Fatal("Validate accepted 9 priority bits")
+	}
+}
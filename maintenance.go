@@ -0,0 +1,158 @@
+package mkey
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrMaintenance is returned by TryGenerate while a maintenance window with the
+// MaintenanceReject policy is active
+var ErrMaintenance = errors.New("node is in a maintenance window")
+
+// ErrSequenceExhausted is returned by TryGenerate when the current
+// millisecond's step space is used up
+var ErrSequenceExhausted = errors.New("sequence exhausted for this millisecond")
+
+// noWaitKey marks a context whose calls fail with ErrSequenceExhausted
+// rather than wait for the next millisecond
+type noWaitKey struct{}
+
+// MaintenanceWindow is a period during which a Node pauses issuance,
+// e.g. while an operator steps the clock
+type MaintenanceWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+// MaintenancePolicy controls what happens to calls made during a maintenance window
+type MaintenancePolicy uint8
+
+const (
+	// MaintenanceQueue blocks callers until the window ends
+	MaintenanceQueue MaintenancePolicy = iota
+
+	// MaintenanceReject makes TryGenerate fail with ErrMaintenance.
+	// Generate cannot report errors and still waits for the window to end.
+	MaintenanceReject
+)
+
+// maintenance holds the schedule of a Node; it has its own lock so callers
+// waiting out a window do not hold the generator lock
+type maintenance struct {
+	// scheduled lets the hot path skip the lock when there are no windows
+	scheduled atomic.Bool
+
+	mu      sync.Mutex
+	windows []MaintenanceWindow
+	policy  MaintenancePolicy
+	changed chan struct{}
+	closed  bool
+}
+
+func newMaintenance(windows []MaintenanceWindow, policy MaintenancePolicy) *maintenance {
+	m := &maintenance{
+		windows: slices.Clone(windows),
+		policy:  policy,
+		changed: make(chan struct{}),
+	}
+	m.scheduled.Store(len(windows) > 0)
+	return m
+}
+
+// active returns the end of the window containing t, if any
+func (m *maintenance) active(t time.Time) (time.Time, chan struct{}, bool) {
+	if !m.scheduled.Load() {
+		return time.Time{}, nil, false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return time.Time{}, m.changed, false
+	}
+	for _, w := range m.windows {
+		if !t.Before(w.Start) && t.Before(w.End) {
+			return w.End, m.changed, true
+		}
+	}
+	return time.Time{}, m.changed, false
+}
+
+// wait blocks until no window is active or ctx is done. Schedule changes
+// wake waiters early, and Node.Close makes them fail with ErrClosed.
+func (m *maintenance) wait(ctx context.Context) error {
+	for {
+		end, changed, ok := m.active(time.Now())
+		if !ok {
+			if m.isClosed() {
+				return ErrClosed
+			}
+			return nil
+		}
+		t := time.NewTimer(time.Until(end))
+		select {
+		case <-t.C:
+		case <-changed:
+			t.Stop()
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+func (m *maintenance) set(windows []MaintenanceWindow) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.windows = slices.Clone(windows)
+	m.scheduled.Store(len(windows) > 0)
+	close(m.changed)
+	m.changed = make(chan struct{})
+}
+
+// close wakes waiters for good, see Node.Close
+func (m *maintenance) close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.closed = true
+	close(m.changed)
+	m.changed = make(chan struct{})
+}
+
+func (m *maintenance) isClosed() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.closed
+}
+
+// SetMaintenance replaces the node's maintenance schedule. Callers currently
+// waiting out a window re-check the new schedule immediately.
+func (n *Node) SetMaintenance(windows []MaintenanceWindow) {
+	n.maint.set(windows)
+}
+
+// InMaintenance reports whether a maintenance window is currently active,
+// suitable for wiring into a health or readiness endpoint
+func (n *Node) InMaintenance() bool {
+	_, _, ok := n.maint.active(time.Now())
+	return ok
+}
+
+// TryGenerate is like Generate but fails instead of waiting when the node is
+// in a maintenance window with the MaintenanceReject policy, when the
+// millisecond's step space is used up (ErrSequenceExhausted), when the rate
+// limit is reached (ErrRateLimited), or when the clock moved back with
+// Config.DetectClockBack
+func (n *Node) TryGenerate() (ID, error) {
+	if n.maint.policy == MaintenanceReject && n.InMaintenance() {
+		return 0, ErrMaintenance
+	}
+	return n.GenerateContext(context.WithValue(context.Background(), noWaitKey{}, true))
+}
@@ -0,0 +1,105 @@
+package mkey
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMaintenanceQueue(t *testing.T) {
+	start := time.Now()
+	cfg := NewConfig()
+	cfg.Maintenance = []MaintenanceWindow{{Start: start.Add(-time.Second), End: start.Add(50 * time.Millisecond)}}
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !n.InMaintenance() {
+		t.Fatal("InMaintenance = false inside a window")
+	}
+
+	n.Generate()
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("Generate returned after %v, inside the window", elapsed)
+	}
+	if n.InMaintenance() {
+		t.Fatal("InMaintenance = true after the window")
+	}
+}
+
+func TestMaintenanceReject(t *testing.T) {
+	now := time.Now()
+	cfg := NewConfig()
+	cfg.Maintenance = []MaintenanceWindow{{Start: now.Add(-time.Second), End: now.Add(time.Hour)}}
+	cfg.MaintenancePolicy = MaintenanceReject
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.TryGenerate(); !errors.Is(err, ErrMaintenance) {
+		t.Fatalf("TryGenerate = %v, want ErrMaintenance", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := n.GenerateContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("GenerateContext = %v, want the context error", err)
+	}
+
+	n.SetMaintenance(nil)
+	if _, err := n.TryGenerate(); err != nil {
+		t.Fatalf("TryGenerate after clearing the schedule: %v", err)
+	}
+}
+
+func TestSetMaintenanceWakesWaiters(t *testing.T) {
+	now := time.Now()
+	cfg := NewConfig()
+	cfg.Maintenance = []MaintenanceWindow{{Start: now.Add(-time.Second), End: now.Add(time.Hour)}}
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan ID)
+	go func() { done <- n.Generate() }()
+	select {
+	case <-done:
+		t.Fatal("Generate did not wait for the window")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	n.SetMaintenance([]MaintenanceWindow{{Start: now.Add(time.Hour), End: now.Add(2 * time.Hour)}})
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Generate still waiting after the window was moved")
+	}
+	if n.InMaintenance() {
+		t.Fatal("future window reported as active")
+	}
+}
+
+func TestTryGenerateExhausted(t *testing.T) {
+	n, clock := stalledNode(t)
+	start := time.Now()
+	if _, err := n.TryGenerate(); !errors.Is(err, ErrSequenceExhausted) {
+		t.Fatalf("TryGenerate on an exhausted millisecond = %v, want ErrSequenceExhausted", err)
+	}
+	if d := time.Since(start); d > 50*time.Millisecond {
+		t.Fatalf("TryGenerate took %s, want it to fail at once", d)
+	}
+	if s := n.Stats(); s.Generated != 2 || s.Waits != 0 {
+		t.Fatalf("Stats after a failed TryGenerate = %+v, want 2 generated and no waits", s)
+	}
+
+	clock.Advance(time.Millisecond)
+	id, err := n.TryGenerate()
+	if err != nil {
+		t.Fatalf("TryGenerate in the next millisecond: %v", err)
+	}
+	if got, want := n.Layout().Time(id), clock.Now().UnixMilli(); got != want {
+		t.Fatalf("ID time = %d, want %d", got, want)
+	}
+}
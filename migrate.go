@@ -0,0 +1,77 @@
+package mkey
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MigratingGenerator issues IDs that are valid under two layouts at once, for
+// live migrations where readers of the old and the new layout coexist.
+//
+// Both layouts must agree on the epoch and on everything above the node
+// field, so timestamps decode identically; they may split the bits below
+// between node and step differently. IDs are issued from the node ID of the
+// layout with more node bits and with the smaller step field, so each layout
+// decodes them to its own node ID. This requires the node IDs to nest: the
+// finer node ID shifted down to the coarser width must equal the coarser one.
+// Throughput is that of the smaller step field.
+type MigratingGenerator struct {
+	from, to Layout
+	inner    *Node
+}
+
+// NewMigratingGenerator creates a generator for moving from the old to the new configuration
+func NewMigratingGenerator(from, to *Config) (*MigratingGenerator, error) {
+	lf, lt := from.Layout(), to.Layout()
+	for _, l := range []Layout{lf, lt} {
+		if err := l.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if lf.Epoch != lt.Epoch {
+		return nil, errors.New("layouts must share the epoch")
+	}
+	if lf.NodeBits+lf.StepBits != lt.NodeBits+lt.StepBits ||
+		lf.ExpiryBits != lt.ExpiryBits || lf.PriorityBits != lt.PriorityBits ||
+		lf.TombstoneBit != lt.TombstoneBit || lf.ShardBits != lt.ShardBits ||
+		lf.ChildBits != lt.ChildBits {
+		return nil, errors.New("layouts may only differ in how node and step bits are split")
+	}
+
+	fine, coarse := to, from
+	if lf.NodeBits > lt.NodeBits {
+		fine, coarse = from, to
+	}
+	if fine.Node>>(fine.NodeBits-coarse.NodeBits) != coarse.Node {
+		return nil, fmt.Errorf("node IDs %d (%d bits) and %d (%d bits) do not nest",
+			fine.Node, fine.NodeBits, coarse.Node, coarse.NodeBits)
+	}
+
+	cfg := *from
+	cfg.EpochName = ""
+	cfg.Epoch = lf.Epoch
+	cfg.NodeBits = fine.NodeBits
+	cfg.StepBits = min(lf.StepBits, lt.StepBits)
+	cfg.Node = fine.Node
+	inner, err := NewNodeWithConfig(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &MigratingGenerator{from: lf, to: lt, inner: inner}, nil
+}
+
+// Generate returns an ID that both layouts decode to the same timestamp and
+// to their respective node IDs
+func (m *MigratingGenerator) Generate() ID {
+	return m.inner.Generate()
+}
+
+// From returns the old layout
+func (m *MigratingGenerator) From() Layout {
+	return m.from
+}
+
+// To returns the new layout
+func (m *MigratingGenerator) To() Layout {
+	return m.to
+}
@@ -0,0 +1,58 @@
+package mkey
+
+import "testing"
+
+func migrationConfigs() (from, to *Config) {
+	from = NewConfig()
+	from.Node = 5
+	to = NewConfig()
+	to.NodeBits, to.StepBits = 12, 10
+	to.Node = 5<<2 | 3
+	return from, to
+}
+
+func TestMigratingGenerator(t *testing.T) {
+	from, to := migrationConfigs()
+	// the direction of the migration does not matter
+	for _, pair := range [][2]*Config{{from, to}, {to, from}} {
+		m, err := NewMigratingGenerator(pair[0], pair[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		lf, lt := from.Layout(), to.Layout()
+		var prev ID
+		for range 3000 {
+			id := m.Generate()
+			if id <= prev {
+				t.Fatalf("ID %v does not follow %v", id, prev)
+			}
+			prev = id
+			if lf.NodeID(id) != 5 || lt.NodeID(id) != 23 {
+				t.Fatalf("nodes %d and %d, want 5 and 23", lf.NodeID(id), lt.NodeID(id))
+			}
+			if lf.Time(id) != lt.Time(id) {
+				t.Fatal("layouts decode different timestamps")
+			}
+		}
+		if m.From() != pair[0].Layout() || m.To() != pair[1].Layout() {
+			t.Fatal("From/To do not return the layouts")
+		}
+	}
+}
+
+func TestMigratingGeneratorInvalid(t *testing.T) {
+	tests := map[string]func(from, to *Config){
+		"epoch":      func(_, to *Config) { to.Epoch++ },
+		"total bits": func(_, to *Config) { to.StepBits-- },
+		"expiry":     func(_, to *Config) { to.StepBits -= 2; to.NodeBits -= 2; to.ExpiryBits = 4 },
+		"nesting":    func(_, to *Config) { to.Node = 6 << 2 },
+		"invalid":    func(from, _ *Config) { from.StepBits = 30 },
+	}
+	for name, tweak := range tests {
+		from, to := migrationConfigs()
+		tweak(from, to)
+		if _, err := NewMigratingGenerator(from, to); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
@@ -2,14 +2,18 @@
 package mkey
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/binary"
 	"errors"
 	"fmt"
 	"math/big"
+	"runtime"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -65,27 +69,286 @@ type Config struct {
 	NodeBits uint8
 	StepBits uint8
 	Node     int64
+
+	// EpochName selects a registered epoch (see RegisterEpoch) and takes
+	// precedence over Epoch when set
+	EpochName string
+
+	// ExpiryBits reserves bits below the timestamp for a TTL offset
+	// (see Layout.ExpiryBits); ExpiryUnit is the unit of that offset
+	ExpiryBits uint8
+	ExpiryUnit time.Duration
+
+	// PriorityBits reserves bits above the timestamp for GenerateWithPriority
+	PriorityBits uint8
+
+	// TombstoneBit reserves a bit for tombstone IDs (see Layout.TombstoneBit)
+	TombstoneBit bool
+
+	// ShardBits reserves bits for a shard value (see Layout.ShardBits)
+	ShardBits uint8
+
+	// ChildBits reserves low step bits for Node.Child (see Layout.ChildBits);
+	// ChildWindow is how long after a parent Child accepts it,
+	// DefaultChildWindow if 0
+	ChildBits   uint8
+	ChildWindow time.Duration
+
+	// ReservedSteps holds back this many steps of every millisecond for
+	// Node.GenerateLane(LaneHigh); it must be less than MaxStep
+	ReservedSteps int
+
+	// ScrambleSteps issues the steps of each millisecond in the order of a
+	// maximal-length LFSR instead of counting up, so step values look random
+	// yet never repeat within the millisecond. IDs then only sort by
+	// millisecond, not by issue order within one.
+	ScrambleSteps bool
+
+	// CoarseTime makes generation read a millisecond cached by a background
+	// ticker instead of the clock, which dominates profiles at high rates.
+	// The precise clock is only read while waiting for the next millisecond.
+	// Timestamps may then lag the clock by up to a millisecond, and the
+	// cache never moves back, so backwards clock steps are held rather than
+	// detected or slewed.
+	CoarseTime bool
+
+	// RandomStepStart starts the steps of each millisecond at an offset
+	// derived from the millisecond and a secret drawn when the node is
+	// created, counting up from there and wrapping around, so step values
+	// are not guessable. IDs then only sort by millisecond.
+	RandomStepStart bool
+
+	// Exclusive catches two live nodes in one process sharing a node ID and
+	// layout, which silently collide: creating the second fails with
+	// ErrNodeInUse, or with OnDuplicate set, reports it there and proceeds.
+	// Only nodes created with Exclusive are tracked, and a node counts as
+	// live until it is garbage collected.
+	Exclusive   bool
+	OnDuplicate func(node int64, l Layout)
+
+	// Hook, if set, observes every ID issued, every wait and clock events
+	Hook Hook
+
+	// Strict refuses node ID 0, which is what every instance gets when the
+	// node ID is left unset; see NewNodeAuto for deriving one
+	Strict bool
+
+	// DefaultEncoding selects the representation returned by Node.Format,
+	// so an application can standardize on e.g. EncodingBase62 in one place
+	DefaultEncoding Encoding
+
+	// Labels are arbitrary deployment dimensions (region, service, AZ) attached
+	// to the node, reported in Stats and exported with its metrics
+	Labels map[string]string
+
+	// Maintenance lists windows during which the node pauses issuance
+	Maintenance []MaintenanceWindow
+
+	// MaintenancePolicy controls whether calls during a window queue or fail
+	MaintenancePolicy MaintenancePolicy
+
+	// RateLimit, if positive, caps issuance at this many IDs per second with
+	// a token bucket of RateBurst IDs (at least 1). Generate waits for
+	// tokens and TryGenerate fails with ErrRateLimited; batches larger
+	// than RateBurst fail. Change it at run time with Node.SetRateLimit.
+	RateLimit float64
+
+	// RateBurst is the token bucket size of RateLimit
+	RateBurst int
+
+	// Faults injects clock and sequence failures for chaos tests; leave nil
+	// in production
+	Faults Faults
+
+	// SlewLimit, if positive, absorbs backwards clock corrections of up to
+	// this much by holding the node's logical clock instead of reusing past
+	// milliseconds; the logical clock never runs more than SlewLimit ahead
+	// of the real one. Larger corrections are not absorbed.
+	SlewLimit time.Duration
+
+	// BorrowLimit, if positive, makes a node that used up a millisecond's
+	// step space move on to the next millisecond at once instead of waiting,
+	// as long as its logical clock stays within BorrowLimit ahead of the
+	// real one. Bursts then cost no latency; IDs carry timestamps up to
+	// BorrowLimit in the future until the clock catches up.
+	BorrowLimit time.Duration
+
+	// MaxDrift bounds how far ahead of the local clock an ID passed to
+	// Node.Observe may be; 0 disables the check
+	MaxDrift time.Duration
+
+	// LatencyHistogram records the latency of every generation call,
+	// reported in Stats.Latency
+	LatencyHistogram bool
+
+	// ProfileWaits sets pprof labels (mkey_wait, mkey_node) while a call
+	// blocks, so blocked time is attributable in CPU profiles. The calling
+	// goroutine's own labels are cleared after each wait.
+	ProfileWaits bool
+
+	// Clock is the time source for ID timestamps, SystemClock if nil.
+	// Maintenance windows and latency measurements always use real time.
+	Clock Clock
+
+	// DetectClockBack makes generation fail with ErrClockMovedBack when the
+	// clock reads more than ClockBackTolerance before the last issued
+	// timestamp, instead of reusing past milliseconds. Smaller regressions
+	// are absorbed by holding the last timestamp. Generate, which cannot
+	// report errors, waits for the clock to catch up instead.
+	DetectClockBack    bool
+	ClockBackTolerance time.Duration
+
+	// WaitStrategy selects how the node waits once a millisecond's step space
+	// is used up; the default WaitSpin busy-polls the clock
+	WaitStrategy WaitStrategy
+
+	// Jitter, if at least a millisecond, makes the node's timestamps lag the
+	// clock by a fixed number of whole milliseconds below Jitter, derived
+	// from the node ID. Nodes triggered in lockstep then spread their IDs
+	// over several milliseconds instead of hammering one key range. Raising
+	// Jitter can move a node's timestamps back by up to the difference, so
+	// apply it while the node is stopped for at least that long.
+	Jitter time.Duration
 }
 
 // Node represents a snowflake generator node
 type Node struct {
 	mu    sync.Mutex
 	epoch time.Time
-	time  int64
 	node  int64
+
+	// state packs the last issued time and step as time<<StepBits | step,
+	// with the sign bit set while a caller holds mu (see lock). time and step
+	// are its unpacked copy, valid only between lock and unlock.
+	state atomic.Int64
+	time  int64
 	step  int64
 
+	// fast enables the lock-free path of generateFast; it is off when a
+	// feature needs every call to run under mu
+	fast bool
+
 	// Precomputed values
+	layout    Layout
 	nodeMax   int64
 	nodeMask  int64
 	stepMask  int64
 	timeShift uint8
 	nodeShift uint8
+
+	// stepLimit is the last step counter LaneNormal may use, stepMask less
+	// Config.ReservedSteps
+	stepLimit int64
+
+	// childShift is Layout.ChildBits; steps are issued in multiples of
+	// 1<<childShift, leaving the bits below for Child
+	childShift uint8
+
+	// exclusive is the node's entry in the Config.Exclusive registry
+	exclusive *exclusiveToken
+
+	// closed is set by Close
+	closed    atomic.Bool
+	closeOnce sync.Once
+
+	// coarse caches the clock reading with Config.CoarseTime, nil otherwise
+	coarse *coarseTime
+
+	// perm maps step counters to step values, nil unless Config.ScrambleSteps
+	perm []uint16
+
+	// randomStart and stepSeed implement Config.RandomStepStart
+	randomStart bool
+	stepSeed    int64
+
+	labels   map[string]string
+	maint    *maintenance
+	hook     Hook
+	rate     *rateLimiter
+	encoding Encoding
+	faults   Faults
+	clock    Clock
+	slewMs   int64
+	borrowMs int64
+	maxDrift int64
+
+	profileWaits bool
+	waitStrategy WaitStrategy
+	jitter       time.Duration
+
+	detectBack bool
+	backTolMs  int64
+
+	// floor is one past the newest timestamp passed to Observe, written under mu
+	floor atomic.Int64
+
+	// Counters reported by Stats; waits and borrowed are guarded by mu
+	generated atomic.Uint64
+	waits     uint64
+	borrowed  uint64
+
+	// Lock-free per-second counters, see IDsThisSecond
+	secIDs   secondCounter
+	secWaits secondCounter
+
+	// waitSince is when the current wait for the next millisecond began in
+	// Unix nanoseconds, 0 when not waiting; read by Watchdog
+	waitSince atomic.Int64
+
+	// prov records where IDs came from in mkeydebug builds
+	prov provenanceRing
+
+	// latency is nil unless Config.LatencyHistogram is set, guarded by mu
+	latency *LatencyHistogram
+
+	// high is the highest ID issued, see Barrier; highTime is one past the
+	// newest timestamp issued, see HighWatermark
+	high     atomic.Int64
+	highTime atomic.Int64
+
+	// started is when the node was created in milliseconds since the epoch
+	started int64
+
+	// children counts the child IDs issued per parent, see Child
+	childMu     sync.Mutex
+	children    map[ID]int64
+	childWindow int64
+	childPruned int64
 }
 
 // ID is a custom type for snowflake ID
 type ID int64
 
+// Nil is the zero ID, which JSON null decodes to
+const Nil ID = 0
+
+// MaxSafeJSONID is the largest ID a JSON number can carry without losing
+// precision in JavaScript (Number.MAX_SAFE_INTEGER); larger IDs should be
+// sent as strings
+const MaxSafeJSONID ID = 1<<53 - 1
+
+// JSONBase58Prefix marks a Base58 ID inside a JSON string, e.g. "b58:AbjLRc8x23"
+const JSONBase58Prefix = "b58:"
+
+// JSONDecodeOptions controls which forms ID.UnmarshalJSON accepts
+type JSONDecodeOptions struct {
+	// AllowQuoted accepts decimal IDs in quotes, as sent by JavaScript clients
+	AllowQuoted bool
+
+	// AllowNull decodes null as Nil
+	AllowNull bool
+
+	// AllowBase58 accepts strings with JSONBase58Prefix
+	AllowBase58 bool
+}
+
+// JSONDecoding holds the options used by ID.UnmarshalJSON. Set it during
+// program initialization; the zero value accepts bare numbers only.
+var JSONDecoding = JSONDecodeOptions{
+	AllowQuoted: true,
+	AllowNull:   true,
+}
+
 // NewConfig creates a new Config with default values
 func NewConfig() *Config {
 	return &Config{
@@ -108,50 +371,204 @@ func NewNode(node int64) (*Node, error) {
 // NewNodeWithConfig creates a new snowflake node with custom configuration
 func NewNodeWithConfig(cfg *Config) (*Node, error) {
 	// Validate configuration
-	if cfg.NodeBits > MaxNodeBits {
-		return nil, fmt.Errorf("NodeBits must be <= %d", MaxNodeBits)
+	if cfg.EpochName != "" {
+		if _, ok := LookupEpoch(cfg.EpochName); !ok {
+			return nil, fmt.Errorf("unknown epoch %q", cfg.EpochName)
+		}
+	}
+	if cfg.ChildWindow < 0 {
+		return nil, errors.New("ChildWindow must not be negative")
 	}
-	if cfg.StepBits > MaxStepBits {
-		return nil, fmt.Errorf("StepBits must be <= %d", MaxStepBits)
+	if cfg.Jitter < 0 || cfg.Jitter > MaxJitter {
+		return nil, fmt.Errorf("Jitter must be between 0 and %s", MaxJitter)
 	}
-	if cfg.NodeBits+cfg.StepBits > 22 {
-		return nil, errors.New("NodeBits + StepBits must be <= 22")
+	if cfg.RateLimit < 0 || cfg.RateBurst < 0 {
+		return nil, errors.New("RateLimit and RateBurst must not be negative")
+	}
+	if cfg.WaitStrategy > WaitAdaptive {
+		return nil, fmt.Errorf("unknown wait strategy %d", cfg.WaitStrategy)
+	}
+	layout := cfg.Layout()
+	if err := layout.Validate(); err != nil {
+		return nil, err
+	}
+	if steps := layout.MaxStep() >> cfg.ChildBits; cfg.ReservedSteps < 0 || int64(cfg.ReservedSteps) >= steps {
+		return nil, fmt.Errorf("ReservedSteps must be between 0 and %d", steps-1)
 	}
 
 	nodeMax := -1 ^ (-1 << cfg.NodeBits)
 	if cfg.Node < 0 || cfg.Node > int64(nodeMax) {
 		return nil, fmt.Errorf("Node must be between 0 and %d", nodeMax)
 	}
+	if cfg.Strict && cfg.Node == 0 {
+		return nil, ErrNodeUnset
+	}
 
 	n := &Node{
+		layout:    layout,
 		node:      cfg.Node,
 		nodeMax:   int64(nodeMax),
 		nodeMask:  int64(nodeMax) << cfg.StepBits,
-		stepMask:  -1 ^ (-1 << cfg.StepBits),
-		timeShift: cfg.NodeBits + cfg.StepBits,
+		stepMask:  -1 ^ (-1 << (cfg.StepBits - cfg.ChildBits)),
+		stepLimit: -1 ^ (-1 << (cfg.StepBits - cfg.ChildBits)) - int64(cfg.ReservedSteps),
+		timeShift: layout.TimeShift(),
 		nodeShift: cfg.StepBits,
+		labels:    copyLabels(cfg.Labels),
+		maint:     newMaintenance(cfg.Maintenance, cfg.MaintenancePolicy),
+		hook:      cfg.Hook,
+		rate:      newRateLimiter(cfg.RateLimit, cfg.RateBurst),
+		encoding:  cfg.DefaultEncoding,
+		faults:    cfg.Faults,
+		clock:     cfg.Clock,
+		slewMs:    cfg.SlewLimit.Milliseconds(),
+		borrowMs:  cfg.BorrowLimit.Milliseconds(),
+		maxDrift:  cfg.MaxDrift.Milliseconds(),
+
+		profileWaits: cfg.ProfileWaits,
+		waitStrategy: cfg.WaitStrategy,
+		jitter:       jitterLag(cfg.Node, cfg.Jitter),
+		detectBack:   cfg.DetectClockBack,
+		backTolMs:    cfg.ClockBackTolerance.Milliseconds(),
+
+		childShift:  cfg.ChildBits,
+		childWindow: cfg.ChildWindow.Milliseconds(),
+	}
+
+	if cfg.LatencyHistogram {
+		n.latency = &LatencyHistogram{}
+	}
+	if cfg.ScrambleSteps {
+		n.perm = stepPermutation(cfg.StepBits - cfg.ChildBits)
+	}
+	if cfg.RandomStepStart {
+		var seed [8]byte
+		rand.Read(seed[:])
+		n.randomStart = true
+		n.stepSeed = int64(binary.LittleEndian.Uint64(seed[:]))
+	}
+	n.fast = n.faults == nil && n.latency == nil && !ProvenanceEnabled
+
+	if n.clock == nil {
+		n.clock = SystemClock{}
 	}
 
 	// Setup epoch
-	curTime := time.Now()
-	n.epoch = curTime.Add(time.Unix(cfg.Epoch/1000, (cfg.Epoch%1000)*1000000).Sub(curTime))
+	curTime := n.clock.Now()
+	n.epoch = curTime.Add(time.Unix(layout.Epoch/1000, (layout.Epoch%1000)*1000000).Sub(curTime))
+	if cfg.CoarseTime {
+		clock, epoch, jitter, faults := n.clock, n.epoch, n.jitter, n.faults
+		n.coarse = startCoarseTime(func() int64 {
+			return elapsedSince(clock, epoch, jitter, faults).Nanoseconds() / 1000000
+		})
+		// The ticker only references the cache, so it stops once the node
+		// is unreachable
+		runtime.AddCleanup(n, (*coarseTime).close, n.coarse)
+	}
+	n.started = n.now()
+	if cfg.Exclusive {
+		if err := registerExclusive(n, cfg.OnDuplicate); err != nil {
+			return nil, err
+		}
+	}
+	if n.childWindow == 0 {
+		n.childWindow = DefaultChildWindow.Milliseconds()
+	}
 
 	return n, nil
 }
 
-// Generate creates and returns a unique snowflake ID
+// Generate creates and returns a unique snowflake ID. It panics after Close,
+// as it cannot report ErrClosed; use GenerateContext or TryGenerate where
+// the node may be closed concurrently.
 func (n *Node) Generate() ID {
-	n.mu.Lock()
-	defer n.mu.Unlock()
+	return n.generate(0)
+}
+
+// GenerateContext is like Generate but gives up with ctx.Err() if ctx is
+// done while waiting for the next millisecond or a maintenance window
+func (n *Node) GenerateContext(ctx context.Context) (ID, error) {
+	return n.generateContext(ctx, 0)
+}
+
+// generate issues the next ID with fields OR-ed in; fields holds the layout
+// components that are neither time, node nor step (e.g. expiry)
+func (n *Node) generate(fields int64) ID {
+	var since int64
+	for {
+		id, err := n.generateContext(context.Background(), fields)
+		if err == nil {
+			if since != 0 {
+				n.waitSince.CompareAndSwap(since, 0)
+			}
+			return id
+		}
+		if errors.Is(err, ErrClosed) {
+			panic(closedPanic)
+		}
+		// Otherwise only ErrClockMovedBack can occur without a context; wait
+		// it out where the Watchdog can see it
+		if since == 0 {
+			since = time.Now().UnixNano()
+			n.waitSince.CompareAndSwap(0, since)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (n *Node) generateContext(ctx context.Context, fields int64) (ID, error) {
+	return n.generateUpTo(ctx, fields, n.stepLimit)
+}
+
+// generateUpTo issues an ID using step counters up to limit: n.stepLimit in
+// LaneNormal, n.stepMask in LaneHigh
+func (n *Node) generateUpTo(ctx context.Context, fields, limit int64) (ID, error) {
+	if n.closed.Load() {
+		return 0, ErrClosed
+	}
+	start := n.latencyStart()
+	if err := n.waitMaintenance(ctx); err != nil {
+		return 0, err
+	}
+	if err := n.waitRate(ctx, 1); err != nil {
+		return 0, err
+	}
+	if n.fast {
+		if id, ok := n.generateFast(fields, limit); ok {
+			n.hookGenerate(id)
+			return id, nil
+		}
+	}
+
+	n.lock()
+	defer n.unlock()
 
-	now := time.Since(n.epoch).Nanoseconds() / 1000000
+	var now int64
+	var err error
+	for {
+		now, err = n.clockNow()
+		if err != nil {
+			return 0, err
+		}
+		n.injectExhaustion(now)
+		if limit == n.stepMask || !n.laneFull(now, n.step+1) {
+			break
+		}
+		if err = n.waitLane(ctx); err != nil {
+			return 0, err
+		}
+		if n.closed.Load() {
+			return 0, ErrClosed
+		}
+	}
 
 	if now == n.time {
 		n.step = (n.step + 1) & n.stepMask
 
 		if n.step == 0 {
-			for now <= n.time {
-				now = time.Since(n.epoch).Nanoseconds() / 1000000
+			if now, err = n.waitNextMilli(ctx); err != nil {
+				// Leave the millisecond marked as used up
+				n.step = n.stepMask
+				return 0, err
 			}
 		}
 	} else {
@@ -159,10 +576,134 @@ func (n *Node) Generate() ID {
 	}
 
 	n.time = now
+	n.generated.Add(1)
+	n.secIDs.add(now/1000, 1)
 
-	return ID((now)<<n.timeShift |
+	id := ID((now)<<n.timeShift | fields |
 		(n.node << n.nodeShift) |
-		(n.step))
+		n.stepValue(now, n.step))
+	n.recordProvenance(id)
+	n.raiseWatermark(id)
+	n.hookGenerate(id)
+	n.recordLatency(start)
+	return id, nil
+}
+
+// waitNextMilli waits until a millisecond after n.time may be used and
+// returns it, or fails if ctx is done or the node is closed first. Contexts from TryGenerate fail at
+// once with ErrSequenceExhausted. Callers hold n.mu.
+func (n *Node) waitNextMilli(ctx context.Context) (int64, error) {
+	if next, ok := n.borrowNext(n.preciseNow()); ok {
+		return next, nil
+	}
+	if ctx.Value(noWaitKey{}) != nil {
+		return 0, ErrSequenceExhausted
+	}
+	n.waits++
+	n.secWaits.add(n.time/1000, 1)
+	n.waitSince.Store(time.Now().UnixNano())
+	defer n.waitSince.Store(0)
+
+	done := ctx.Done()
+	var next int64
+	var err error
+	n.instrumentWait(ctx, waitNextMillisecond, func() {
+		for {
+			// Close waits for the lock held here, so give up rather than
+			// wait for a clock that may not move
+			if n.closed.Load() {
+				err = ErrClosed
+				return
+			}
+			now := max(n.preciseNow(), n.floor.Load())
+			if now > n.time {
+				next = now
+				return
+			}
+			if slewed, ok := n.slewNext(now); ok {
+				next = slewed
+				return
+			}
+			if done != nil {
+				select {
+				case <-done:
+					err = ctx.Err()
+					return
+				default:
+				}
+			}
+			n.pause(done)
+		}
+	})
+	return next, err
+}
+
+// Observe records an ID received from another node with the same layout, so
+// that every ID this node issues afterwards sorts after it even when the
+// peer's clock runs ahead. Until the local clock catches up the node issues
+// at the observed timestamp and waits when that millisecond is used up.
+// IDs more than Config.MaxDrift ahead are rejected with ErrClockDrift.
+func (n *Node) Observe(id ID) error {
+	t := n.layout.Time(id) - n.layout.Epoch
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.maxDrift > 0 {
+		if ahead := t - n.now(); ahead > n.maxDrift {
+			return fmt.Errorf("%w: %dms ahead", ErrClockDrift, ahead)
+		}
+	}
+	n.floor.Store(max(n.floor.Load(), t+1))
+	return nil
+}
+
+// Barrier issues and returns an ID strictly greater than every ID this node
+// has issued so far, for use as a fencing token or as a "replicas must catch
+// up to at least this ID" marker. This holds under every layout and option:
+// where IDs can sort out of issue order (priority, expiry or shard fields,
+// ScrambleSteps, RandomStepStart) or the clock went back, the barrier takes
+// a millisecond past the newest one issued, carries only the node and the
+// highest priority issued so far, and uses that millisecond up so no later
+// ID of it sorts below the barrier; the next call may then wait for the
+// clock. Like Generate it panics after Close.
+func (n *Node) Barrier() ID {
+	if n.closed.Load() || n.waitMaintenance(context.Background()) != nil || n.waitRate(context.Background(), 1) != nil {
+		panic(closedPanic)
+	}
+
+	// Without fields or steps that sort out of issue order the next ID is
+	// a barrier, unless the clock went back
+	l := n.layout
+	if n.perm == nil && !n.randomStart && l.PriorityBits == 0 && l.ExpiryBits == 0 && l.ShardBits == 0 {
+		high := ID(n.high.Load())
+		if id, err := n.generateContext(context.Background(), 0); err == nil && id > high {
+			return id
+		}
+	}
+
+	n.lock()
+	defer n.unlock()
+
+	last := n.time
+	var priority int64
+	if high := ID(n.high.Load()); high != 0 {
+		last = max(last, l.Time(high)-l.Epoch)
+		priority = int64(high) &^ (1<<(63-l.PriorityBits) - 1)
+	}
+	t := max(n.now(), n.floor.Load(), last+1)
+
+	// Leave no free step in t, and keep the clock from going back before it
+	n.time, n.step = t, n.stepMask
+	n.floor.Store(max(n.floor.Load(), t))
+	n.generated.Add(1)
+	n.secIDs.add(t/1000, 1)
+
+	id := ID(priority | t<<n.timeShift | n.node<<n.nodeShift)
+	n.recordProvenance(id)
+	n.raiseWatermark(id)
+	n.hookGenerate(id)
+	return id
 }
 
 // GenerateBatch generates multiple IDs at once (more efficient for bulk operations)
@@ -170,39 +711,138 @@ func (n *Node) GenerateBatch(count int) ([]ID, error) {
 	if count <= 0 {
 		return nil, errors.New("count must be positive")
 	}
-	if count > int(n.stepMask) {
-		return nil, fmt.Errorf("count must be <= %d", n.stepMask)
+	if count > int(n.stepLimit) {
+		return nil, fmt.Errorf("count must be <= %d", n.stepLimit)
 	}
 
 	ids := make([]ID, count)
-	n.mu.Lock()
-	defer n.mu.Unlock()
+	if err := n.generateBatch(context.Background(), ids, nil); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// GenerateBatchContext issues count IDs on a best-effort basis for callers
+// under deadline pressure. Like GenerateBatchInto it works in batches of at
+// most MaxStep that each share a millisecond, so count may exceed MaxStep.
+// If ctx is done while waiting, it returns the IDs issued so far together
+// with ctx.Err(); a batch in progress issues no IDs.
+func (n *Node) GenerateBatchContext(ctx context.Context, count int) ([]ID, error) {
+	if count <= 0 {
+		return nil, errors.New("count must be positive")
+	}
+
+	ids := make([]ID, count)
+	done := 0
+	for done < count {
+		chunk := ids[done:min(count, done+int(n.stepLimit))]
+		if err := n.generateBatch(ctx, chunk, nil); err != nil {
+			return ids[:done], err
+		}
+		done += len(chunk)
+	}
+	return ids, nil
+}
+
+// GenerateBatchInto fills dst with new IDs, reusing the caller's buffer
+// instead of allocating. IDs are issued in batches of at most MaxStep that
+// each share a millisecond, so dst may be of any length. It returns the
+// number of IDs written, which is less than len(dst) only with an error.
+func (n *Node) GenerateBatchInto(dst []ID) (int, error) {
+	done := 0
+	for done < len(dst) {
+		chunk := dst[done:min(len(dst), done+int(n.stepLimit))]
+		if err := n.generateBatch(context.Background(), chunk, nil); err != nil {
+			return done, err
+		}
+		done += len(chunk)
+	}
+	return done, nil
+}
+
+// generateBatch fills ids with consecutive steps of one millisecond, OR-ing
+// in fields[i] if fields is not nil. len(ids) must not exceed n.stepLimit.
+func (n *Node) generateBatch(ctx context.Context, ids []ID, fields []int64) error {
+	if n.closed.Load() {
+		return ErrClosed
+	}
+	count := len(ids)
+	start := n.latencyStart()
+	if err := n.waitMaintenance(ctx); err != nil {
+		return err
+	}
+	if err := n.waitRate(ctx, count); err != nil {
+		return err
+	}
+
+	n.lock()
+	defer n.unlock()
+
+	now, first, err := n.reserveSteps(ctx, count)
+	if err != nil {
+		return err
+	}
+
+	var high ID
+	for i := range ids {
+		var f int64
+		if fields != nil {
+			f = fields[i]
+		}
+		ids[i] = ID((now)<<n.timeShift | f |
+			(n.node << n.nodeShift) |
+			n.stepValue(now, first+int64(i)))
+		high = max(high, ids[i])
+		n.recordProvenance(ids[i])
+		n.hookGenerate(ids[i])
+	}
+	n.raiseWatermark(high)
+	n.recordLatency(start)
+
+	return nil
+}
 
-	now := time.Since(n.epoch).Nanoseconds() / 1000000
+// reserveSteps claims count consecutive steps of one millisecond and returns
+// that millisecond and the first step. Callers hold the lock.
+func (n *Node) reserveSteps(ctx context.Context, count int) (now, first int64, err error) {
+	for {
+		now, err = n.clockNow()
+		if err != nil {
+			return 0, 0, err
+		}
+		n.injectExhaustion(now)
+		if !n.laneFull(now, n.step+int64(count)) {
+			break
+		}
+		if err = n.waitLane(ctx); err != nil {
+			return 0, 0, err
+		}
+	}
 
+	// first is the first free step; n.step holds the last one used
 	if now == n.time {
+		first = n.step + 1
+
 		// If we're at the same time, we need to make sure we have enough step space
 		if n.step+int64(count) > n.stepMask {
 			// Not enough space in current millisecond, wait for next
-			for now <= n.time {
-				now = time.Since(n.epoch).Nanoseconds() / 1000000
+			if now, err = n.waitNextMilli(ctx); err != nil {
+				return 0, 0, err
 			}
-			n.step = 0
+			first = 0
 		}
-	} else {
-		n.step = 0
 	}
 
 	n.time = now
+	n.step = first + int64(count) - 1
+	n.generated.Add(uint64(count))
+	n.secIDs.add(now/1000, uint64(count))
+	return now, first, nil
+}
 
-	for i := 0; i < count; i++ {
-		ids[i] = ID((now)<<n.timeShift |
-			(n.node << n.nodeShift) |
-			(n.step))
-		n.step++
-	}
-
-	return ids, nil
+// Labels returns a copy of the labels attached to the node
+func (n *Node) Labels() map[string]string {
+	return copyLabels(n.labels)
 }
 
 // RandomNodeID generates a random node ID within the allowed range
@@ -222,7 +862,7 @@ func (f ID) Int64() int64 {
 
 // Time returns the timestamp component of the ID
 func (f ID) Time(node *Node) int64 {
-	return (int64(f) >> node.timeShift) + node.epoch.UnixNano()/1000000
+	return node.layout.Time(f)
 }
 
 // NodeID returns the node component of the ID
@@ -232,7 +872,7 @@ func (f ID) NodeID(node *Node) int64 {
 
 // Step returns the step component of the ID
 func (f ID) Step(node *Node) int64 {
-	return int64(f) & node.stepMask
+	return int64(f) & node.layout.StepMask()
 }
 
 // String returns a decimal string representation of the ID
@@ -245,16 +885,18 @@ func (f ID) Base2() string {
 	return strconv.FormatInt(int64(f), 2)
 }
 
-// Base32 returns a base32 encoded string using custom encoding
+// Base32 returns a base32 encoded string using custom encoding. Negative
+// IDs, such as those from HashToID, encode as their unsigned 64-bit value.
 func (f ID) Base32() string {
 	if f == 0 {
 		return string(encodeBase32Map[0])
 	}
 
-	b := make([]byte, 0, 12)
-	for f > 0 {
-		b = append(b, encodeBase32Map[f%32])
-		f /= 32
+	v := uint64(f)
+	b := make([]byte, 0, 13)
+	for v > 0 {
+		b = append(b, encodeBase32Map[v%32])
+		v /= 32
 	}
 
 	// Reverse the slice
@@ -265,16 +907,18 @@ func (f ID) Base32() string {
 	return string(b)
 }
 
-// Base58 returns a base58 encoded string
+// Base58 returns a base58 encoded string. Negative IDs, such as those from
+// HashToID, encode as their unsigned 64-bit value.
 func (f ID) Base58() string {
 	if f == 0 {
 		return string(encodeBase58Map[0])
 	}
 
+	v := uint64(f)
 	b := make([]byte, 0, 11)
-	for f > 0 {
-		b = append(b, encodeBase58Map[f%58])
-		f /= 58
+	for v > 0 {
+		b = append(b, encodeBase58Map[v%58])
+		v /= 58
 	}
 
 	// Reverse the slice
@@ -307,17 +951,56 @@ func (f ID) Bytes() []byte {
 
 // Timestamp returns the time.Time representation of the timestamp component
 func (f ID) Timestamp(node *Node) time.Time {
-	ms := (int64(f) >> node.timeShift) + node.epoch.UnixNano()/1000000
+	ms := node.layout.Time(f)
 	return time.Unix(ms/1000, (ms%1000)*1000000)
 }
 
+// UnixMilli returns the creation time in milliseconds since the Unix epoch,
+// without constructing a time.Time
+func (f ID) UnixMilli(l Layout) int64 {
+	return l.Time(f)
+}
+
+// UnixSeconds returns the creation time in whole seconds since the Unix epoch,
+// without constructing a time.Time
+func (f ID) UnixSeconds(l Layout) int64 {
+	return l.Time(f) / 1000
+}
+
 // MarshalJSON implements json.Marshaler
 func (f ID) MarshalJSON() ([]byte, error) {
 	return []byte(f.String()), nil
 }
 
-// UnmarshalJSON implements json.Unmarshaler
+// UnmarshalJSON implements json.Unmarshaler. Besides bare numbers it accepts
+// the forms enabled in JSONDecoding.
 func (f *ID) UnmarshalJSON(data []byte) error {
+	opts := JSONDecoding
+
+	if string(data) == "null" {
+		if !opts.AllowNull {
+			return errors.New("null is not a valid ID")
+		}
+		*f = Nil
+		return nil
+	}
+
+	if len(data) >= 2 && data[0] == '"' && data[len(data)-1] == '"' {
+		s := string(data[1 : len(data)-1])
+		if rest, ok := strings.CutPrefix(s, JSONBase58Prefix); ok && opts.AllowBase58 {
+			id, err := ParseBase58([]byte(rest))
+			if err != nil {
+				return err
+			}
+			*f = id
+			return nil
+		}
+		if !opts.AllowQuoted {
+			return fmt.Errorf("quoted ID %s is not allowed", data)
+		}
+		data = data[1 : len(data)-1]
+	}
+
 	id, err := strconv.ParseInt(string(data), 10, 64)
 	if err != nil {
 		return err
@@ -0,0 +1,242 @@
+package mkey
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+// setJSONDecoding replaces JSONDecoding for the duration of the test
+func setJSONDecoding(t *testing.T, o JSONDecodeOptions) {
+	old := JSONDecoding
+	JSONDecoding = o
+	t.Cleanup(func() { JSONDecoding = old })
+}
+
+func TestUnmarshalJSONDefaults(t *testing.T) {
+	tests := []struct {
+		in   string
+		want ID
+		ok   bool
+	}{
+		{`123`, 123, true},
+		{`"123"`, 123, true},
+		{`null`, Nil, true},
+		{`"b58:2"`, 0, false},
+		{`"abc"`, 0, false},
+		{`""`, 0, false},
+		{`1.5`, 0, false},
+		{`"`, 0, false},
+	}
+	for _, tt := range tests {
+		id := ID(7)
+		err := json.Unmarshal([]byte(tt.in), &id)
+		if tt.ok && (err != nil || id != tt.want) {
+			t.Errorf("Unmarshal(%s) = %v, %v; want %v", tt.in, id, err, tt.want)
+		}
+		if !tt.ok && err == nil {
+			t.Errorf("Unmarshal(%s) = %v, want an error", tt.in, id)
+		}
+	}
+}
+
+func TestUnmarshalJSONStrict(t *testing.T) {
+	setJSONDecoding(t, JSONDecodeOptions{})
+	var id ID
+	for _, in := range []string{`"123"`, `null`, `"b58:2"`} {
+		if err := id.UnmarshalJSON([]byte(in)); err == nil {
+			t.Errorf("strict Unmarshal(%s) succeeded", in)
+		}
+	}
+	if err := id.UnmarshalJSON([]byte(`123`)); err != nil || id != 123 {
+		t.Fatalf("strict Unmarshal(123) = %v, %v", id, err)
+	}
+}
+
+func TestUnmarshalJSONBase58(t *testing.T) {
+	setJSONDecoding(t, JSONDecodeOptions{AllowBase58: true})
+	want := ID(1234567890)
+
+	var v struct{ ID ID }
+	if err := json.Unmarshal([]byte(`{"ID":"`+JSONBase58Prefix+want.Base58()+`"}`), &v); err != nil || v.ID != want {
+		t.Fatalf("Unmarshal base58 = %v, %v; want %v", v.ID, err, want)
+	}
+	if err := json.Unmarshal([]byte(`{"ID":"`+JSONBase58Prefix+`0OIl"}`), &v); err == nil {
+		t.Fatal("invalid base58 accepted")
+	}
+	// base58 alone does not enable plain quoted decimals
+	if err := json.Unmarshal([]byte(`{"ID":"123"}`), &v); err == nil {
+		t.Fatal("quoted decimal accepted without AllowQuoted")
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil || string(b) != `{"ID":1234567890}` {
+		t.Fatalf("Marshal = %s, %v", b, err)
+	}
+}
+
+func TestUnixAccessors(t *testing.T) {
+	l := DefaultLayout()
+	at := time.Date(2025, 6, 1, 12, 30, 45, 678e6, time.UTC)
+	id, err := l.Compose(at, 7, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := id.UnixMilli(l); got != at.UnixMilli() {
+		t.Errorf("UnixMilli = %d, want %d", got, at.UnixMilli())
+	}
+	if got := id.UnixSeconds(l); got != at.Unix() {
+		t.Errorf("UnixSeconds = %d, want %d", got, at.Unix())
+	}
+
+	n, _ := NewNode(1)
+	id = n.Generate()
+	if got, want := id.UnixMilli(n.Layout()), id.Timestamp(n).UnixMilli(); got != want {
+		t.Errorf("UnixMilli = %d, Timestamp says %d", got, want)
+	}
+	if allocs := testing.AllocsPerRun(100, func() { _ = id.UnixMilli(l) + id.UnixSeconds(l) }); allocs != 0 {
+		t.Errorf("accessors allocate %v times", allocs)
+	}
+}
+
+// stalledNode returns a node with two steps per millisecond on a stopped
+// clock, with the current millisecond already used up
+func stalledNode(t *testing.T) (*Node, *ManualClock) {
+	t.Helper()
+	clock := NewManualClock(time.Now())
+	cfg := NewConfig()
+	cfg.Node = 1
+	cfg.StepBits = 1
+	cfg.Clock = clock
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.Generate()
+	n.Generate()
+	return n, clock
+}
+
+func TestGenerateContext(t *testing.T) {
+	n, clock := stalledNode(t)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := n.GenerateContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want DeadlineExceeded", err)
+	}
+
+	ctx, cancel = context.WithCancel(t.Context())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	if _, err := n.GenerateContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want Canceled", err)
+	}
+
+	// an abandoned wait leaves the node usable and the millisecond used up
+	clock.Advance(time.Millisecond)
+	id, err := n.GenerateContext(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := n.Layout(); l.Step(id) != 0 || l.Time(id) != clock.Now().UnixMilli() {
+		t.Fatalf("ID after the clock advanced: step %d", l.Step(id))
+	}
+}
+
+func TestGenerateBatchContext(t *testing.T) {
+	n, clock := stalledNode(t)
+	clock.Advance(time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+	ids, err := n.GenerateBatchContext(ctx, 5)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want DeadlineExceeded", err)
+	}
+	if len(ids) > 2 {
+		t.Fatalf("got %d IDs from a two-step millisecond", len(ids))
+	}
+
+	if _, err := n.GenerateBatchContext(t.Context(), 0); err == nil {
+		t.Fatal("count 0 accepted")
+	}
+}
+
+func TestGenerateBatchContextPartial(t *testing.T) {
+	n, clock := stalledNode(t)
+	clock.Advance(time.Millisecond)
+
+	// the first two IDs fill the new millisecond; the rest would need the
+	// clock to move again, so the deadline cuts the batch short
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+	ids, err := n.GenerateBatchContext(ctx, 5)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want DeadlineExceeded", err)
+	}
+	if len(ids) != 2 {
+		t.Fatalf("got %d IDs before the deadline, want the 2 of the new millisecond", len(ids))
+	}
+	if ids[1] <= ids[0] {
+		t.Fatalf("partial batch %v not ascending", ids)
+	}
+
+	clock.Advance(time.Millisecond)
+	if next := n.Generate(); next <= ids[1] {
+		t.Fatalf("Generate after the partial batch = %d, want above %d", next, ids[1])
+	}
+}
+
+func TestGenerateBatchContextLarge(t *testing.T) {
+	n, _ := NewNode(1)
+	count := int(n.Layout().MaxStep())*3 + 7
+	ids, err := n.GenerateBatchContext(t.Context(), count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != count {
+		t.Fatalf("got %d IDs, want %d", len(ids), count)
+	}
+	for i := 1; i < len(ids); i++ {
+		if ids[i] <= ids[i-1] {
+			t.Fatalf("ids[%d] = %v does not follow %v", i, ids[i], ids[i-1])
+		}
+	}
+}
+
+func TestGenerateBatchInto(t *testing.T) {
+	n, _ := NewNode(1)
+	dst := make([]ID, 10000)
+	got, err := n.GenerateBatchInto(dst)
+	if err != nil || got != len(dst) {
+		t.Fatalf("GenerateBatchInto = %d, %v", got, err)
+	}
+	for i := 1; i < len(dst); i++ {
+		if dst[i] <= dst[i-1] {
+			t.Fatalf("dst[%d] = %v does not follow %v", i, dst[i], dst[i-1])
+		}
+	}
+	if next := n.Generate(); next <= dst[len(dst)-1] {
+		t.Fatal("Generate went back after GenerateBatchInto")
+	}
+
+	if got, err := n.GenerateBatchInto(nil); got != 0 || err != nil {
+		t.Fatalf("empty dst = %d, %v", got, err)
+	}
+
+	// the mkeydebug build records a caller for every ID
+	buf := make([]ID, 64)
+	if allocs := testing.AllocsPerRun(100, func() { n.GenerateBatchInto(buf) }); allocs != 0 && !ProvenanceEnabled {
+		t.Fatalf("GenerateBatchInto allocates %v times", allocs)
+	}
+
+	n.Close()
+	if got, err := n.GenerateBatchInto(buf); got != 0 || !errors.Is(err, ErrClosed) {
+		t.Fatalf("after Close = %d, %v; want 0, ErrClosed", got, err)
+	}
+}
@@ -0,0 +1,149 @@
+package mkeytest
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/icehuntmen/mkey"
+)
+
+func generate(t *testing.T, cfg mkey.Config, src rand.Source, count int) []mkey.ID {
+	t.Helper()
+	g, err := NewGenerator(cfg, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, err := g.GenerateBatch(count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ids
+}
+
+func TestGeneratorReproducible(t *testing.T) {
+	cfg := *mkey.NewConfig()
+	a := generate(t, cfg, rand.NewPCG(1, 2), 500)
+	if b := generate(t, cfg, rand.NewPCG(1, 2), 500); !slices.Equal(a, b) {
+		t.Fatal("the same seed produced different IDs")
+	}
+	if b := generate(t, cfg, rand.NewPCG(1, 3), 500); slices.Equal(a, b) {
+		t.Fatal("different seeds produced the same IDs")
+	}
+	seed := [32]byte{1}
+	c := generate(t, cfg, rand.NewChaCha8(seed), 500)
+	if d := generate(t, cfg, rand.NewChaCha8(seed), 500); !slices.Equal(c, d) {
+		t.Fatal("the same ChaCha8 seed produced different IDs")
+	}
+
+	l := cfg.Layout()
+	node := l.NodeID(a[0])
+	for i, id := range a {
+		if i > 0 && id <= a[i-1] {
+			t.Fatalf("ID %d = %d, not above %d", i, id, a[i-1])
+		}
+		if l.NodeID(id) != node {
+			t.Fatalf("ID %d has node %d, want %d", i, l.NodeID(id), node)
+		}
+		if ts := l.Time(id); ts < l.Epoch || ts > l.Epoch+fixtureSpan.Milliseconds()+int64(len(a)) {
+			t.Fatalf("ID %d has time %d, outside a year of the epoch %d", i, ts, l.Epoch)
+		}
+	}
+}
+
+func TestGeneratorFixedNode(t *testing.T) {
+	cfg := *mkey.NewConfig()
+	cfg.Node = 42
+	for _, id := range generate(t, cfg, rand.NewPCG(1, 2), 10) {
+		if got := cfg.Layout().NodeID(id); got != 42 {
+			t.Fatalf("ID %d has node %d, want 42", id, got)
+		}
+	}
+}
+
+func TestGeneratorStillClock(t *testing.T) {
+	cfg := *mkey.NewConfig()
+	cfg.StepBits = 2
+	g, err := NewGenerator(cfg, rand.NewPCG(1, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.MaxAdvance = 0
+	start := g.Clock().Now()
+
+	// a still clock only moves when a millisecond fills up, one per 4 IDs
+	ids, err := g.GenerateBatch(12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := cfg.Layout()
+	for i, id := range ids {
+		if got, want := l.Time(id), start.UnixMilli()+int64(i/4); got != want {
+			t.Fatalf("ID %d has time %d, want %d", i, got, want)
+		}
+	}
+	if got := g.Clock().Now().Sub(start); got != 2*time.Millisecond {
+		t.Fatalf("clock advanced %s, want 2ms", got)
+	}
+}
+
+func TestGeneratorContextAndClose(t *testing.T) {
+	g, err := NewGenerator(*mkey.NewConfig(), rand.NewPCG(1, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.GenerateContext(t.Context()); err != nil {
+		t.Fatalf("GenerateContext: %v", err)
+	}
+	if _, err := g.GenerateBatch(0); err == nil {
+		t.Fatal("GenerateBatch accepted a zero count")
+	}
+
+	g.Node().Close()
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Generate on a closed node did not panic")
+		}
+	}()
+	g.Generate()
+}
+
+func TestNewGeneratorRejects(t *testing.T) {
+	cfg := *mkey.NewConfig()
+	cfg.CoarseTime = true
+	if _, err := NewGenerator(cfg, rand.NewPCG(1, 2)); err == nil {
+		t.Error("NewGenerator accepted CoarseTime")
+	}
+	cfg = *mkey.NewConfig()
+	cfg.RandomStepStart = true
+	if _, err := NewGenerator(cfg, rand.NewPCG(1, 2)); err == nil {
+		t.Error("NewGenerator accepted RandomStepStart")
+	}
+}
+
+func TestRandomIDs(t *testing.T) {
+	cfg := mkey.NewConfig()
+	cfg.NodeBits = 4
+	cfg.StepBits = 4
+	l := cfg.Layout()
+
+	ids := RandomIDs(rand.NewPCG(7, 7), l, 1000)
+	if len(ids) != 1000 {
+		t.Fatalf("got %d IDs, want 1000", len(ids))
+	}
+	if !slices.Equal(ids, RandomIDs(rand.NewPCG(7, 7), l, 1000)) {
+		t.Fatal("the same seed produced different IDs")
+	}
+	for i, id := range ids {
+		if i > 0 && id <= ids[i-1] {
+			t.Fatalf("IDs not ascending and distinct at %d: %d after %d", i, id, ids[i-1])
+		}
+		if ts := l.Time(id); ts < l.Epoch || ts >= l.Epoch+fixtureSpan.Milliseconds() {
+			t.Fatalf("ID %d has time %d, outside a year of the epoch", id, ts)
+		}
+		if l.NodeID(id) > l.MaxNode() || l.Step(id) > l.MaxStep() {
+			t.Fatalf("ID %d has node %d and step %d outside the layout", id, l.NodeID(id), l.Step(id))
+		}
+	}
+}
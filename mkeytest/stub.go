@@ -0,0 +1,101 @@
+package mkeytest
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/icehuntmen/mkey"
+)
+
+// ErrStubExhausted is returned by a Stub whose scripted IDs are used up
+var ErrStubExhausted = errors.New("mkeytest: stub has no scripted IDs left")
+
+// Stub is an mkey.Generator that returns scripted IDs in order, for unit
+// tests of code that accepts a Generator. It is safe for concurrent use.
+type Stub struct {
+	mu   sync.Mutex
+	ids  []mkey.ID
+	err  error
+	used int
+}
+
+// NewStub creates a stub returning ids in order
+func NewStub(ids ...mkey.ID) *Stub {
+	return &Stub{ids: ids}
+}
+
+// Push appends ids to the script
+func (s *Stub) Push(ids ...mkey.ID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ids = append(s.ids, ids...)
+}
+
+// Fail makes the next calls fail with err instead of returning IDs, until
+// it is called again with nil
+func (s *Stub) Fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// Used returns how many scripted IDs have been returned
+func (s *Stub) Used() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.used
+}
+
+// Remaining returns how many scripted IDs are left
+func (s *Stub) Remaining() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.ids) - s.used
+}
+
+func (s *Stub) take(count int) ([]mkey.ID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return nil, s.err
+	}
+	if len(s.ids)-s.used < count {
+		return nil, ErrStubExhausted
+	}
+	ids := s.ids[s.used : s.used+count : s.used+count]
+	s.used += count
+	return ids, nil
+}
+
+// Generate returns the next scripted ID. Like Node.Generate it cannot report
+// errors, so it panics when the script is used up or Fail was called.
+func (s *Stub) Generate() mkey.ID {
+	ids, err := s.take(1)
+	if err != nil {
+		panic(err)
+	}
+	return ids[0]
+}
+
+// GenerateContext returns the next scripted ID, or ctx.Err() if ctx is
+// already done
+func (s *Stub) GenerateContext(ctx context.Context) (mkey.ID, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	ids, err := s.take(1)
+	if err != nil {
+		return 0, err
+	}
+	return ids[0], nil
+}
+
+// GenerateBatch returns the next count scripted IDs
+func (s *Stub) GenerateBatch(count int) ([]mkey.ID, error) {
+	if count <= 0 {
+		return nil, errors.New("count must be positive")
+	}
+	return s.take(count)
+}
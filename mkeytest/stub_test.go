@@ -0,0 +1,121 @@
+package mkeytest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/icehuntmen/mkey"
+)
+
+var (
+	_ mkey.Generator = (*mkey.Node)(nil)
+	_ mkey.Generator = (*Stub)(nil)
+	_ mkey.Generator = (*Generator)(nil)
+)
+
+func TestStubScript(t *testing.T) {
+	s := NewStub(1, 2)
+	s.Push(3, 4, 5)
+	var g mkey.Generator = s
+
+	if id := g.Generate(); id != 1 {
+		t.Fatalf("Generate = %d, want 1", id)
+	}
+	if id, err := g.GenerateContext(t.Context()); err != nil || id != 2 {
+		t.Fatalf("GenerateContext = %d, %v, want 2", id, err)
+	}
+	ids, err := g.GenerateBatch(3)
+	if err != nil || !slices.Equal(ids, []mkey.ID{3, 4, 5}) {
+		t.Fatalf("GenerateBatch = %v, %v, want [3 4 5]", ids, err)
+	}
+	if s.Used() != 5 || s.Remaining() != 0 {
+		t.Fatalf("Used, Remaining = %d, %d, want 5, 0", s.Used(), s.Remaining())
+	}
+
+	// appending to a returned batch must not overwrite IDs pushed later
+	s.Push(6)
+	_ = append(ids, 99)
+	if id := s.Generate(); id != 6 {
+		t.Fatalf("Generate after Push = %d, want 6", id)
+	}
+}
+
+func TestStubExhausted(t *testing.T) {
+	s := NewStub(1)
+	if _, err := s.GenerateBatch(2); !errors.Is(err, ErrStubExhausted) {
+		t.Fatalf("GenerateBatch beyond the script = %v, want ErrStubExhausted", err)
+	}
+	if s.Used() != 0 {
+		t.Fatalf("a failed batch used %d IDs", s.Used())
+	}
+	if _, err := s.GenerateBatch(0); err == nil {
+		t.Fatal("GenerateBatch accepted a zero count")
+	}
+	s.Generate()
+	if _, err := s.GenerateContext(t.Context()); !errors.Is(err, ErrStubExhausted) {
+		t.Fatalf("GenerateContext beyond the script = %v, want ErrStubExhausted", err)
+	}
+	defer func() {
+		if r := recover(); r != ErrStubExhausted {
+			t.Fatalf("Generate beyond the script panicked with %v, want ErrStubExhausted", r)
+		}
+	}()
+	s.Generate()
+}
+
+func TestStubFail(t *testing.T) {
+	s := NewStub(1, 2)
+	boom := errors.New("boom")
+	s.Fail(boom)
+	if _, err := s.GenerateContext(t.Context()); !errors.Is(err, boom) {
+		t.Fatalf("GenerateContext = %v, want the scripted error", err)
+	}
+	if _, err := s.GenerateBatch(1); !errors.Is(err, boom) {
+		t.Fatalf("GenerateBatch = %v, want the scripted error", err)
+	}
+	s.Fail(nil)
+	if id := s.Generate(); id != 1 {
+		t.Fatalf("Generate after clearing the failure = %d, want 1", id)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if _, err := s.GenerateContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("GenerateContext with a done context = %v, want context.Canceled", err)
+	}
+	if s.Remaining() != 1 {
+		t.Fatalf("a cancelled call used a scripted ID, %d remaining", s.Remaining())
+	}
+}
+
+func TestStubConcurrent(t *testing.T) {
+	script := make([]mkey.ID, 1000)
+	for i := range script {
+		script[i] = mkey.ID(i + 1)
+	}
+	s := NewStub(script...)
+
+	var mu sync.Mutex
+	var got []mkey.ID
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				id := s.Generate()
+				mu.Lock()
+				got = append(got, id)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	slices.Sort(got)
+	if !slices.Equal(got, script) {
+		t.Fatal("concurrent callers did not each get distinct scripted IDs")
+	}
+}
@@ -0,0 +1,105 @@
+// Package msgid stamps mkey IDs into message headers for NATS and AMQP so
+// consumers get stable de-duplication keys.
+//
+// The helpers take the plain map types underlying nats.Header
+// (map[string][]string) and amqp091.Table (map[string]any), so values of
+// those types can be passed and assigned directly without this package
+// depending on either client:
+//
+//	id := msgid.Ensure(ctx, node)
+//	msg.Header = msgid.SetNATS(msg.Header, id)
+//	_, err := js.PublishMsg(msg)
+//
+// Publish retries of the same logical message must reuse the ID (pass the
+// same ctx to Ensure) so the broker or consumer can drop the duplicates.
+package msgid
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/icehuntmen/mkey"
+)
+
+const (
+	// NATSHeader is the header JetStream uses for message de-duplication
+	NATSHeader = "Nats-Msg-Id"
+
+	// AMQPHeader is the header table key used for AMQP messages. When the
+	// client exposes it, also set the message_id property to id.String().
+	AMQPHeader = "x-message-id"
+)
+
+// Ensure returns the ID carried by ctx, generating one on node if there is none
+func Ensure(ctx context.Context, node *mkey.Node) mkey.ID {
+	if id, ok := mkey.FromContext(ctx); ok {
+		return id
+	}
+	return node.Generate()
+}
+
+// SetNATS stores id in h, allocating h if it is nil, and returns h
+func SetNATS(h map[string][]string, id mkey.ID) map[string][]string {
+	if h == nil {
+		h = make(map[string][]string, 1)
+	}
+	h[NATSHeader] = []string{id.String()}
+	return h
+}
+
+// GetNATS returns the ID stored in h by SetNATS
+func GetNATS(h map[string][]string) (mkey.ID, bool) {
+	v := h[NATSHeader]
+	if len(v) == 0 {
+		return 0, false
+	}
+	return parse(v[0])
+}
+
+// SetAMQP stores id in t, allocating t if it is nil, and returns t
+func SetAMQP(t map[string]any, id mkey.ID) map[string]any {
+	if t == nil {
+		t = make(map[string]any, 1)
+	}
+	t[AMQPHeader] = id.String()
+	return t
+}
+
+// GetAMQP returns the ID stored in t by SetAMQP. Integer values set by
+// other producers are accepted as well.
+func GetAMQP(t map[string]any) (mkey.ID, bool) {
+	switch v := t[AMQPHeader].(type) {
+	case string:
+		return parse(v)
+	case int64:
+		return mkey.ID(v), v > 0
+	case int32:
+		return mkey.ID(v), v > 0
+	}
+	return 0, false
+}
+
+// ContextFromNATS returns ctx carrying the ID found in h, for consumers that
+// continue processing under the message's ID
+func ContextFromNATS(ctx context.Context, h map[string][]string) context.Context {
+	if id, ok := GetNATS(h); ok {
+		return mkey.NewContext(ctx, id)
+	}
+	return ctx
+}
+
+// ContextFromAMQP is ContextFromNATS for AMQP header tables
+func ContextFromAMQP(ctx context.Context, t map[string]any) context.Context {
+	if id, ok := GetAMQP(t); ok {
+		return mkey.NewContext(ctx, id)
+	}
+	return ctx
+}
+
+func parse(s string) (mkey.ID, bool) {
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || v <= 0 {
+		return 0, false
+	}
+	return mkey.ID(v), true
+}
@@ -0,0 +1,74 @@
+package msgid
+
+import (
+	"context"
+	"testing"
+
+	"github.com/icehuntmen/mkey"
+)
+
+func TestEnsure(t *testing.T) {
+	n, err := mkey.NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := mkey.NewContext(t.Context(), 99)
+	if id := Ensure(ctx, n); id != 99 {
+		t.Fatalf("Ensure with an ID in ctx = %v, want 99", id)
+	}
+	a, b := Ensure(context.Background(), n), Ensure(context.Background(), n)
+	if a == 0 || a == b {
+		t.Fatalf("Ensure without an ID = %v, %v; want fresh IDs", a, b)
+	}
+}
+
+func TestNATS(t *testing.T) {
+	h := SetNATS(nil, 12345)
+	if got := h[NATSHeader]; len(got) != 1 || got[0] != "12345" {
+		t.Fatalf("header = %v", got)
+	}
+	if id, ok := GetNATS(h); !ok || id != 12345 {
+		t.Fatalf("GetNATS = %v, %v", id, ok)
+	}
+	if id, _ := mkey.FromContext(ContextFromNATS(t.Context(), h)); id != 12345 {
+		t.Fatalf("ContextFromNATS carried %v", id)
+	}
+
+	existing := map[string][]string{"Other": {"x"}}
+	if h := SetNATS(existing, 1); h["Other"][0] != "x" {
+		t.Fatal("SetNATS dropped existing headers")
+	}
+
+	for _, h := range []map[string][]string{nil, {NATSHeader: {}}, {NATSHeader: {"abc"}}, {NATSHeader: {"-5"}}, {NATSHeader: {"0"}}} {
+		if id, ok := GetNATS(h); ok {
+			t.Errorf("GetNATS(%v) = %v, true", h, id)
+		}
+		if _, ok := mkey.FromContext(ContextFromNATS(t.Context(), h)); ok {
+			t.Errorf("ContextFromNATS(%v) added an ID", h)
+		}
+	}
+}
+
+func TestAMQP(t *testing.T) {
+	tbl := SetAMQP(nil, 777)
+	if tbl[AMQPHeader] != "777" {
+		t.Fatalf("table = %v", tbl)
+	}
+	if id, ok := GetAMQP(tbl); !ok || id != 777 {
+		t.Fatalf("GetAMQP = %v, %v", id, ok)
+	}
+	if id, _ := mkey.FromContext(ContextFromAMQP(t.Context(), tbl)); id != 777 {
+		t.Fatalf("ContextFromAMQP carried %v", id)
+	}
+
+	for v, want := range map[any]mkey.ID{int64(5): 5, int32(6): 6} {
+		if id, ok := GetAMQP(map[string]any{AMQPHeader: v}); !ok || id != want {
+			t.Errorf("GetAMQP(%T) = %v, %v", v, id, ok)
+		}
+	}
+	for _, v := range []any{nil, int64(0), int32(-1), 3.5, "x"} {
+		if id, ok := GetAMQP(map[string]any{AMQPHeader: v}); ok {
+			t.Errorf("GetAMQP(%#v) = %v, true", v, id)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package mkey
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"math"
+	"sync"
+	"time"
+)
+
+// NoiseConfig configures a TimestampNoise
+type NoiseConfig struct {
+	// Epsilon is the privacy parameter; smaller values add more noise
+	Epsilon float64
+
+	// Sensitivity is the difference in creation time that should be hidden,
+	// one second if 0
+	Sensitivity time.Duration
+
+	// Bound caps the absolute noise added, ten times the Laplace scale
+	// Sensitivity/Epsilon if 0
+	Bound time.Duration
+}
+
+// TimestampNoise perturbs the timestamps of IDs exposed outside the system,
+// e.g. by inspect endpoints or public APIs, with Laplace noise of scale
+// Sensitivity/Epsilon truncated to Bound. The noise is a keyed pseudo-random
+// function of the ID's timestamp, so asking for the same timestamp
+// repeatedly, or for many IDs of the same millisecond, returns the same value
+// instead of letting averages converge on the real one. IDs of different
+// milliseconds get independent noise, so averaging many IDs spread over a
+// period still narrows down that period. The ID itself still carries the
+// exact time and must stay internal.
+type TimestampNoise struct {
+	layout Layout
+	scale  float64
+	bound  float64
+
+	mu  sync.Mutex
+	mac hash.Hash
+}
+
+// NewTimestampNoise creates a TimestampNoise for IDs of layout l. key should
+// be at least 16 random bytes and kept secret.
+func NewTimestampNoise(key []byte, l Layout, cfg NoiseConfig) (*TimestampNoise, error) {
+	if len(key) < 16 {
+		return nil, errors.New("key must be at least 16 bytes")
+	}
+	if err := l.Validate(); err != nil {
+		return nil, err
+	}
+	if !(cfg.Epsilon > 0) || math.IsInf(cfg.Epsilon, 1) {
+		return nil, errors.New("Epsilon must be positive")
+	}
+	if cfg.Sensitivity < 0 || cfg.Bound < 0 {
+		return nil, errors.New("Sensitivity and Bound must not be negative")
+	}
+
+	sensitivity := cfg.Sensitivity
+	if sensitivity == 0 {
+		sensitivity = time.Second
+	}
+	scale := float64(sensitivity) / float64(time.Millisecond) / cfg.Epsilon
+	bound := 10 * scale
+	if cfg.Bound > 0 {
+		bound = float64(cfg.Bound) / float64(time.Millisecond)
+	}
+
+	return &TimestampNoise{
+		layout: l,
+		scale:  scale,
+		bound:  bound,
+		mac:    hmac.New(sha256.New, key),
+	}, nil
+}
+
+// Time returns the noisy timestamp of id in milliseconds since the Unix epoch
+func (t *TimestampNoise) Time(id ID) int64 {
+	ms := t.layout.Time(id)
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(ms))
+
+	t.mu.Lock()
+	t.mac.Reset()
+	t.mac.Write(buf[:])
+	prf := binary.BigEndian.Uint64(t.mac.Sum(nil))
+	t.mu.Unlock()
+
+	// Inverse CDF of the Laplace distribution at u, uniform in (-1/2, 1/2)
+	u := (float64(prf>>11)+0.5)/(1<<53) - 0.5
+	noise := -t.scale * math.Copysign(math.Log1p(-2*math.Abs(u)), u)
+	noise = max(-t.bound, min(t.bound, noise))
+
+	return ms + int64(math.Round(noise))
+}
+
+// Timestamp returns the noisy timestamp of id as a time.Time
+func (t *TimestampNoise) Timestamp(id ID) time.Time {
+	return time.UnixMilli(t.Time(id))
+}
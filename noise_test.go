@@ -0,0 +1,119 @@
+package mkey
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+var noiseKey = []byte("0123456789abcdef")
+
+func TestTimestampNoiseSubMillisecond(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          NoiseConfig
+		scale, bound float64
+	}{
+		{"defaults", NoiseConfig{Epsilon: 1}, 1000, 10000},
+		{"sub-ms sensitivity", NoiseConfig{Epsilon: 1, Sensitivity: 500 * time.Microsecond}, 0.5, 5},
+		{"sub-ms bound", NoiseConfig{Epsilon: 0.1, Sensitivity: time.Second, Bound: 1500 * time.Microsecond}, 10000, 1.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tn, err := NewTimestampNoise(noiseKey, DefaultLayout(), tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tn.scale != tt.scale || tn.bound != tt.bound {
+				t.Fatalf("scale, bound = %v, %v, want %v, %v", tn.scale, tn.bound, tt.scale, tt.bound)
+			}
+		})
+	}
+}
+
+func TestTimestampNoiseBounded(t *testing.T) {
+	l := DefaultLayout()
+	tn, err := NewTimestampNoise(noiseKey, l, NoiseConfig{Epsilon: 1, Bound: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Now().UnixMilli() - l.Epoch
+	var moved int
+	for i := range int64(10000) {
+		id := ID((base + i) << l.TimeShift())
+		d := tn.Time(id) - l.Time(id)
+		if d < -50 || d > 50 {
+			t.Fatalf("noise %dms exceeds the 50ms bound", d)
+		}
+		if d != 0 {
+			moved++
+		}
+	}
+	if moved < 9000 {
+		t.Fatalf("only %d of 10000 timestamps were perturbed", moved)
+	}
+}
+
+func TestTimestampNoiseKeyedOnTime(t *testing.T) {
+	n, err := NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tn, err := NewTimestampNoise(noiseKey, n.layout, NoiseConfig{Epsilon: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every ID of one millisecond reports the same noisy time, so averaging
+	// them gains nothing over a single one
+	ids, err := n.GenerateBatch(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := tn.Time(ids[0])
+	for _, id := range ids {
+		if n.layout.Time(id) != n.layout.Time(ids[0]) {
+			continue
+		}
+		if got := tn.Time(id); got != want {
+			t.Fatalf("IDs of one millisecond got noisy times %d and %d", want, got)
+		}
+	}
+	if tn.Timestamp(ids[0]).UnixMilli() != want {
+		t.Fatal("Timestamp disagrees with Time")
+	}
+
+	other, err := NewTimestampNoise([]byte("fedcba9876543210"), n.layout, NoiseConfig{Epsilon: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var same int
+	for i := range int64(100) {
+		id := ids[0] + ID(i<<n.layout.TimeShift())
+		if other.Time(id) == tn.Time(id) {
+			same++
+		}
+	}
+	if same > 10 {
+		t.Fatalf("%d of 100 timestamps got the same noise under different keys", same)
+	}
+}
+
+func TestNewTimestampNoiseRejects(t *testing.T) {
+	tests := map[string]struct {
+		key []byte
+		cfg NoiseConfig
+	}{
+		"short key":            {[]byte("short"), NoiseConfig{Epsilon: 1}},
+		"zero epsilon":         {noiseKey, NoiseConfig{}},
+		"infinite epsilon":     {noiseKey, NoiseConfig{Epsilon: math.Inf(1)}},
+		"NaN epsilon":          {noiseKey, NoiseConfig{Epsilon: math.NaN()}},
+		"negative sensitivity": {noiseKey, NoiseConfig{Epsilon: 1, Sensitivity: -1}},
+		"negative bound":       {noiseKey, NoiseConfig{Epsilon: 1, Bound: -1}},
+	}
+	for name, tt := range tests {
+		if _, err := NewTimestampNoise(tt.key, DefaultLayout(), tt.cfg); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
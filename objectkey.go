@@ -0,0 +1,80 @@
+package mkey
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ObjectKey expands pattern into an object-store key for id, producing
+// time-partitioned keys such as "{yyyy}/{mm}/{dd}/{hh}/{base58}".
+// Time fields use the ID's embedded timestamp in UTC.
+//
+// Supported placeholders:
+//
+//	{yyyy} {mm} {dd} {hh} {mi} {ss}   date and time fields, zero-padded
+//	{node} {step}                      decimal ID components
+//	{id}                               decimal ID
+//	{base32} {base58} {base62} {base64} {hex}
+//	{base62w} {hexw}                   fixed-width, sortable forms
+func ObjectKey(id ID, l Layout, pattern string) (string, error) {
+	t := l.Timestamp(id).UTC()
+
+	var b strings.Builder
+	b.Grow(len(pattern) + 16)
+
+	for len(pattern) > 0 {
+		i := strings.IndexByte(pattern, '{')
+		if i < 0 {
+			b.WriteString(pattern)
+			break
+		}
+		b.WriteString(pattern[:i])
+		pattern = pattern[i+1:]
+
+		j := strings.IndexByte(pattern, '}')
+		if j < 0 {
+			return "", fmt.Errorf("unterminated placeholder in pattern")
+		}
+		name := pattern[:j]
+		pattern = pattern[j+1:]
+
+		switch name {
+		case "yyyy":
+			fmt.Fprintf(&b, "%04d", t.Year())
+		case "mm":
+			fmt.Fprintf(&b, "%02d", int(t.Month()))
+		case "dd":
+			fmt.Fprintf(&b, "%02d", t.Day())
+		case "hh":
+			fmt.Fprintf(&b, "%02d", t.Hour())
+		case "mi":
+			fmt.Fprintf(&b, "%02d", t.Minute())
+		case "ss":
+			fmt.Fprintf(&b, "%02d", t.Second())
+		case "node":
+			b.WriteString(strconv.FormatInt(l.NodeID(id), 10))
+		case "step":
+			b.WriteString(strconv.FormatInt(l.Step(id), 10))
+		case "id":
+			b.WriteString(id.String())
+		case "base32":
+			b.WriteString(id.Base32())
+		case "base58":
+			b.WriteString(id.Base58())
+		case "base62":
+			b.WriteString(id.Base62())
+		case "base62w":
+			b.WriteString(formatBase62(uint64(id), Base62Width))
+		case "base64":
+			b.WriteString(id.Base64())
+		case "hex":
+			b.WriteString(id.Hex())
+		case "hexw":
+			b.WriteString(id.HexWith(FormatOptions{Width: HexWidth}))
+		default:
+			return "", fmt.Errorf("unknown placeholder {%s}", name)
+		}
+	}
+	return b.String(), nil
+}
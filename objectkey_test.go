@@ -0,0 +1,43 @@
+package mkey
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestObjectKey(t *testing.T) {
+	l := NewConfig().Layout()
+	at := time.Date(2025, 3, 7, 9, 5, 2, 0, time.UTC)
+	id, err := l.Compose(at, 12, 34)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for pattern, want := range map[string]string{
+		"{yyyy}/{mm}/{dd}/{hh}/{base58}":        "2025/03/07/09/" + id.Base58(),
+		"logs/{yyyy}{mm}{dd}T{hh}{mi}{ss}.json": "logs/20250307T090502.json",
+		"{node}-{step}":                         "12-34",
+		"{id}.{hex}.{base32}.{base62}.{base64}": fmt.Sprintf("%s.%s.%s.%s.%s", id, id.Hex(), id.Base32(), id.Base62(), id.Base64()),
+		"{hexw}/{base62w}":                      id.HexWith(FormatOptions{Width: HexWidth}) + "/" + formatBase62(uint64(id), Base62Width),
+		"no placeholders":                       "no placeholders",
+	} {
+		got, err := ObjectKey(id, l, pattern)
+		if err != nil || got != want {
+			t.Errorf("ObjectKey(%q) = %q, %v; want %q", pattern, got, err, want)
+		}
+	}
+
+	// local time zones must not leak into the key
+	loc := time.FixedZone("x", 5*3600)
+	id2, _ := l.Compose(at.In(loc), 0, 0)
+	if got, _ := ObjectKey(id2, l, "{hh}"); got != "09" {
+		t.Errorf("{hh} = %q, want UTC hour 09", got)
+	}
+
+	for _, bad := range []string{"{yyyy", "{nope}", "a/{}/b"} {
+		if _, err := ObjectKey(id, l, bad); err == nil {
+			t.Errorf("ObjectKey(%q) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package mkey
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNodeObserve(t *testing.T) {
+	start := time.Now()
+	newNode := func(node int64, at time.Time, drift time.Duration) *Node {
+		cfg := NewConfig()
+		cfg.Node = node
+		cfg.Clock = NewManualClock(at)
+		cfg.MaxDrift = drift
+		n, err := NewNodeWithConfig(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	local := newNode(1, start, 0)
+	peer := newNode(2, start.Add(500*time.Millisecond), 0)
+
+	remote := peer.Generate()
+	if local.Generate() > remote {
+		t.Fatal("local node already ahead of its peer")
+	}
+	if err := local.Observe(remote); err != nil {
+		t.Fatal(err)
+	}
+	prev := remote
+	for range 100 {
+		id := local.Generate()
+		if id <= prev {
+			t.Fatalf("ID %v after Observe does not follow %v", id, prev)
+		}
+		prev = id
+	}
+
+	// an older ID does not lower the floor
+	if err := local.Observe(1); err != nil {
+		t.Fatal(err)
+	}
+	if id := local.Generate(); id <= prev {
+		t.Fatal("Observe of an older ID moved the node back")
+	}
+
+	strict := newNode(3, start, 100*time.Millisecond)
+	if err := strict.Observe(remote); !errors.Is(err, ErrClockDrift) {
+		t.Fatalf("Observe beyond MaxDrift: err = %v, want ErrClockDrift", err)
+	}
+	if id := strict.Generate(); id > remote {
+		t.Fatal("rejected observation raised the floor")
+	}
+	near := newNode(4, start.Add(50*time.Millisecond), 0).Generate()
+	if err := strict.Observe(near); err != nil {
+		t.Fatalf("Observe within MaxDrift: %v", err)
+	}
+	if id := strict.Generate(); id <= near {
+		t.Fatal("accepted observation did not raise the floor")
+	}
+}
@@ -0,0 +1,99 @@
+package mkey
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrPacerClosed is returned by PacedNode.Generate once the node is closed
+var ErrPacerClosed = errors.New("paced node is closed")
+
+// PacedNode issues IDs at a constant rate whatever the demand, so observers
+// comparing the IDs they receive cannot infer traffic volume from steps or
+// gaps. A background goroutine generates one ID per interval and hands it to
+// the longest-waiting caller, discarding it if nobody is waiting; callers
+// queue while the rate is exceeded.
+//
+// The node must not be used for anything else, or its other IDs show up as
+// steps between paced ones. An interval of at least a millisecond gives each
+// ID a millisecond of its own.
+type PacedNode struct {
+	node   *Node
+	ids    chan ID
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewPacedNode starts issuing one ID from node every interval. Call Close to
+// stop it.
+func NewPacedNode(node *Node, interval time.Duration) (*PacedNode, error) {
+	if interval <= 0 {
+		return nil, errors.New("interval must be positive")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &PacedNode{
+		node:   node,
+		ids:    make(chan ID),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go p.run(ctx, interval)
+	return p, nil
+}
+
+func (p *PacedNode) run(ctx context.Context, interval time.Duration) {
+	defer close(p.done)
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+
+		id, err := p.node.GenerateContext(ctx)
+		if errors.Is(err, ErrClosed) {
+			return
+		}
+		if err != nil {
+			// Skip the tick; a clock regression leaves a gap either way
+			continue
+		}
+		select {
+		case p.ids <- id:
+		default:
+		}
+	}
+}
+
+// Generate waits for the next paced ID, or until ctx is done or the node is
+// closed
+func (p *PacedNode) Generate(ctx context.Context) (ID, error) {
+	select {
+	case id := <-p.ids:
+		return id, nil
+	case <-p.done:
+		return 0, ErrPacerClosed
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// Node returns the node IDs are generated from
+func (p *PacedNode) Node() *Node {
+	return p.node
+}
+
+// Close stops issuance and waits for the background goroutine to exit.
+// Waiting callers get ErrPacerClosed. It is safe to call Close more than once.
+func (p *PacedNode) Close() error {
+	p.once.Do(p.cancel)
+	<-p.done
+	return nil
+}
@@ -0,0 +1,108 @@
+package mkey
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newPacedNode(t *testing.T, interval time.Duration) *PacedNode {
+	t.Helper()
+	n, err := NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewPacedNode(n, interval)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestPacedNode(t *testing.T) {
+	const interval = 5 * time.Millisecond
+	p := newPacedNode(t, interval)
+	defer p.Close()
+
+	start := time.Now()
+	var prev ID
+	for i := range 10 {
+		id, err := p.Generate(t.Context())
+		if err != nil {
+			t.Fatalf("Generate %d: %v", i, err)
+		}
+		if id <= prev {
+			t.Fatalf("ID %d not above %d", id, prev)
+		}
+		if prev != 0 && id.Time(p.Node()) == prev.Time(p.Node()) {
+			t.Fatalf("paced IDs %d and %d share a millisecond", prev, id)
+		}
+		prev = id
+	}
+	if d := time.Since(start); d < 9*interval {
+		t.Fatalf("10 paced IDs took %s, want at least %s", d, 9*interval)
+	}
+}
+
+func TestPacedNodeDiscardsIdle(t *testing.T) {
+	p := newPacedNode(t, time.Millisecond)
+	defer p.Close()
+
+	// IDs issued while nobody waits are dropped rather than queued, so a
+	// caller arriving later gets a fresh one
+	time.Sleep(20 * time.Millisecond)
+	before := time.Now().UnixMilli()
+	id, err := p.Generate(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.Time(p.Node()) < before {
+		t.Fatalf("ID from %d handed out at %d was queued while idle", id.Time(p.Node()), before)
+	}
+	if generated := p.Node().Stats().Generated; generated < 5 {
+		t.Fatalf("node generated %d IDs while idle, want one per tick", generated)
+	}
+}
+
+func TestPacedNodeContext(t *testing.T) {
+	p := newPacedNode(t, time.Hour)
+	defer p.Close()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Generate(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Generate = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestPacedNodeClose(t *testing.T) {
+	p := newPacedNode(t, time.Hour)
+	errc := make(chan error, 1)
+	go func() {
+		_, err := p.Generate(context.Background())
+		errc <- err
+	}()
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; !errors.Is(err, ErrPacerClosed) {
+		t.Fatalf("waiting Generate = %v, want ErrPacerClosed", err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+	if _, err := p.Generate(context.Background()); !errors.Is(err, ErrPacerClosed) {
+		t.Fatalf("Generate after Close = %v, want ErrPacerClosed", err)
+	}
+}
+
+func TestNewPacedNodeInterval(t *testing.T) {
+	n, err := NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewPacedNode(n, 0); err == nil {
+		t.Fatal("NewPacedNode accepted a zero interval")
+	}
+}
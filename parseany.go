@@ -0,0 +1,138 @@
+package mkey
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ParseInfo describes how ParseAnyInfo interpreted a string
+type ParseInfo struct {
+	// Encoding is the encoding the ID was decoded from
+	Encoding Encoding
+
+	// LayoutName and Layout are the preferred registered layout the ID is
+	// valid under: "mkey" if it fits, otherwise the first by name. Both are
+	// zero if the ID fits no registered layout.
+	LayoutName string
+	Layout     Layout
+
+	// Warnings lists ambiguities worth reviewing, e.g. other encodings the
+	// string is also valid in
+	Warnings []string
+}
+
+// parseAnyOrder lists the encodings ParseAny tries, most specific first
+var parseAnyOrder = []Encoding{
+	EncodingDecimal,
+	EncodingHex,
+	EncodingBase58,
+	EncodingBase62,
+	EncodingBase32,
+	EncodingBase32Std,
+	EncodingBase64,
+}
+
+// ParseAny parses an ID of unknown encoding; see ParseAnyInfo
+func ParseAny(s string) (ID, error) {
+	id, _, err := ParseAnyInfo(s)
+	return id, err
+}
+
+// ParseAnyInfo parses an ID whose encoding is not known in advance, such as
+// one received by an ingestion service. A "b58:" prefix selects Base58 and
+// "0x" selects hex; otherwise the encodings are tried in order (decimal, hex
+// when exactly HexWidth digits, Base58, Base62, base32, standard base32,
+// base64) and the first whose result is valid under a registered layout
+// wins. Other encodings yielding valid IDs are reported as warnings.
+func ParseAnyInfo(s string) (ID, ParseInfo, error) {
+	if s == "" {
+		return 0, ParseInfo{}, errors.New("empty ID")
+	}
+	if rest, ok := strings.CutPrefix(s, JSONBase58Prefix); ok {
+		return parseAnyAs(EncodingBase58, rest)
+	}
+	if rest, ok := strings.CutPrefix(strings.ToLower(s), "0x"); ok {
+		return parseAnyAs(EncodingHex, rest)
+	}
+
+	var (
+		found    bool
+		best     ID
+		info     ParseInfo
+		fallback *ParseInfo
+		fbID     ID
+	)
+	for _, e := range parseAnyOrder {
+		if e == EncodingHex && len(s) != HexWidth {
+			continue
+		}
+		id, err := e.Parse(s)
+		if err != nil {
+			continue
+		}
+		names := MatchLayouts(id)
+		if len(names) == 0 {
+			if fallback == nil {
+				fallback = &ParseInfo{Encoding: e}
+				fbID = id
+			}
+			continue
+		}
+		if found {
+			info.Warnings = append(info.Warnings, fmt.Sprintf("also a valid %s ID (%d)", e, id))
+			continue
+		}
+		found, best = true, id
+		info = parseInfoFor(e, names)
+	}
+
+	if found {
+		return best, info, nil
+	}
+	if fallback != nil {
+		fallback.Warnings = append(fallback.Warnings, noLayoutWarning(fbID))
+		return fbID, *fallback, nil
+	}
+	return 0, ParseInfo{}, fmt.Errorf("%q is not an ID in any known encoding", s)
+}
+
+// parseAnyAs parses s in an encoding selected by an explicit prefix
+func parseAnyAs(e Encoding, s string) (ID, ParseInfo, error) {
+	id, err := e.Parse(s)
+	if err != nil {
+		return 0, ParseInfo{}, err
+	}
+	names := MatchLayouts(id)
+	info := parseInfoFor(e, names)
+	if len(names) == 0 {
+		info.Warnings = append(info.Warnings, noLayoutWarning(id))
+	}
+	return id, info, nil
+}
+
+// noLayoutWarning explains why id fits no registered layout
+func noLayoutWarning(id ID) string {
+	if id.IsHashed() {
+		return "hashed ID from HashToID, not valid under any layout"
+	}
+	return "not valid under any registered layout"
+}
+
+func parseInfoFor(e Encoding, layoutNames []string) ParseInfo {
+	info := ParseInfo{Encoding: e}
+	if len(layoutNames) == 0 {
+		return info
+	}
+
+	info.LayoutName = layoutNames[0]
+	if slices.Contains(layoutNames, "mkey") {
+		info.LayoutName = "mkey"
+	}
+	info.Layout, _ = LookupLayout(info.LayoutName)
+	if len(layoutNames) > 1 {
+		info.Warnings = append(info.Warnings, "valid under layouts "+strings.Join(layoutNames, ", "))
+	}
+	return info
+}
@@ -0,0 +1,73 @@
+package mkey
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAnyInfo(t *testing.T) {
+	// a step with hex letters keeps the padded hex form from also reading
+	// as a decimal ID
+	id, err := DefaultLayout().Compose(time.Now(), 3, 0xabc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		in   string
+		enc  Encoding
+		warn string
+	}{
+		{id.String(), EncodingDecimal, "valid under layouts discord, mkey, twitter"},
+		{JSONBase58Prefix + id.Base58(), EncodingBase58, ""},
+		{"0x" + id.Hex(), EncodingHex, ""},
+		{"0X" + strings.ToUpper(id.Hex()), EncodingHex, ""},
+		{id.HexWith(FormatOptions{Width: HexWidth}), EncodingHex, ""},
+		{id.Base58(), EncodingBase58, ""},
+	}
+	for _, tt := range tests {
+		got, info, err := ParseAnyInfo(tt.in)
+		if err != nil || got != id {
+			t.Errorf("ParseAnyInfo(%q) = %v, %v; want %v", tt.in, got, err, id)
+			continue
+		}
+		if info.Encoding != tt.enc || info.LayoutName != "mkey" || info.Layout != DefaultLayout() {
+			t.Errorf("ParseAnyInfo(%q) info = %+v", tt.in, info)
+		}
+		if tt.warn != "" && !strings.Contains(strings.Join(info.Warnings, "\n"), tt.warn) {
+			t.Errorf("ParseAnyInfo(%q) warnings = %q, want %q", tt.in, info.Warnings, tt.warn)
+		}
+		if p, err := ParseAny(tt.in); err != nil || p != id {
+			t.Errorf("ParseAny(%q) = %v, %v", tt.in, p, err)
+		}
+	}
+}
+
+func TestParseAnyInfoLayouts(t *testing.T) {
+	tw, _ := LookupLayout("twitter")
+	id, err := tw.Compose(time.Now(), 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, info, err := ParseAnyInfo(id.String())
+	if err != nil || info.LayoutName != "twitter" {
+		t.Fatalf("Twitter ID: info %+v, err %v", info, err)
+	}
+
+	// an ID from far in the future fits no layout but still parses
+	far := ID(1<<63 - 1)
+	got, info, err := ParseAnyInfo(far.String())
+	if err != nil || got != far || info.LayoutName != "" {
+		t.Fatalf("far ID = %v, %+v, %v", got, info, err)
+	}
+	if !strings.Contains(strings.Join(info.Warnings, "\n"), "not valid under any registered layout") {
+		t.Fatalf("far ID warnings = %q", info.Warnings)
+	}
+
+	for _, bad := range []string{"", "!!!", JSONBase58Prefix + "0OIl", "0xzz"} {
+		if _, _, err := ParseAnyInfo(bad); err == nil {
+			t.Errorf("ParseAnyInfo(%q) succeeded", bad)
+		}
+	}
+}
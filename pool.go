@@ -0,0 +1,95 @@
+package mkey
+
+import (
+	"errors"
+	"sync"
+)
+
+// PoolMember is a node and its share of a Pool's Generate calls
+type PoolMember struct {
+	Node *Node
+
+	// Weight is the node's relative share; 0 means the node's step capacity
+	// (MaxStep+1), so nodes configured with more headroom take more calls
+	Weight int
+}
+
+// Pool spreads Generate calls over several nodes in proportion to their
+// weights, e.g. one node per host in a mixed fleet. Selection uses smooth
+// weighted round robin, so a heavy node's turns are interleaved with the
+// others rather than taken in bursts.
+type Pool struct {
+	mu      sync.Mutex
+	members []poolMember
+	total   int64
+}
+
+type poolMember struct {
+	node    *Node
+	weight  int64
+	current int64
+}
+
+// NewPool creates a pool of nodes each weighted by its step capacity
+func NewPool(nodes ...*Node) (*Pool, error) {
+	members := make([]PoolMember, len(nodes))
+	for i, n := range nodes {
+		members[i] = PoolMember{Node: n}
+	}
+	return NewWeightedPool(members...)
+}
+
+// NewWeightedPool creates a pool with explicit weights
+func NewWeightedPool(members ...PoolMember) (*Pool, error) {
+	if len(members) == 0 {
+		return nil, errors.New("pool needs at least one node")
+	}
+
+	p := &Pool{members: make([]poolMember, len(members))}
+	for i, m := range members {
+		if m.Node == nil {
+			return nil, errors.New("pool node must not be nil")
+		}
+		if m.Weight < 0 {
+			return nil, errors.New("pool weight must not be negative")
+		}
+		w := int64(m.Weight)
+		if w == 0 {
+			w = m.Node.stepMask + 1
+		}
+		p.members[i] = poolMember{node: m.Node, weight: w}
+		p.total += w
+	}
+	return p, nil
+}
+
+// next picks the node for the next call
+func (p *Pool) next() *Node {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	best := &p.members[0]
+	for i := range p.members {
+		m := &p.members[i]
+		m.current += m.weight
+		if m.current > best.current {
+			best = m
+		}
+	}
+	best.current -= p.total
+	return best.node
+}
+
+// Generate returns an ID from the next node in weighted order
+func (p *Pool) Generate() ID {
+	return p.next().Generate()
+}
+
+// Nodes returns the pool's nodes
+func (p *Pool) Nodes() []*Node {
+	nodes := make([]*Node, len(p.members))
+	for i, m := range p.members {
+		nodes[i] = m.node
+	}
+	return nodes
+}
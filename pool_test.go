@@ -0,0 +1,96 @@
+package mkey
+
+import "testing"
+
+func poolNode(t *testing.T, node int64, stepBits uint8) *Node {
+	t.Helper()
+	cfg := NewConfig()
+	cfg.Node = node
+	cfg.StepBits = stepBits
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+// poolCounts returns how many of calls IDs each node ID issued, with the
+// position of each call
+func poolCounts(p *Pool, calls int) (map[int64]int, []int64) {
+	counts := make(map[int64]int)
+	var order []int64
+	for range calls {
+		id := p.Generate()
+		node := int64(id) >> DefaultStepBits & DefaultLayout().NodeMask()
+		counts[node]++
+		order = append(order, node)
+	}
+	return counts, order
+}
+
+func TestWeightedPool(t *testing.T) {
+	a, b := poolNode(t, 1, DefaultStepBits), poolNode(t, 2, DefaultStepBits)
+	p, err := NewWeightedPool(PoolMember{Node: a, Weight: 3}, PoolMember{Node: b, Weight: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts, order := poolCounts(p, 400)
+	if counts[1] != 300 || counts[2] != 100 {
+		t.Fatalf("counts = %v, want 300:100", counts)
+	}
+	// smooth: the light node gets a turn in every cycle of four
+	for i := 0; i+4 <= len(order); i += 4 {
+		c := 0
+		for _, n := range order[i : i+4] {
+			if n == 2 {
+				c++
+			}
+		}
+		if c != 1 {
+			t.Fatalf("cycle at %d = %v, want one call to node 2", i, order[i:i+4])
+		}
+	}
+
+	if got := p.Nodes(); len(got) != 2 || got[0] != a || got[1] != b {
+		t.Fatalf("Nodes() = %v", got)
+	}
+}
+
+func TestPoolStepWeights(t *testing.T) {
+	big, small := poolNode(t, 1, DefaultStepBits), poolNode(t, 2, DefaultStepBits)
+	p, err := NewPool(big, small)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts, _ := poolCounts(p, 100); counts[1] != 50 || counts[2] != 50 {
+		t.Fatalf("equal nodes: counts = %v", counts)
+	}
+
+	// a node with half the step space takes half the calls; count picks
+	// directly since its IDs decode under a different layout
+	half := poolNode(t, 3, DefaultStepBits-1)
+	p, err = NewPool(big, half)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := map[*Node]int{}
+	for range 300 {
+		calls[p.next()]++
+	}
+	if calls[big] != 200 || calls[half] != 100 {
+		t.Fatalf("capacity weights: big %d, half %d; want 200, 100", calls[big], calls[half])
+	}
+}
+
+func TestPoolInvalid(t *testing.T) {
+	n := poolNode(t, 1, DefaultStepBits)
+	if _, err := NewPool(); err == nil {
+		t.Error("empty pool accepted")
+	}
+	if _, err := NewPool(n, nil); err == nil {
+		t.Error("nil node accepted")
+	}
+	if _, err := NewWeightedPool(PoolMember{Node: n, Weight: -1}); err == nil {
+		t.Error("negative weight accepted")
+	}
+}
@@ -0,0 +1,26 @@
+package mkey
+
+import (
+	"context"
+	"fmt"
+)
+
+// MaxPriority returns the largest priority the layout can encode
+func (l Layout) MaxPriority() uint8 {
+	return uint8(1<<l.PriorityBits - 1)
+}
+
+// Priority returns the priority component of id
+func (l Layout) Priority(id ID) uint8 {
+	return uint8(int64(id) >> (63 - l.PriorityBits) & int64(l.MaxPriority()))
+}
+
+// GenerateWithPriority creates an ID with priority p in the bits above the
+// timestamp, so sorting IDs yields priority-then-time order. Lower values
+// sort first; use 0 for the most urgent class if consumers pop the smallest key.
+func (n *Node) GenerateWithPriority(p uint8) (ID, error) {
+	if max := n.layout.MaxPriority(); p > max {
+		return 0, fmt.Errorf("priority must be <= %d", max)
+	}
+	return n.generateContext(context.Background(), int64(p)<<(63-n.layout.PriorityBits))
+}
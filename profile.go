@@ -0,0 +1,57 @@
+package mkey
+
+import (
+	"context"
+	"runtime/pprof"
+	"runtime/trace"
+	"strconv"
+	"time"
+)
+
+// Kinds of waits reported in the mkey_wait pprof label and trace region names
+const (
+	waitNextMillisecond = "next_millisecond"
+	waitMaintenance     = "maintenance"
+	waitRateLimit       = "rate_limit"
+	waitReservedSteps   = "reserved_steps"
+)
+
+// instrumentWait runs the blocking function f inside an execution trace
+// region, when tracing is on, and with pprof labels added to those of ctx,
+// when Config.ProfileWaits is set. Otherwise f runs directly. Config.Hook
+// learns how long f blocked.
+func (n *Node) instrumentWait(ctx context.Context, kind string, f func()) {
+	if n.hook != nil {
+		start := time.Now()
+		defer func() { n.hook.OnWait(time.Since(start)) }()
+	}
+	tracing := trace.IsEnabled()
+	if !tracing && !n.profileWaits {
+		f()
+		return
+	}
+
+	if tracing {
+		defer trace.StartRegion(ctx, "mkey.wait."+kind).End()
+	}
+	if !n.profileWaits {
+		f()
+		return
+	}
+	labels := pprof.Labels("mkey_wait", kind, "mkey_node", strconv.FormatInt(n.node, 10))
+	pprof.Do(ctx, labels, func(context.Context) { f() })
+}
+
+// waitMaintenance blocks while a maintenance window is active, or until ctx is done
+func (n *Node) waitMaintenance(ctx context.Context) error {
+	// Skip reading the clock on the hot path when nothing is scheduled
+	if !n.maint.scheduled.Load() {
+		return nil
+	}
+	if _, _, ok := n.maint.active(time.Now()); !ok {
+		return nil
+	}
+	var err error
+	n.instrumentWait(ctx, waitMaintenance, func() { err = n.maint.wait(ctx) })
+	return err
+}
@@ -0,0 +1,67 @@
+package mkey
+
+import (
+	"bytes"
+	"runtime/pprof"
+	"runtime/trace"
+	"strings"
+	"testing"
+)
+
+// goroutineProfile returns the debug=1 goroutine profile, which lists the
+// pprof labels of each goroutine
+func goroutineProfile(t *testing.T) string {
+	var b bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&b, 1); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestInstrumentWaitLabels(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Node = 12
+	cfg.ProfileWaits = true
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var during string
+	n.instrumentWait(t.Context(), waitNextMillisecond, func() { during = goroutineProfile(t) })
+	for _, want := range []string{`"mkey_wait":"next_millisecond"`, `"mkey_node":"12"`} {
+		if !strings.Contains(during, want) {
+			t.Errorf("profile during the wait lacks %s", want)
+		}
+	}
+	if after := goroutineProfile(t); strings.Contains(after, "mkey_wait") {
+		t.Error("labels outlive the wait")
+	}
+
+	plain, _ := NewNode(13)
+	plain.instrumentWait(t.Context(), waitNextMillisecond, func() { during = goroutineProfile(t) })
+	if strings.Contains(during, "mkey_wait") {
+		t.Error("labels set without Config.ProfileWaits")
+	}
+}
+
+func TestInstrumentWaitTrace(t *testing.T) {
+	if trace.IsEnabled() {
+		t.Skip("tracing already enabled")
+	}
+	var b bytes.Buffer
+	if err := trace.Start(&b); err != nil {
+		t.Fatal(err)
+	}
+	n, _ := NewNode(1)
+	ran := false
+	n.instrumentWait(t.Context(), waitMaintenance, func() { ran = true })
+	trace.Stop()
+
+	if !ran {
+		t.Fatal("wait function did not run")
+	}
+	if !bytes.Contains(b.Bytes(), []byte("mkey.wait.maintenance")) {
+		t.Error("trace lacks the mkey.wait.maintenance region")
+	}
+}
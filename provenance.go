@@ -0,0 +1,17 @@
+package mkey
+
+import "time"
+
+// Provenance records where an ID was generated; see Node.Provenance
+type Provenance struct {
+	ID ID
+
+	// Caller is the function and file:line outside mkey that requested the ID
+	Caller string
+
+	// Time is the wall-clock time of generation
+	Time time.Time
+
+	// Goroutine is the ID of the generating goroutine
+	Goroutine uint64
+}
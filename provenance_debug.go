@@ -0,0 +1,77 @@
+//go:build mkeydebug
+
+package mkey
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProvenanceEnabled reports whether the binary was built with the mkeydebug
+// tag, which makes nodes record where each ID was generated
+const ProvenanceEnabled = true
+
+// ProvenanceSize is the number of most recent IDs each node remembers
+const ProvenanceSize = 4096
+
+// provenanceRing holds the most recent records, guarded by Node.mu
+type provenanceRing struct {
+	entries [ProvenanceSize]Provenance
+	next    int
+}
+
+// recordProvenance remembers the caller of the current generation. Callers hold n.mu.
+func (n *Node) recordProvenance(id ID) {
+	n.prov.entries[n.prov.next] = Provenance{
+		ID:        id,
+		Caller:    externalCaller(),
+		Time:      time.Now(),
+		Goroutine: goroutineID(),
+	}
+	n.prov.next = (n.prov.next + 1) % ProvenanceSize
+}
+
+// Provenance returns where id was generated, if it is among the node's
+// ProvenanceSize most recent IDs
+func (n *Node) Provenance(id ID) (Provenance, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for _, p := range n.prov.entries {
+		if p.ID == id && !p.Time.IsZero() {
+			return p, true
+		}
+	}
+	return Provenance{}, false
+}
+
+// externalCaller returns the first frame outside this package
+func externalCaller() string {
+	var pcs [16]uintptr
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs[:])])
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, "github.com/icehuntmen/mkey.") {
+			return f.Function + " " + f.File + ":" + strconv.Itoa(f.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+// goroutineID parses the current goroutine's ID from its stack header,
+// "goroutine 123 [running]:"
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
@@ -0,0 +1,64 @@
+//go:build mkeydebug
+
+package mkey
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProvenance(t *testing.T) {
+	n, err := NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	id := n.Generate()
+
+	p, ok := n.Provenance(id)
+	if !ok || p.ID != id {
+		t.Fatalf("Provenance(%v) = %+v, %v", id, p, ok)
+	}
+	// frames inside mkey, including this test, are skipped
+	if !strings.HasPrefix(p.Caller, "testing.") || !strings.Contains(p.Caller, ".go:") {
+		t.Errorf("Caller = %q, want the first frame outside mkey", p.Caller)
+	}
+	if p.Time.Before(start) || time.Since(p.Time) > time.Minute {
+		t.Errorf("Time = %v", p.Time)
+	}
+	if p.Goroutine == 0 || p.Goroutine != goroutineID() {
+		t.Errorf("Goroutine = %d, want %d", p.Goroutine, goroutineID())
+	}
+
+	other := make(chan uint64)
+	go func() {
+		p, _ := n.Provenance(n.Generate())
+		other <- p.Goroutine
+	}()
+	if g := <-other; g == 0 || g == p.Goroutine {
+		t.Errorf("ID from another goroutine recorded goroutine %d", g)
+	}
+
+	if _, ok := n.Provenance(id + 1<<40); ok {
+		t.Error("Provenance found an ID the node never issued")
+	}
+}
+
+func TestProvenanceRing(t *testing.T) {
+	n, err := NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := n.Generate()
+	var last ID
+	for range ProvenanceSize {
+		last = n.Generate()
+	}
+	if _, ok := n.Provenance(first); ok {
+		t.Error("oldest ID still recorded after the ring wrapped")
+	}
+	if _, ok := n.Provenance(last); !ok {
+		t.Error("newest ID not recorded")
+	}
+}
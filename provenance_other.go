@@ -0,0 +1,18 @@
+//go:build !mkeydebug
+
+package mkey
+
+// ProvenanceEnabled reports whether the binary was built with the mkeydebug
+// tag, which makes nodes record where each ID was generated
+const ProvenanceEnabled = false
+
+// provenanceRing is empty without the mkeydebug tag, so recording compiles away
+type provenanceRing struct{}
+
+func (n *Node) recordProvenance(ID) {}
+
+// Provenance returns where id was generated. Without the mkeydebug build tag
+// nothing is recorded and it always reports false.
+func (n *Node) Provenance(id ID) (Provenance, bool) {
+	return Provenance{}, false
+}
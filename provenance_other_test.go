@@ -0,0 +1,16 @@
+//go:build !mkeydebug
+
+package mkey
+
+import "testing"
+
+func TestProvenanceDisabled(t *testing.T) {
+	n, err := NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := n.Generate()
+	if p, ok := n.Provenance(id); ok || p != (Provenance{}) {
+		t.Fatalf("Provenance without mkeydebug = %+v, %v", p, ok)
+	}
+}
@@ -0,0 +1,154 @@
+package mkey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrRateLimited is returned by TryGenerate when issuing the ID would exceed
+// the node's rate limit
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// rateLimiter is a token bucket refilled at rate tokens per second up to
+// burst. Like maintenance it has its own lock, so callers waiting for tokens
+// do not hold the generator lock. It always uses real time.
+type rateLimiter struct {
+	// enabled lets the hot path skip the lock when there is no limit
+	enabled atomic.Bool
+
+	mu      sync.Mutex
+	rate    float64
+	burst   int
+	tokens  float64
+	last    time.Time
+	changed chan struct{}
+	closed  bool
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	r := &rateLimiter{changed: make(chan struct{})}
+	r.set(rate, burst)
+	return r
+}
+
+func (r *rateLimiter) set(rate float64, burst int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if burst < 1 {
+		burst = 1
+	}
+	r.rate = rate
+	r.burst = burst
+	r.tokens = float64(burst)
+	r.last = time.Now()
+	r.enabled.Store(rate > 0)
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+// close makes waiters and later callers fail with ErrClosed, see Node.Close
+func (r *rateLimiter) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+// reserve takes count tokens, letting the bucket go negative, and returns how
+// long the caller must wait before proceeding. With noWait it takes nothing
+// and fails with ErrRateLimited if the tokens are not available now.
+func (r *rateLimiter) reserve(count int, noWait bool) (time.Duration, chan struct{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return 0, nil, ErrClosed
+	}
+	if r.rate <= 0 {
+		return 0, nil, nil
+	}
+	if count > r.burst {
+		return 0, nil, fmt.Errorf("count must be <= rate limit burst %d", r.burst)
+	}
+
+	now := time.Now()
+	r.tokens = min(float64(r.burst), r.tokens+now.Sub(r.last).Seconds()*r.rate)
+	r.last = now
+
+	need := float64(count)
+	if r.tokens >= need {
+		r.tokens -= need
+		return 0, nil, nil
+	}
+	if noWait {
+		return 0, nil, ErrRateLimited
+	}
+	delay := time.Duration((need - r.tokens) / r.rate * float64(time.Second))
+	r.tokens -= need
+	return delay, r.changed, nil
+}
+
+// cancel returns count tokens reserved by a caller that gave up waiting,
+// unless the limit was replaced in the meantime
+func (r *rateLimiter) cancel(count int, changed chan struct{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if changed == r.changed {
+		r.tokens = min(float64(r.burst), r.tokens+float64(count))
+	}
+}
+
+// wait blocks until count tokens are available or ctx is done. Limit
+// changes and Node.Close wake waiters, which then reserve again under the
+// new limit or fail with ErrClosed.
+func (r *rateLimiter) wait(ctx context.Context, delay time.Duration, changed chan struct{}, count int) error {
+	for {
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+			return nil
+		case <-changed:
+			t.Stop()
+		case <-ctx.Done():
+			t.Stop()
+			r.cancel(count, changed)
+			return ctx.Err()
+		}
+
+		var err error
+		delay, changed, err = r.reserve(count, false)
+		if err != nil || delay == 0 {
+			return err
+		}
+	}
+}
+
+// waitRate takes count tokens from the node's rate limit, blocking until they
+// are available unless ctx comes from TryGenerate
+func (n *Node) waitRate(ctx context.Context, count int) error {
+	// Skip the lock on the hot path when there is no limit
+	if !n.rate.enabled.Load() {
+		return nil
+	}
+	delay, changed, err := n.rate.reserve(count, ctx.Value(noWaitKey{}) != nil)
+	if err != nil || delay == 0 {
+		return err
+	}
+	n.instrumentWait(ctx, waitRateLimit, func() { err = n.rate.wait(ctx, delay, changed, count) })
+	return err
+}
+
+// SetRateLimit replaces the node's rate limit, see Config.RateLimit; a
+// non-positive idsPerSecond removes it. The bucket starts full, and callers
+// currently waiting for tokens re-check the new limit immediately.
+func (n *Node) SetRateLimit(idsPerSecond float64, burst int) {
+	n.rate.set(idsPerSecond, burst)
+}
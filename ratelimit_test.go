@@ -0,0 +1,125 @@
+package mkey
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newRateLimitedNode(t *testing.T, rate float64, burst int) *Node {
+	t.Helper()
+	cfg := NewConfig()
+	cfg.RateLimit = rate
+	cfg.RateBurst = burst
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestRateLimitBurst(t *testing.T) {
+	n := newRateLimitedNode(t, 1, 5)
+	for i := range 5 {
+		if _, err := n.TryGenerate(); err != nil {
+			t.Fatalf("TryGenerate %d within the burst: %v", i, err)
+		}
+	}
+	if _, err := n.TryGenerate(); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("TryGenerate beyond the burst = %v, want ErrRateLimited", err)
+	}
+	if _, err := n.GenerateBatch(6); err == nil {
+		t.Fatal("GenerateBatch accepted a batch larger than the burst")
+	}
+}
+
+func TestRateLimitWaits(t *testing.T) {
+	n := newRateLimitedNode(t, 1000, 1)
+	start := time.Now()
+	for range 21 {
+		n.Generate()
+	}
+	if d := time.Since(start); d < 18*time.Millisecond {
+		t.Fatalf("21 IDs at 1000/s with a burst of 1 took %s, want about 20ms", d)
+	}
+}
+
+func TestRateLimitContext(t *testing.T) {
+	n := newRateLimitedNode(t, 1, 1)
+	n.Generate()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := n.GenerateContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("GenerateContext = %v, want context.DeadlineExceeded", err)
+	}
+	// the caller that gave up hands its reservation back rather than
+	// delaying the next one by a whole token
+	n.rate.mu.Lock()
+	tokens := n.rate.tokens
+	n.rate.mu.Unlock()
+	if tokens < -0.5 {
+		t.Fatalf("tokens = %v after a cancelled wait, want the reservation returned", tokens)
+	}
+}
+
+func TestSetRateLimit(t *testing.T) {
+	n := newRateLimitedNode(t, 0.001, 1)
+	n.Generate()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := n.GenerateContext(context.Background())
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	n.SetRateLimit(0, 0)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("waiting GenerateContext after removing the limit: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("removing the limit did not wake the waiter")
+	}
+
+	n.SetRateLimit(1, 2)
+	for i := range 2 {
+		if _, err := n.TryGenerate(); err != nil {
+			t.Fatalf("TryGenerate %d with a fresh bucket: %v", i, err)
+		}
+	}
+	if _, err := n.TryGenerate(); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("TryGenerate beyond the new burst = %v, want ErrRateLimited", err)
+	}
+}
+
+func TestRateLimitClose(t *testing.T) {
+	n := newRateLimitedNode(t, 0.001, 1)
+	n.Generate()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := n.GenerateContext(context.Background())
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	n.Close()
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrClosed) {
+			t.Fatalf("waiting GenerateContext after Close = %v, want ErrClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Close did not wake the waiter")
+	}
+}
+
+func TestRateLimitNegative(t *testing.T) {
+	cfg := NewConfig()
+	cfg.RateLimit = -1
+	if _, err := NewNodeWithConfig(cfg); err == nil {
+		t.Fatal("NewNodeWithConfig accepted a negative RateLimit")
+	}
+}
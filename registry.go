@@ -0,0 +1,87 @@
+package mkey
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ValidClockSkew is how far in the future an ID's timestamp may lie and
+// still be considered valid, allowing for clock differences between hosts
+const ValidClockSkew = time.Minute
+
+var (
+	layoutsMu sync.RWMutex
+	layouts   = map[string]Layout{
+		"mkey":    DefaultLayout(),
+		"twitter": {Epoch: TwitterEpoch, NodeBits: 10, StepBits: 12},
+		"discord": {Epoch: DiscordEpoch, NodeBits: 10, StepBits: 12},
+	}
+)
+
+// RegisterLayout adds a named layout, so IDs can be matched against it with
+// MatchLayouts. Names are case-insensitive and cannot be redefined.
+func RegisterLayout(name string, l Layout) error {
+	key := strings.ToLower(name)
+	if key == "" {
+		return errors.New("layout name must not be empty")
+	}
+	if err := l.Validate(); err != nil {
+		return err
+	}
+
+	layoutsMu.Lock()
+	defer layoutsMu.Unlock()
+	if _, ok := layouts[key]; ok {
+		return fmt.Errorf("layout %q is already registered", name)
+	}
+	layouts[key] = l
+	return nil
+}
+
+// LookupLayout returns the layout registered under name
+func LookupLayout(name string) (Layout, bool) {
+	layoutsMu.RLock()
+	defer layoutsMu.RUnlock()
+	l, ok := layouts[strings.ToLower(name)]
+	return l, ok
+}
+
+// LayoutNames returns the registered layout names in sorted order
+func LayoutNames() []string {
+	layoutsMu.RLock()
+	defer layoutsMu.RUnlock()
+	return slices.Sorted(maps.Keys(layouts))
+}
+
+// Valid reports whether the ID could have been issued under l: it must be
+// positive and its timestamp must not lie more than ValidClockSkew in the
+// future. Every non-negative value decodes to some node and step, so the
+// timestamp is what tells foreign IDs apart.
+func (f ID) Valid(l Layout) bool {
+	if f <= 0 {
+		return false
+	}
+	return l.Time(f) <= time.Now().Add(ValidClockSkew).UnixMilli()
+}
+
+// MatchLayouts returns the names of the registered layouts under which the
+// ID is valid, in sorted order, to triage IDs leaking in from other systems.
+// IDs from layouts with later epochs or fewer timestamp bits tend to match
+// fewer layouts; an empty result means the ID fits none.
+func MatchLayouts(id ID) []string {
+	layoutsMu.RLock()
+	defer layoutsMu.RUnlock()
+
+	var names []string
+	for _, name := range slices.Sorted(maps.Keys(layouts)) {
+		if id.Valid(layouts[name]) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
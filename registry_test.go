@@ -0,0 +1,89 @@
+package mkey
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMatchLayouts(t *testing.T) {
+	now := time.Now()
+	compose := func(name string) ID {
+		l, ok := LookupLayout(name)
+		if !ok {
+			t.Fatalf("layout %q not registered", name)
+		}
+		id, err := l.Compose(now, 1, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+
+	// an ID's offset from its own epoch puts it in the future under any layout
+	// with a later epoch
+	tests := map[string][]string{
+		"twitter": {"twitter"},
+		"discord": {"discord", "twitter"},
+		"mkey":    {"discord", "mkey", "twitter"},
+	}
+	for name, want := range tests {
+		if got := MatchLayouts(compose(name)); !slices.Equal(got, want) {
+			t.Errorf("MatchLayouts(%s ID) = %v, want %v", name, got, want)
+		}
+	}
+	for _, id := range []ID{0, -5} {
+		if got := MatchLayouts(id); len(got) != 0 {
+			t.Errorf("MatchLayouts(%d) = %v", id, got)
+		}
+	}
+}
+
+func TestValid(t *testing.T) {
+	l := DefaultLayout()
+	for d, want := range map[time.Duration]bool{
+		0:                            true,
+		ValidClockSkew - time.Second: true,
+		ValidClockSkew + time.Second: false,
+		-time.Hour:                   true,
+	} {
+		id, _ := l.Compose(time.Now().Add(d), 0, 1)
+		if got := id.Valid(l); got != want {
+			t.Errorf("ID %v from now: Valid = %v, want %v", d, got, want)
+		}
+	}
+}
+
+func TestRegisterLayout(t *testing.T) {
+	const name = "test-layout-501"
+	l := Layout{Epoch: time.Now().Add(-time.Hour).UnixMilli(), NodeBits: 8, StepBits: 14}
+	if err := RegisterLayout("Test-Layout-501", l); err != nil {
+		t.Fatal(err)
+	}
+	// registrations are process-wide, so remove it for repeated runs
+	t.Cleanup(func() {
+		layoutsMu.Lock()
+		defer layoutsMu.Unlock()
+		delete(layouts, name)
+	})
+	if got, ok := LookupLayout(name); !ok || got != l {
+		t.Fatalf("LookupLayout = %+v, %v", got, ok)
+	}
+	if names := LayoutNames(); !slices.Contains(names, name) || !slices.IsSorted(names) {
+		t.Fatalf("LayoutNames() = %v", names)
+	}
+
+	id, _ := l.Compose(time.Now(), 1, 1)
+	if !slices.Contains(MatchLayouts(id), name) {
+		t.Fatal("registered layout not matched")
+	}
+
+	for _, bad := range []string{name, "MKEY", ""} {
+		if err := RegisterLayout(bad, l); err == nil {
+			t.Errorf("RegisterLayout(%q) succeeded", bad)
+		}
+	}
+	if err := RegisterLayout("test-layout-501-bad", Layout{StepBits: 30}); err == nil {
+		t.Error("invalid layout accepted")
+	}
+}
@@ -0,0 +1,34 @@
+// Package echoid adapts requestid to Echo. It is a separate module, so the
+// core mkey module stays free of framework dependencies.
+//
+//	e := echo.New()
+//	e.Use(echoid.Middleware(node, requestid.Config{}))
+package echoid
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"github.com/icehuntmen/mkey"
+	"github.com/icehuntmen/mkey/requestid"
+)
+
+// Middleware returns Echo middleware behaving like requestid.Middleware: it
+// resolves the request ID, sets it on the response header and stores it in
+// the request context, where handlers read it with FromContext
+func Middleware(node *mkey.Node, cfg requestid.Config) echo.MiddlewareFunc {
+	header := cfg.HeaderName()
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			r := c.Request()
+			id := cfg.Resolve(node, r.Header.Get(header))
+			c.Response().Header().Set(header, id.String())
+			c.SetRequest(r.WithContext(mkey.NewContext(r.Context(), id)))
+			return next(c)
+		}
+	}
+}
+
+// FromContext returns the request ID stored by Middleware
+func FromContext(c echo.Context) (mkey.ID, bool) {
+	return mkey.FromContext(c.Request().Context())
+}
@@ -0,0 +1,62 @@
+package echoid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/icehuntmen/mkey"
+	"github.com/icehuntmen/mkey/requestid"
+)
+
+func TestMiddleware(t *testing.T) {
+	node, err := mkey.NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		cfg      requestid.Config
+		incoming string
+		reuse    bool
+	}{
+		{"generated", requestid.Config{}, "", false},
+		{"untrusted incoming", requestid.Config{}, "12345", false},
+		{"trusted incoming", requestid.Config{TrustIncoming: true}, "12345", true},
+		{"trusted invalid", requestid.Config{TrustIncoming: true}, "-5", false},
+		{"custom header", requestid.Config{Header: "X-Trace", TrustIncoming: true}, "777", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen mkey.ID
+			e := echo.New()
+			e.Use(Middleware(node, tt.cfg))
+			e.GET("/", func(c echo.Context) error {
+				id, ok := FromContext(c)
+				if !ok {
+					t.Error("no ID in the request context")
+				}
+				seen = id
+				return c.NoContent(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(tt.cfg.HeaderName(), tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, req)
+
+			got := w.Header().Get(tt.cfg.HeaderName())
+			if got != seen.String() {
+				t.Fatalf("response header %q, handler saw %d", got, seen)
+			}
+			if (got == tt.incoming) != tt.reuse {
+				t.Fatalf("response header %q for incoming %q, want reuse %v", got, tt.incoming, tt.reuse)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+// Package fiberid adapts requestid to Fiber. It is a separate module, so the
+// core mkey module stays free of framework dependencies.
+//
+//	app := fiber.New()
+//	app.Use(fiberid.Middleware(node, requestid.Config{}))
+//
+// Fiber does not run on net/http, so the ID is stored in the user context
+// (Ctx.UserContext), which handlers pass on to downstream calls.
+package fiberid
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/icehuntmen/mkey"
+	"github.com/icehuntmen/mkey/requestid"
+)
+
+// Middleware returns Fiber middleware behaving like requestid.Middleware: it
+// resolves the request ID, sets it on the response header and stores it in
+// the user context, where handlers read it with FromContext
+func Middleware(node *mkey.Node, cfg requestid.Config) fiber.Handler {
+	header := cfg.HeaderName()
+	return func(c *fiber.Ctx) error {
+		id := cfg.Resolve(node, c.Get(header))
+		c.Set(header, id.String())
+		c.SetUserContext(mkey.NewContext(c.UserContext(), id))
+		return c.Next()
+	}
+}
+
+// FromContext returns the request ID stored by Middleware
+func FromContext(c *fiber.Ctx) (mkey.ID, bool) {
+	return mkey.FromContext(c.UserContext())
+}
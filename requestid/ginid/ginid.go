@@ -0,0 +1,31 @@
+// Package ginid adapts requestid to Gin. It is a separate module, so the
+// core mkey module stays free of framework dependencies.
+//
+//	r := gin.New()
+//	r.Use(ginid.Middleware(node, requestid.Config{}))
+package ginid
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/icehuntmen/mkey"
+	"github.com/icehuntmen/mkey/requestid"
+)
+
+// Middleware returns Gin middleware behaving like requestid.Middleware: it
+// resolves the request ID, sets it on the response header and stores it in
+// the request context, where handlers read it with FromContext
+func Middleware(node *mkey.Node, cfg requestid.Config) gin.HandlerFunc {
+	header := cfg.HeaderName()
+	return func(c *gin.Context) {
+		id := cfg.Resolve(node, c.GetHeader(header))
+		c.Header(header, id.String())
+		c.Request = c.Request.WithContext(mkey.NewContext(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// FromContext returns the request ID stored by Middleware
+func FromContext(c *gin.Context) (mkey.ID, bool) {
+	return mkey.FromContext(c.Request.Context())
+}
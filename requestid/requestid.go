@@ -0,0 +1,98 @@
+// Package requestid generates and propagates mkey request IDs over HTTP.
+//
+// Middleware works with net/http and any router built on it, including
+// chi (r.Use(requestid.Middleware(node, requestid.Config{}))). Gin, Echo and
+// Fiber adapters with the same header and context behavior live in the
+// ginid, echoid and fiberid submodules, each its own Go module so this one
+// stays free of framework dependencies. They require a tagged mkey release;
+// to work on them against this checkout, create an uncommitted go.work in
+// this directory:
+//
+//	go work init ./ginid ./echoid ./fiberid
+//	go work edit -replace github.com/icehuntmen/mkey=..
+//
+// Other frameworks need only a few lines around Config.Resolve.
+package requestid
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/icehuntmen/mkey"
+)
+
+// DefaultHeader is the header carrying the request ID
+const DefaultHeader = "X-Request-ID"
+
+// Config controls how request IDs are obtained and propagated
+type Config struct {
+	// Header is the request and response header name (default DefaultHeader)
+	Header string
+
+	// TrustIncoming reuses a valid decimal ID sent by the client or an
+	// upstream proxy instead of generating a new one
+	TrustIncoming bool
+}
+
+// HeaderName returns the configured header name
+func (c Config) HeaderName() string {
+	if c.Header == "" {
+		return DefaultHeader
+	}
+	return c.Header
+}
+
+// Resolve returns the ID for a request given its incoming header value:
+// the incoming ID when trusted and valid, a freshly generated one otherwise
+func (c Config) Resolve(node *mkey.Node, incoming string) mkey.ID {
+	if c.TrustIncoming && incoming != "" {
+		if v, err := strconv.ParseInt(incoming, 10, 64); err == nil && v > 0 {
+			return mkey.ID(v)
+		}
+	}
+	return node.Generate()
+}
+
+// Middleware returns net/http middleware that resolves the request ID, sets
+// it on the response header and stores it in the request context, where
+// handlers read it with FromRequest or mkey.FromContext
+func Middleware(node *mkey.Node, cfg Config) func(http.Handler) http.Handler {
+	header := cfg.HeaderName()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := cfg.Resolve(node, r.Header.Get(header))
+			w.Header().Set(header, id.String())
+			next.ServeHTTP(w, r.WithContext(mkey.NewContext(r.Context(), id)))
+		})
+	}
+}
+
+// FromRequest returns the request ID stored by Middleware
+func FromRequest(r *http.Request) (mkey.ID, bool) {
+	return mkey.FromContext(r.Context())
+}
+
+// Transport is an http.RoundTripper that forwards the request ID from the
+// outgoing request's context to downstream services
+type Transport struct {
+	// Base is the underlying transport (default http.DefaultTransport)
+	Base http.RoundTripper
+
+	// Header is the header name (default DefaultHeader)
+	Header string
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	header := Config{Header: t.Header}.HeaderName()
+
+	if id, ok := mkey.FromContext(r.Context()); ok && r.Header.Get(header) == "" {
+		r = r.Clone(r.Context())
+		r.Header.Set(header, id.String())
+	}
+	return base.RoundTrip(r)
+}
@@ -0,0 +1,99 @@
+package requestid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/icehuntmen/mkey"
+)
+
+func TestResolve(t *testing.T) {
+	node, err := mkey.NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		cfg      Config
+		incoming string
+		want     mkey.ID
+	}{
+		{"untrusted", Config{}, "42", 0},
+		{"trusted", Config{TrustIncoming: true}, "42", 42},
+		{"trusted empty", Config{TrustIncoming: true}, "", 0},
+		{"trusted zero", Config{TrustIncoming: true}, "0", 0},
+		{"trusted negative", Config{TrustIncoming: true}, "-42", 0},
+		{"trusted garbage", Config{TrustIncoming: true}, "4x2", 0},
+	}
+	for _, tt := range tests {
+		id := tt.cfg.Resolve(node, tt.incoming)
+		if tt.want != 0 && id != tt.want {
+			t.Errorf("%s: Resolve = %d, want %d", tt.name, id, tt.want)
+		}
+		if tt.want == 0 && node.Layout().NodeID(id) != 1 {
+			t.Errorf("%s: Resolve = %d, want a generated ID", tt.name, id)
+		}
+	}
+	if h := (Config{}).HeaderName(); h != DefaultHeader {
+		t.Errorf("default header = %q", h)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	node, err := mkey.NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seen mkey.ID
+	h := Middleware(node, Config{Header: "X-Trace", TrustIncoming: true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := FromRequest(r)
+		if !ok {
+			t.Error("no ID in the request context")
+		}
+		seen = id
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Trace", "777")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if seen != 777 || w.Header().Get("X-Trace") != "777" {
+		t.Fatalf("handler saw %d, response header %q; want 777", seen, w.Header().Get("X-Trace"))
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if seen == 777 || w.Header().Get("X-Trace") != seen.String() {
+		t.Fatalf("handler saw %d, response header %q", seen, w.Header().Get("X-Trace"))
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get(DefaultHeader))
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: &Transport{}}
+
+	// The ID in the context is forwarded, an explicit header is kept, and
+	// requests without an ID are left alone
+	req, _ := http.NewRequestWithContext(mkey.NewContext(t.Context(), 99), http.MethodGet, srv.URL, nil)
+	explicit := req.Clone(req.Context())
+	explicit.Header.Set(DefaultHeader, "5")
+	plain, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	for _, r := range []*http.Request{req, explicit, plain} {
+		resp, err := client.Do(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if len(got) != 3 || got[0] != "99" || got[1] != "5" || got[2] != "" {
+		t.Fatalf("downstream headers = %q, want 99, 5 and none", got)
+	}
+	if req.Header.Get(DefaultHeader) != "" {
+		t.Fatal("Transport modified the caller's request")
+	}
+}
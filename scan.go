@@ -0,0 +1,117 @@
+package mkey
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ScanRange is a range of fixed-width encoded keys, Start inclusive and End
+// exclusive, as taken by DynamoDB's BETWEEN-style conditions or Bigtable row ranges
+type ScanRange struct {
+	Start string
+	End   string
+}
+
+// sortableEncoding returns the base, fixed width and fixed-width formatter of
+// an encoding whose output sorts like the IDs
+func sortableEncoding(e Encoding) (base uint64, width int, format func(uint64) string, err error) {
+	switch e {
+	case EncodingHex:
+		return 16, HexWidth, func(v uint64) string {
+			return formatPow2(v, 4, encodeHexMap, FormatOptions{Width: HexWidth}, false)
+		}, nil
+	case EncodingBase62:
+		return 62, Base62Width, func(v uint64) string {
+			return formatBase62(v, Base62Width)
+		}, nil
+	}
+	return 0, 0, nil, fmt.Errorf("encoding %s does not sort; use hex or base62", e)
+}
+
+// scanIntervals returns the inclusive ID intervals whose timestamps fall in
+// [from, to) at millisecond granularity, one per priority value
+func scanIntervals(l Layout, from, to time.Time) ([][2]uint64, error) {
+	if err := l.Validate(); err != nil {
+		return nil, err
+	}
+	if !from.Before(to) {
+		return nil, errors.New("from must be before to")
+	}
+
+	maxTime := l.TimeMask() + 1
+	clamp := func(t time.Time) int64 {
+		return min(max(t.UnixMilli()-l.Epoch, 0), maxTime)
+	}
+	t0, t1 := clamp(from), clamp(to)
+	if t0 == t1 {
+		return nil, nil
+	}
+
+	shift := l.TimeShift()
+	intervals := make([][2]uint64, 0, int(l.MaxPriority())+1)
+	for p := uint64(0); p <= uint64(l.MaxPriority()); p++ {
+		high := p << (63 - l.PriorityBits)
+		intervals = append(intervals, [2]uint64{
+			high | uint64(t0)<<shift,
+			high | (uint64(t1)<<shift - 1),
+		})
+	}
+	return intervals, nil
+}
+
+// ScanRanges returns the key ranges covering every ID created in [from, to)
+// under layout l, with keys in the fixed-width form of e (EncodingHex or
+// EncodingBase62). Layouts with priority bits need one range per priority;
+// otherwise the result is a single range.
+func ScanRanges(l Layout, e Encoding, from, to time.Time) ([]ScanRange, error) {
+	_, _, format, err := sortableEncoding(e)
+	if err != nil {
+		return nil, err
+	}
+	intervals, err := scanIntervals(l, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	ranges := make([]ScanRange, len(intervals))
+	for i, iv := range intervals {
+		ranges[i] = ScanRange{Start: format(iv[0]), End: format(iv[1] + 1)}
+	}
+	return ranges, nil
+}
+
+// ScanPrefixes returns the minimal set of key prefixes covering exactly the
+// IDs created in [from, to) under layout l, for stores that scan by prefix.
+// Keys are in the fixed-width form of e, as for ScanRanges. The number of
+// prefixes grows with the base of the encoding; hex yields the fewest.
+func ScanPrefixes(l Layout, e Encoding, from, to time.Time) ([]string, error) {
+	base, width, format, err := sortableEncoding(e)
+	if err != nil {
+		return nil, err
+	}
+	intervals, err := scanIntervals(l, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var prefixes []string
+	for _, iv := range intervals {
+		lo, hi := iv[0], iv[1]
+		for {
+			// Grow the block while lo stays aligned to it and it fits in [lo, hi]
+			size, digits := uint64(1), 0
+			for digits < width && size <= (hi-lo+1)/base && lo%(size*base) == 0 {
+				size *= base
+				digits++
+			}
+			prefixes = append(prefixes, format(lo)[:width-digits])
+
+			if hi-lo < size {
+				break
+			}
+			lo += size
+		}
+	}
+	return prefixes, nil
+}
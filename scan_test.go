@@ -0,0 +1,103 @@
+package mkey
+
+import (
+	"math/rand/v2"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSortableEncodingOrder(t *testing.T) {
+	for _, e := range []Encoding{EncodingHex, EncodingBase62} {
+		_, width, format, err := sortableEncoding(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for range 10000 {
+			a, b := rand.Uint64(), rand.Uint64()
+			ka, kb := format(a), format(b)
+			if len(ka) != width || len(kb) != width {
+				t.Fatalf("%s: keys %q, %q not %d wide", e, ka, kb, width)
+			}
+			if (a < b) != (ka < kb) {
+				t.Fatalf("%s: %d < %d is %v but %q < %q is %v", e, a, b, a < b, ka, kb, ka < kb)
+			}
+		}
+	}
+}
+
+func TestScanRejectsUnsortedEncodings(t *testing.T) {
+	from := time.Now()
+	for _, e := range []Encoding{EncodingBase32Std, EncodingBase32, EncodingBase58, EncodingBase64, EncodingDecimal} {
+		if _, err := ScanRanges(DefaultLayout(), e, from, from.Add(time.Second)); err == nil {
+			t.Errorf("ScanRanges accepted %s", e)
+		}
+		if _, err := ScanPrefixes(DefaultLayout(), e, from, from.Add(time.Second)); err == nil {
+			t.Errorf("ScanPrefixes accepted %s", e)
+		}
+	}
+}
+
+func TestScanCoversWindow(t *testing.T) {
+	layouts := map[string]func(*Config){
+		"default":  func(*Config) {},
+		"priority": func(c *Config) { c.PriorityBits, c.NodeBits = 2, 8 },
+	}
+	for name, opt := range layouts {
+		for _, e := range []Encoding{EncodingHex, EncodingBase62} {
+			t.Run(name+"/"+e.String(), func(t *testing.T) {
+				cfg := NewConfig()
+				opt(cfg)
+				l := cfg.Layout()
+				_, _, format, err := sortableEncoding(e)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				from := time.UnixMilli(l.Epoch + 1_000_000_007)
+				to := from.Add(1234 * time.Millisecond)
+				ranges, err := ScanRanges(l, e, from, to)
+				if err != nil {
+					t.Fatal(err)
+				}
+				prefixes, err := ScanPrefixes(l, e, from, to)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				check := func(at time.Time, rest int64, priority int64) {
+					t.Helper()
+					id := ID(priority<<(63-l.PriorityBits) |
+						(at.UnixMilli()-l.Epoch)<<l.TimeShift() | rest)
+					key := format(uint64(id))
+					want := !at.Before(from) && at.Before(to)
+
+					var inRange bool
+					for _, r := range ranges {
+						inRange = inRange || r.Start <= key && key < r.End
+					}
+					var inPrefix bool
+					for _, p := range prefixes {
+						inPrefix = inPrefix || strings.HasPrefix(key, p)
+					}
+					if inRange != want || inPrefix != want {
+						t.Fatalf("ID at %v (key %q): in ranges %v, in prefixes %v, want %v",
+							at.UnixMilli(), key, inRange, inPrefix, want)
+					}
+				}
+
+				maxRest := int64(1)<<l.TimeShift() - 1
+				for p := int64(0); p <= int64(l.MaxPriority()); p++ {
+					for _, at := range []time.Time{
+						from.Add(-time.Millisecond), from, from.Add(time.Millisecond),
+						to.Add(-time.Millisecond), to, to.Add(time.Hour),
+					} {
+						check(at, 0, p)
+						check(at, maxRest, p)
+						check(at, rand.Int64N(maxRest+1), p)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package mkey
+
+// lfsrTaps[k] is the feedback mask of a maximal-length Galois LFSR over k
+// bits, whose states run through every non-zero k-bit value before repeating
+var lfsrTaps = [MaxStepBits + 1]uint32{
+	2: 0x3, 3: 0x5, 4: 0x9, 5: 0x12, 6: 0x21, 7: 0x41, 8: 0x8e, 9: 0x108,
+	10: 0x204, 11: 0x402, 12: 0x829, 13: 0x100d, 14: 0x2015, 15: 0x4001, 16: 0x8016,
+}
+
+// stepPermutation returns a permutation of [0, 1<<bits): 0 followed by the
+// states of the maximal-length LFSR seeded with 1
+func stepPermutation(bits uint8) []uint16 {
+	perm := make([]uint16, 1<<bits)
+	if bits == 0 {
+		return perm
+	}
+	s := uint32(1)
+	for i := 1; i < len(perm); i++ {
+		perm[i] = uint16(s)
+		if s&1 != 0 {
+			s = s>>1 ^ lfsrTaps[bits]
+		} else {
+			s >>= 1
+		}
+	}
+	return perm
+}
+
+// stepValue returns the step field for the c-th ID of millisecond ms. With
+// Config.RandomStepStart the counter is offset by a secret per-millisecond
+// value, and with Config.ScrambleSteps mapped through the LFSR permutation,
+// rotated by the millisecond so each one visits the steps in its own order.
+// Both keep the mapping a bijection of the counters of one millisecond.
+func (n *Node) stepValue(ms, c int64) int64 {
+	if n.randomStart {
+		c = (c + int64(ID(ms^n.stepSeed).Hash64())) & n.stepMask
+	}
+	if n.perm != nil {
+		c = int64(n.perm[(c+ms)&n.stepMask])
+	}
+	return c << n.childShift
+}
@@ -0,0 +1,128 @@
+package mkey
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestStepPermutation(t *testing.T) {
+	for bits := uint8(0); bits <= MaxStepBits; bits++ {
+		perm := stepPermutation(bits)
+		if len(perm) != 1<<bits {
+			t.Fatalf("bits %d: len %d, want %d", bits, len(perm), 1<<bits)
+		}
+		if perm[0] != 0 {
+			t.Fatalf("bits %d: perm[0] = %d, want 0", bits, perm[0])
+		}
+		seen := make([]bool, len(perm))
+		for i, v := range perm {
+			if int(v) >= len(perm) || seen[v] {
+				t.Fatalf("bits %d: perm[%d] = %d repeats or is out of range", bits, i, v)
+			}
+			seen[v] = true
+		}
+	}
+}
+
+// scrambleSteps returns the step fields of every ID a node with the given
+// options issues in a single millisecond
+func scrambleSteps(t *testing.T, ms time.Time, scramble, randomStart bool) []int64 {
+	t.Helper()
+	cfg := NewConfig()
+	cfg.Node = 1
+	cfg.StepBits = 6
+	cfg.ScrambleSteps = scramble
+	cfg.RandomStepStart = randomStart
+	cfg.Clock = NewManualClock(ms)
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := cfg.Layout()
+	steps := make([]int64, 0, l.MaxStep()+1)
+	for range l.MaxStep() + 1 {
+		id := n.Generate()
+		if got := l.Time(id); got != ms.UnixMilli() {
+			t.Fatalf("ID of %d left the millisecond %d", got, ms.UnixMilli())
+		}
+		steps = append(steps, l.Step(id))
+	}
+	if _, err := n.TryGenerate(); err != ErrSequenceExhausted {
+		t.Fatalf("TryGenerate after %d IDs = %v, want ErrSequenceExhausted", len(steps), err)
+	}
+	return steps
+}
+
+func TestScrambleSteps(t *testing.T) {
+	ms := time.Now().Truncate(time.Millisecond)
+	steps := scrambleSteps(t, ms, true, false)
+	seen := make(map[int64]bool, len(steps))
+	sequential := true
+	for i, s := range steps {
+		if s < 0 || s >= int64(len(steps)) || seen[s] {
+			t.Fatalf("step %d at %d repeats or is out of range", s, i)
+		}
+		seen[s] = true
+		if i > 0 && s != steps[i-1]+1 {
+			sequential = false
+		}
+	}
+	if sequential {
+		t.Fatalf("scrambled steps are sequential: %v", steps)
+	}
+}
+
+func TestScrambleStepsPerMillisecond(t *testing.T) {
+	ms := time.Now().Truncate(time.Millisecond)
+	a := scrambleSteps(t, ms, true, false)
+	if b := scrambleSteps(t, ms, true, false); !slices.Equal(a, b) {
+		t.Fatalf("same millisecond scrambled differently:\n%v\n%v", a, b)
+	}
+	if b := scrambleSteps(t, ms.Add(time.Millisecond), true, false); slices.Equal(a, b) {
+		t.Fatalf("consecutive milliseconds share the step order %v", a)
+	}
+}
+
+func TestRandomStepStart(t *testing.T) {
+	ms := time.Now().Truncate(time.Millisecond)
+	starts := map[int64]bool{}
+	for i := range 8 {
+		steps := scrambleSteps(t, ms.Add(time.Duration(i)*time.Millisecond), false, true)
+		// the steps are a rotation of the counters, so each follows the
+		// last modulo the step range
+		for j := 1; j < len(steps); j++ {
+			if want := (steps[j-1] + 1) % int64(len(steps)); steps[j] != want {
+				t.Fatalf("step %d follows %d, want %d", steps[j], steps[j-1], want)
+			}
+		}
+		starts[steps[0]] = true
+	}
+	if len(starts) < 2 {
+		t.Fatalf("8 milliseconds all started at step %v", starts)
+	}
+}
+
+func TestRandomStepStartScrambled(t *testing.T) {
+	ms := time.Now().Truncate(time.Millisecond)
+	steps := scrambleSteps(t, ms, true, true)
+	seen := make(map[int64]bool, len(steps))
+	for i, s := range steps {
+		if s < 0 || s >= int64(len(steps)) || seen[s] {
+			t.Fatalf("step %d at %d repeats or is out of range", s, i)
+		}
+		seen[s] = true
+	}
+	// each node draws its own secret, so two nodes order the same
+	// milliseconds differently; a single one matches by chance one time in 64
+	same := true
+	for i := range 4 {
+		at := ms.Add(time.Duration(i) * time.Millisecond)
+		if !slices.Equal(scrambleSteps(t, at, true, true), scrambleSteps(t, at, true, true)) {
+			same = false
+		}
+	}
+	if same {
+		t.Fatal("two nodes issued the same random step order for 4 milliseconds")
+	}
+}
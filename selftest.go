@@ -0,0 +1,124 @@
+package mkey
+
+import (
+	"fmt"
+	"time"
+)
+
+// Durations of the self-test phases
+const (
+	selfTestClockSamples = 200000
+	selfTestThroughput   = 50 * time.Millisecond
+)
+
+// SelfTestReport is the result of SelfTest
+type SelfTestReport struct {
+	Layout Layout
+
+	// ClockResolution is the smallest step observed in the wall clock
+	ClockResolution time.Duration
+
+	// ClockRegressions counts wall-clock readings earlier than the previous one
+	ClockRegressions int
+
+	// Throughput is the measured single-goroutine rate in IDs per millisecond
+	// with the step space out of the way, i.e. what the host's CPU sustains;
+	// StepCapacity is the layout's limit per node and millisecond
+	Throughput   float64
+	StepCapacity int64
+
+	// Exhausted is when the layout's timestamp field runs out
+	Exhausted time.Time
+
+	// Problems lists conditions that make the host or layout unfit for
+	// production; it is empty when all checks pass
+	Problems []string
+}
+
+// OK reports whether no problems were found
+func (r *SelfTestReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// SelfTest validates cfg and checks the host before a service goes live: it
+// measures the clock's resolution and monotonicity, estimates the maximum
+// generation rate and looks for layouts close to exhaustion. It takes about
+// a tenth of a second. The returned error is only set for an invalid config;
+// host problems are reported in SelfTestReport.Problems.
+func SelfTest(cfg *Config) (*SelfTestReport, error) {
+	// The test node must not claim the node ID of the service's real one
+	probe := *cfg
+	probe.Exclusive = false
+	node, err := NewNodeWithConfig(&probe)
+	if err != nil {
+		return nil, err
+	}
+	l := node.Layout()
+	rep := &SelfTestReport{
+		Layout:       l,
+		StepCapacity: l.MaxStep() + 1,
+		Exhausted:    time.UnixMilli(l.Epoch + l.TimeMask()),
+	}
+	now := time.Now()
+
+	rep.ClockResolution, rep.ClockRegressions = measureClock(selfTestClockSamples)
+	if rep.ClockResolution > time.Millisecond {
+		rep.problem("clock resolution is %s, coarser than the 1ms timestamp; IDs will arrive in bursts and waits will last a whole tick", rep.ClockResolution)
+	}
+	if rep.ClockRegressions > 0 {
+		rep.problem("wall clock moved backwards %d times during the test", rep.ClockRegressions)
+	}
+
+	rep.Throughput = measureThroughput(l.Epoch, selfTestThroughput)
+
+	if l.Epoch > now.UnixMilli() {
+		rep.problem("epoch %s is in the future", time.UnixMilli(l.Epoch).UTC().Format(time.RFC3339))
+	}
+	if left := rep.Exhausted.Sub(now); left < 10*365*24*time.Hour {
+		rep.problem("timestamp field is exhausted on %s, in less than 10 years", rep.Exhausted.UTC().Format(time.DateOnly))
+	}
+	return rep, nil
+}
+
+// measureClock samples the wall clock and returns the smallest positive step
+// and how often it went backwards. Monotonic readings are stripped so the
+// wall clock itself is measured.
+func measureClock(samples int) (resolution time.Duration, regressions int) {
+	prev := time.Now().UnixNano()
+	for range samples {
+		cur := time.Now().UnixNano()
+		switch d := time.Duration(cur - prev); {
+		case d < 0:
+			regressions++
+		case d > 0 && (resolution == 0 || d < resolution):
+			resolution = d
+		}
+		prev = cur
+	}
+	return resolution, regressions
+}
+
+// measureThroughput generates IDs for d on a node with the widest step field,
+// so the rate reflects generation cost rather than the layout's step limit.
+// A future epoch is moved to now, as such a node would wait until it passes.
+func measureThroughput(epoch int64, d time.Duration) float64 {
+	epoch = min(epoch, time.Now().UnixMilli())
+	node, err := NewNodeWithConfig(&Config{Epoch: epoch, NodeBits: 22 - MaxStepBits, StepBits: MaxStepBits})
+	if err != nil {
+		return 0
+	}
+
+	generated := 0
+	start := time.Now()
+	for time.Since(start) < d {
+		for range 1024 {
+			node.Generate()
+		}
+		generated += 1024
+	}
+	return float64(generated) / (time.Since(start).Seconds() * 1000)
+}
+
+func (r *SelfTestReport) problem(format string, args ...any) {
+	r.Problems = append(r.Problems, fmt.Sprintf(format, args...))
+}
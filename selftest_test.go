@@ -0,0 +1,74 @@
+package mkey
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSelfTest(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Node = 21
+	cfg.Exclusive = true
+	live, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer live.Close()
+
+	// the probe must not collide with the live node holding the same ID
+	rep, err := SelfTest(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Layout != cfg.Layout() || rep.StepCapacity != 4096 {
+		t.Fatalf("Layout %+v, StepCapacity %d", rep.Layout, rep.StepCapacity)
+	}
+	if want := time.UnixMilli(cfg.Epoch + cfg.Layout().TimeMask()); !rep.Exhausted.Equal(want) {
+		t.Fatalf("Exhausted = %v, want %v", rep.Exhausted, want)
+	}
+	if rep.ClockResolution <= 0 || rep.Throughput <= 0 {
+		t.Fatalf("ClockResolution %v, Throughput %v", rep.ClockResolution, rep.Throughput)
+	}
+	for _, p := range rep.Problems {
+		if strings.Contains(p, "epoch") || strings.Contains(p, "exhausted") {
+			t.Errorf("default layout reported %q", p)
+		}
+	}
+}
+
+func TestSelfTestProblems(t *testing.T) {
+	tests := []struct {
+		name  string
+		epoch time.Time
+		want  string
+	}{
+		{"future epoch", time.Now().Add(time.Hour), "is in the future"},
+		// the 41-bit timestamp lasts about 69.7 years
+		{"old epoch", time.Now().AddDate(-65, 0, 0), "less than 10 years"},
+	}
+	for _, tt := range tests {
+		cfg := NewConfig()
+		cfg.Epoch = tt.epoch.UnixMilli()
+		rep, err := SelfTest(cfg)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if rep.OK() || !strings.Contains(strings.Join(rep.Problems, "\n"), tt.want) {
+			t.Errorf("%s: Problems = %q, want %q", tt.name, rep.Problems, tt.want)
+		}
+	}
+
+	cfg := NewConfig()
+	cfg.StepBits = 30
+	if _, err := SelfTest(cfg); err == nil {
+		t.Fatal("invalid config accepted")
+	}
+}
+
+func TestMeasureClock(t *testing.T) {
+	res, back := measureClock(10000)
+	if res <= 0 || res > time.Second || back < 0 {
+		t.Fatalf("measureClock = %v, %d", res, back)
+	}
+}
@@ -0,0 +1,99 @@
+package mkey
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrReservationDone is returned when a Reservation is used after Commit or Release
+var ErrReservationDone = errors.New("reservation already committed or released")
+
+// Sequence issues gapless, strictly increasing numbers for invoice-style
+// requirements that snowflake IDs cannot satisfy. Every number is persisted
+// in a StateStore before it counts as issued.
+//
+// Unlike Node, a Sequence is a single serialization point and is far slower;
+// the timestamp attached to each number is advisory only.
+type Sequence struct {
+	mu    sync.Mutex
+	store StateStore
+	last  uint64
+}
+
+// Reservation holds the next number of a Sequence until it is committed or
+// released. The Sequence stays locked while a reservation is open.
+type Reservation struct {
+	// Value is the reserved number
+	Value uint64
+
+	// Time is when the reservation was made; advisory only
+	Time time.Time
+
+	s    *Sequence
+	done bool
+}
+
+// NewSequence creates a sequence continuing from the value in store
+func NewSequence(store StateStore) (*Sequence, error) {
+	last, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	return &Sequence{store: store, last: last}, nil
+}
+
+// Last returns the most recently committed number, 0 if none
+func (s *Sequence) Last() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last
+}
+
+// Next reserves and commits the next number in one step.
+// If the store fails the sequence does not advance.
+func (s *Sequence) Next() (uint64, error) {
+	r := s.Reserve()
+	if err := r.Commit(); err != nil {
+		r.Release()
+		return 0, err
+	}
+	return r.Value, nil
+}
+
+// Reserve locks the sequence and returns the next number without consuming it.
+// Use it when the number must be written together with the record it numbers:
+// Commit once the record is stored, or Release to hand the number to the next caller.
+func (s *Sequence) Reserve() *Reservation {
+	s.mu.Lock()
+	return &Reservation{
+		Value: s.last + 1,
+		Time:  time.Now(),
+		s:     s,
+	}
+}
+
+// Commit persists the reserved number and unlocks the sequence.
+// On a store error the reservation stays open and may be retried or released.
+func (r *Reservation) Commit() error {
+	if r.done {
+		return ErrReservationDone
+	}
+	if err := r.s.store.Save(r.Value); err != nil {
+		return err
+	}
+	r.s.last = r.Value
+	r.done = true
+	r.s.mu.Unlock()
+	return nil
+}
+
+// Release gives the reserved number back and unlocks the sequence.
+// It is a no-op after Commit.
+func (r *Reservation) Release() {
+	if r.done {
+		return
+	}
+	r.done = true
+	r.s.mu.Unlock()
+}
@@ -0,0 +1,136 @@
+package mkey
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// failingStore fails Save while fail is set
+type failingStore struct {
+	MemoryStore
+	fail bool
+}
+
+func (f *failingStore) Save(v uint64) error {
+	if f.fail {
+		return errors.New("disk full")
+	}
+	return f.MemoryStore.Save(v)
+}
+
+func TestSequenceGapless(t *testing.T) {
+	s, err := NewSequence(&MemoryStore{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := make(map[uint64]bool)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				v, err := s.Next()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				seen[v] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	for v := uint64(1); v <= 800; v++ {
+		if !seen[v] {
+			t.Fatalf("gap at %d", v)
+		}
+	}
+	if s.Last() != 800 {
+		t.Fatalf("Last = %d, want 800", s.Last())
+	}
+}
+
+func TestSequenceReservation(t *testing.T) {
+	s, err := NewSequence(&MemoryStore{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := s.Reserve()
+	if r.Value != 1 || r.Time.IsZero() {
+		t.Fatalf("reservation = %+v", r)
+	}
+	r.Release()
+	r.Release()
+	if err := r.Commit(); !errors.Is(err, ErrReservationDone) {
+		t.Fatalf("Commit after Release = %v", err)
+	}
+
+	// A released number goes to the next caller
+	r = s.Reserve()
+	if r.Value != 1 {
+		t.Fatalf("after release got %d, want 1", r.Value)
+	}
+	if err := r.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Commit(); !errors.Is(err, ErrReservationDone) {
+		t.Fatalf("second Commit = %v", err)
+	}
+	r.Release()
+	if v, _ := s.Next(); v != 2 {
+		t.Fatalf("Next = %d, want 2", v)
+	}
+}
+
+func TestSequenceStoreFailure(t *testing.T) {
+	store := &failingStore{}
+	s, err := NewSequence(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Next()
+
+	store.fail = true
+	if _, err := s.Next(); err == nil {
+		t.Fatal("Next succeeded with a failing store")
+	}
+	r := s.Reserve()
+	if err := r.Commit(); err == nil {
+		t.Fatal("Commit succeeded with a failing store")
+	}
+	// The reservation stays open for a retry
+	store.fail = false
+	if err := r.Commit(); err != nil || r.Value != 2 {
+		t.Fatalf("retried Commit = %v for %d, want 2", err, r.Value)
+	}
+}
+
+func TestSequenceFileStoreResumes(t *testing.T) {
+	store := &FileStore{Path: filepath.Join(t.TempDir(), "seq")}
+	if v, err := store.Load(); err != nil || v != 0 {
+		t.Fatalf("Load of a missing file = %d, %v", v, err)
+	}
+	s, err := NewSequence(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		if _, err := s.Next(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err = NewSequence(&FileStore{Path: store.Path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := s.Next(); err != nil || v != 4 {
+		t.Fatalf("resumed Next = %d, %v, want 4", v, err)
+	}
+}
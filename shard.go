@@ -0,0 +1,76 @@
+package mkey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ShardMask returns the mask of the shard component, before shifting
+func (l Layout) ShardMask() int64 {
+	return -1 ^ (-1 << l.ShardBits)
+}
+
+// MaxShard returns the largest shard the layout can encode
+func (l Layout) MaxShard() int64 {
+	return l.ShardMask()
+}
+
+// shardShift returns the bit position of the shard component
+func (l Layout) shardShift() uint8 {
+	return l.TimeShift() - l.ShardBits
+}
+
+// Shard returns the shard component of id
+func (l Layout) Shard(id ID) int64 {
+	return (int64(id) >> l.shardShift()) & l.ShardMask()
+}
+
+// GenerateBatchSharded issues counts[s] IDs carrying shard s for every entry
+// of counts, in one locked pass, so bulk writers that pre-partition rows can
+// route each ID without decoding it. All IDs share one millisecond; shards
+// are issued in ascending order, so the IDs of a smaller shard value get the
+// lower steps. The total count must not exceed the layout's MaxStep.
+func (n *Node) GenerateBatchSharded(counts map[int64]int) (map[int64][]ID, error) {
+	if n.layout.ShardBits == 0 {
+		return nil, errors.New("layout has no shard bits")
+	}
+
+	shards := make([]int64, 0, len(counts))
+	total := 0
+	for s, c := range counts {
+		if s < 0 || s > n.layout.MaxShard() {
+			return nil, fmt.Errorf("shard must be between 0 and %d", n.layout.MaxShard())
+		}
+		if c <= 0 {
+			return nil, errors.New("count must be positive")
+		}
+		total += c
+		if total > int(n.stepLimit) {
+			return nil, fmt.Errorf("total count must be <= %d", n.stepLimit)
+		}
+		shards = append(shards, s)
+	}
+	if total == 0 {
+		return nil, errors.New("count must be positive")
+	}
+	slices.Sort(shards)
+
+	fields := make([]int64, 0, total)
+	for _, s := range shards {
+		for range counts[s] {
+			fields = append(fields, s<<n.layout.shardShift())
+		}
+	}
+	ids := make([]ID, total)
+	if err := n.generateBatch(context.Background(), ids, fields); err != nil {
+		return nil, err
+	}
+
+	out := make(map[int64][]ID, len(shards))
+	for _, s := range shards {
+		out[s], ids = ids[:counts[s]:counts[s]], ids[counts[s]:]
+	}
+	return out, nil
+}
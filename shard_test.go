@@ -0,0 +1,82 @@
+package mkey
+
+import (
+	"testing"
+	"time"
+)
+
+func shardedNode(t *testing.T) *Node {
+	t.Helper()
+	cfg := NewConfig()
+	cfg.Node = 9
+	cfg.StepBits = 8
+	cfg.ShardBits = 4
+	cfg.Clock = NewManualClock(time.Now())
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestGenerateBatchSharded(t *testing.T) {
+	n := shardedNode(t)
+	l := n.Layout()
+	counts := map[int64]int{7: 3, 0: 2, 15: 1}
+	got, err := n.GenerateBatchSharded(counts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[ID]bool)
+	var ms int64 = -1
+	var lastStep int64 = -1
+	for _, s := range []int64{0, 7, 15} {
+		if len(got[s]) != counts[s] {
+			t.Fatalf("shard %d got %d IDs, want %d", s, len(got[s]), counts[s])
+		}
+		for _, id := range got[s] {
+			if l.Shard(id) != s || l.NodeID(id) != 9 {
+				t.Fatalf("ID %v: shard %d node %d", id, l.Shard(id), l.NodeID(id))
+			}
+			if ms >= 0 && l.Time(id) != ms {
+				t.Fatal("IDs span more than one millisecond")
+			}
+			ms = l.Time(id)
+			// ascending shards take ascending steps
+			if l.Step(id) <= lastStep {
+				t.Fatalf("step %d of shard %d does not follow %d", l.Step(id), s, lastStep)
+			}
+			lastStep = l.Step(id)
+			seen[id] = true
+		}
+	}
+	if len(seen) != 6 {
+		t.Fatalf("%d distinct IDs, want 6", len(seen))
+	}
+
+	// plain IDs carry shard 0 and continue after the batch
+	if next := n.Generate(); l.Shard(next) != 0 || next <= got[0][1] {
+		t.Fatalf("plain ID %v after the batch", next)
+	}
+}
+
+func TestGenerateBatchShardedInvalid(t *testing.T) {
+	n := shardedNode(t)
+	for name, counts := range map[string]map[int64]int{
+		"empty":      {},
+		"zero count": {1: 0},
+		"shard":      {16: 1},
+		"negative":   {-1: 1},
+		"too many":   {1: 200, 2: 100},
+	} {
+		if _, err := n.GenerateBatchSharded(counts); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+
+	plain, _ := NewNode(1)
+	if _, err := plain.GenerateBatchSharded(map[int64]int{0: 1}); err == nil {
+		t.Error("layout without shard bits accepted")
+	}
+}
@@ -0,0 +1,175 @@
+package mkey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/bits"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// ShardRouting selects how a ShardedNode assigns calls to its shards
+type ShardRouting uint8
+
+const (
+	// RouteRoundRobin hands out the shards in turn
+	RouteRoundRobin ShardRouting = iota
+
+	// RouteProcessor keeps a shard cached per processor (P), so callers
+	// running on different CPUs rarely contend on the same lock
+	RouteProcessor
+)
+
+// ShardedNode splits the step space of one node ID across several internal
+// generators, each with its own lock, so generation scales with cores.
+// Shard i issues the steps whose top bits equal i; its IDs decode under
+// Layout to the configured node ID like those of a plain Node.
+//
+// IDs are unique and ordered by millisecond, but within a millisecond the
+// order follows the shard rather than the call order, and each shard only
+// has its share of the step space per millisecond.
+type ShardedNode struct {
+	layout  Layout
+	node    int64
+	shards  []*Node
+	routing ShardRouting
+
+	next  atomic.Uint32
+	procs sync.Pool
+
+	// exclusive is the Config.Exclusive registration of the node as a whole
+	exclusive *exclusiveToken
+	closeOnce sync.Once
+}
+
+// NewShardedNode creates a ShardedNode with the given number of shards, which
+// must be a power of two no larger than the step capacity
+func NewShardedNode(cfg *Config, shards int, routing ShardRouting) (*ShardedNode, error) {
+	if shards <= 0 || shards&(shards-1) != 0 {
+		return nil, errors.New("shards must be a positive power of two")
+	}
+	if routing > RouteProcessor {
+		return nil, fmt.Errorf("unknown shard routing %d", routing)
+	}
+	// Validate the configuration as a whole; Config.Exclusive registers the
+	// ShardedNode once under it, not the shards under their widened layout
+	check := *cfg
+	check.Exclusive = false
+	if _, err := NewNodeWithConfig(&check); err != nil {
+		return nil, err
+	}
+
+	k := uint8(bits.TrailingZeros(uint(shards)))
+	if k > cfg.StepBits {
+		return nil, fmt.Errorf("shards must be <= %d for StepBits %d", 1<<cfg.StepBits, cfg.StepBits)
+	}
+	if cfg.NodeBits+k > MaxNodeBits {
+		return nil, fmt.Errorf("shards must be <= %d for NodeBits %d", 1<<(MaxNodeBits-cfg.NodeBits), cfg.NodeBits)
+	}
+
+	s := &ShardedNode{
+		layout:  cfg.Layout(),
+		node:    cfg.Node,
+		shards:  make([]*Node, shards),
+		routing: routing,
+	}
+
+	// Moving k bits from the step to the node field leaves every other bit
+	// in place, so shard i decodes as step prefix i under the parent layout
+	inner := *cfg
+	inner.NodeBits += k
+	inner.StepBits -= k
+	inner.ReservedSteps >>= k
+	inner.Strict = false
+	inner.Exclusive = false
+	for i := range s.shards {
+		inner.Node = cfg.Node<<k | int64(i)
+		n, err := NewNodeWithConfig(&inner)
+		if err != nil {
+			return nil, err
+		}
+		// All shards lag the clock like the configured node would
+		n.jitter = jitterLag(cfg.Node, cfg.Jitter)
+		// Config.RateLimit applies to the node as a whole, not per shard
+		if i > 0 {
+			n.rate = s.shards[0].rate
+		}
+		s.shards[i] = n
+	}
+	s.procs.New = func() any {
+		return s.nextShard()
+	}
+	if cfg.Exclusive {
+		tok, err := claimExclusive(s.layout, s.node, cfg.OnDuplicate)
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		if tok != nil {
+			s.exclusive = tok
+			key := exclusiveKey{fingerprint: s.layout.Fingerprint(), node: s.node}
+			runtime.AddCleanup(s, func(tok *exclusiveToken) { releaseExclusive(key, tok) }, tok)
+		}
+	}
+	return s, nil
+}
+
+func (s *ShardedNode) nextShard() *Node {
+	return s.shards[int(s.next.Add(1)-1)%len(s.shards)]
+}
+
+// shard picks the shard for the next call
+func (s *ShardedNode) shard() *Node {
+	if s.routing == RouteRoundRobin {
+		return s.nextShard()
+	}
+	n := s.procs.Get().(*Node)
+	s.procs.Put(n)
+	return n
+}
+
+// Generate creates and returns a unique snowflake ID
+func (s *ShardedNode) Generate() ID {
+	return s.shard().Generate()
+}
+
+// GenerateContext is like Generate but gives up with ctx.Err() if ctx is
+// done while waiting for the next millisecond or a maintenance window
+func (s *ShardedNode) GenerateContext(ctx context.Context) (ID, error) {
+	return s.shard().GenerateContext(ctx)
+}
+
+// Layout returns the layout the IDs decode under
+func (s *ShardedNode) Layout() Layout {
+	return s.layout
+}
+
+// Stats returns the counters of all shards combined. Latency is not
+// combined and left nil.
+func (s *ShardedNode) Stats() Stats {
+	total := Stats{Node: s.node}
+	for _, n := range s.shards {
+		st := n.Stats()
+		total.Labels = st.Labels
+		total.Generated += st.Generated
+		total.Waits += st.Waits
+		total.Borrowed += st.Borrowed
+	}
+	return total
+}
+
+// Close closes every shard and releases the Config.Exclusive registration,
+// see Node.Close
+func (s *ShardedNode) Close() error {
+	for _, n := range s.shards {
+		n.Close()
+	}
+	s.closeOnce.Do(func() {
+		if s.exclusive != nil {
+			releaseExclusive(exclusiveKey{fingerprint: s.layout.Fingerprint(), node: s.node}, s.exclusive)
+		}
+	})
+	return nil
+}
@@ -0,0 +1,123 @@
+package mkey
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestShardedNodeIDs(t *testing.T) {
+	for _, routing := range []ShardRouting{RouteRoundRobin, RouteProcessor} {
+		cfg := NewConfig()
+		cfg.Node = 9
+		s, err := NewShardedNode(cfg, 4, routing)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var mu sync.Mutex
+		seen := make(map[ID]bool)
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 2000 {
+					id := s.Generate()
+					mu.Lock()
+					if seen[id] {
+						t.Errorf("routing %d: duplicate %d", routing, id)
+					}
+					seen[id] = true
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		for id := range seen {
+			if got := s.Layout().NodeID(id); got != 9 {
+				t.Fatalf("routing %d: %d decodes to node %d, want 9", routing, id, got)
+			}
+		}
+		if st := s.Stats(); st.Generated != 16000 || st.Node != 9 {
+			t.Fatalf("routing %d: Stats = %+v", routing, st)
+		}
+		s.Close()
+	}
+}
+
+func TestShardedNodeRejects(t *testing.T) {
+	cfg := NewConfig()
+	for _, shards := range []int{0, 3, -2, 1 << 13} {
+		if _, err := NewShardedNode(cfg, shards, RouteRoundRobin); err == nil {
+			t.Errorf("accepted %d shards", shards)
+		}
+	}
+	if _, err := NewShardedNode(cfg, 2, RouteProcessor+1); err == nil {
+		t.Error("accepted an unknown routing")
+	}
+}
+
+func TestShardedNodeExclusive(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Node = 613
+	cfg.Exclusive = true
+
+	s, err := NewShardedNode(cfg, 4, RouteRoundRobin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The ShardedNode holds cfg.Node under the configured layout
+	if _, err := NewNodeWithConfig(cfg); !errors.Is(err, ErrNodeInUse) {
+		t.Fatalf("plain node beside sharded node: got %v, want ErrNodeInUse", err)
+	}
+	if _, err := NewShardedNode(cfg, 2, RouteRoundRobin); !errors.Is(err, ErrNodeInUse) {
+		t.Fatalf("second sharded node: got %v, want ErrNodeInUse", err)
+	}
+
+	// The shards' widened layout is not registered, so a node that happens
+	// to match one shard's node ID and layout is not mistaken for a conflict
+	inner := *cfg
+	inner.NodeBits += 2
+	inner.StepBits -= 2
+	inner.Node = cfg.Node << 2
+	if n, err := NewNodeWithConfig(&inner); err != nil {
+		t.Fatalf("node under the shard layout: %v", err)
+	} else {
+		n.Close()
+	}
+
+	s.Close()
+	s.Close()
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("plain node after ShardedNode.Close: %v", err)
+	}
+	if _, err := NewShardedNode(cfg, 4, RouteRoundRobin); !errors.Is(err, ErrNodeInUse) {
+		t.Fatalf("sharded node beside plain node: got %v, want ErrNodeInUse", err)
+	}
+	n.Close()
+}
+
+func TestShardedNodeOnDuplicate(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Node = 614
+	cfg.Exclusive = true
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer n.Close()
+
+	var reported []int64
+	cfg.OnDuplicate = func(node int64, _ Layout) { reported = append(reported, node) }
+	s, err := NewShardedNode(cfg, 4, RouteRoundRobin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if len(reported) != 1 || reported[0] != 614 {
+		t.Fatalf("OnDuplicate reports = %v, want one for node 614", reported)
+	}
+}
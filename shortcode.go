@@ -0,0 +1,168 @@
+package mkey
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrShortCodeExhausted is returned once a ShortCoder's time field has run out;
+// issuing further codes could repeat earlier ones
+var ErrShortCodeExhausted = errors.New("short code time space exhausted")
+
+// shortCodeBits is the number of bits that fit in 6, 7 and 8 Base62 characters
+var shortCodeBits = map[int]uint8{6: 35, 7: 41, 8: 47}
+
+// ShortCodeConfig configures a ShortCoder
+type ShortCodeConfig struct {
+	// Length is the code length, 6 to 8 characters (default 8)
+	Length int
+
+	// Epoch is the start of the first time bucket in milliseconds since the Unix epoch
+	Epoch int64
+
+	// Bucket is the width of a time bucket (default one second)
+	Bucket time.Duration
+
+	// NodeBits and CounterBits size the node and per-bucket counter fields
+	// (defaults 4 and 12); the remaining bits count buckets
+	NodeBits    uint8
+	CounterBits uint8
+	Node        int64
+}
+
+// ShortCoder generates short fixed-length Base62 codes, e.g. for URL
+// shorteners, from a compacted (time bucket, node, counter) layout. Codes
+// never repeat for a given node until the time field is exhausted, at which
+// point Generate fails instead of wrapping around.
+type ShortCoder struct {
+	mu      sync.Mutex
+	bucket  int64
+	counter int64
+
+	length     int
+	epoch      int64
+	bucketMs   int64
+	maxBucket  int64
+	counterMax int64
+	node       int64
+	nodeShift  uint8
+	timeShift  uint8
+}
+
+// NewShortCoder creates a ShortCoder, applying defaults for zero fields
+func NewShortCoder(cfg ShortCodeConfig) (*ShortCoder, error) {
+	if cfg.Length == 0 {
+		cfg.Length = 8
+	}
+	if cfg.Bucket == 0 {
+		cfg.Bucket = time.Second
+	}
+	if cfg.NodeBits == 0 && cfg.CounterBits == 0 {
+		cfg.NodeBits, cfg.CounterBits = 4, 12
+	}
+
+	total, ok := shortCodeBits[cfg.Length]
+	if !ok {
+		return nil, errors.New("Length must be between 6 and 8")
+	}
+	if cfg.Bucket < time.Millisecond {
+		return nil, errors.New("Bucket must be at least one millisecond")
+	}
+	if cfg.NodeBits+cfg.CounterBits >= total {
+		return nil, fmt.Errorf("NodeBits + CounterBits must be < %d for length %d", total, cfg.Length)
+	}
+	nodeMax := int64(-1 ^ (-1 << cfg.NodeBits))
+	if cfg.Node < 0 || cfg.Node > nodeMax {
+		return nil, fmt.Errorf("Node must be between 0 and %d", nodeMax)
+	}
+
+	timeShift := cfg.NodeBits + cfg.CounterBits
+	return &ShortCoder{
+		bucket:     -1,
+		length:     cfg.Length,
+		epoch:      cfg.Epoch,
+		bucketMs:   cfg.Bucket.Milliseconds(),
+		maxBucket:  -1 ^ (-1 << (total - timeShift)),
+		counterMax: -1 ^ (-1 << cfg.CounterBits),
+		node:       cfg.Node,
+		nodeShift:  cfg.CounterBits,
+		timeShift:  timeShift,
+	}, nil
+}
+
+// Generate returns the next code. When the counter space of the current
+// bucket is used up it waits for the next bucket.
+func (s *ShortCoder) Generate() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.currentBucket()
+	if now <= s.bucket {
+		s.counter = (s.counter + 1) & s.counterMax
+		if s.counter == 0 {
+			for now <= s.bucket {
+				time.Sleep(time.Duration(s.bucketMs) * time.Millisecond / 10)
+				now = s.currentBucket()
+			}
+		} else {
+			now = s.bucket
+		}
+	} else {
+		s.counter = 0
+	}
+
+	if now < 0 {
+		return "", errors.New("current time is before the epoch")
+	}
+	if now > s.maxBucket {
+		return "", ErrShortCodeExhausted
+	}
+	s.bucket = now
+
+	v := uint64(now<<s.timeShift | s.node<<s.nodeShift | s.counter)
+	return formatBase62(v, s.length), nil
+}
+
+// Exhausted returns when the coder's time field runs out
+func (s *ShortCoder) Exhausted() time.Time {
+	return time.UnixMilli(s.epoch + (s.maxBucket+1)*s.bucketMs)
+}
+
+// ShortCodeInfo holds the fields decoded from a short code
+type ShortCodeInfo struct {
+	Bucket  time.Time
+	Node    int64
+	Counter int64
+}
+
+// Decode splits a code produced by this coder's layout into its fields
+func (s *ShortCoder) Decode(code string) (ShortCodeInfo, error) {
+	if len(code) != s.length {
+		return ShortCodeInfo{}, fmt.Errorf("code must be %d characters", s.length)
+	}
+	v, err := parseBase62([]byte(code))
+	if err != nil {
+		return ShortCodeInfo{}, err
+	}
+	if v > math.MaxInt64 {
+		return ShortCodeInfo{}, errors.New("code out of range")
+	}
+
+	n := int64(v)
+	return ShortCodeInfo{
+		Bucket:  time.UnixMilli(s.epoch + (n>>s.timeShift)*s.bucketMs),
+		Node:    n >> s.nodeShift & (-1 ^ (-1 << (s.timeShift - s.nodeShift))),
+		Counter: n & s.counterMax,
+	}, nil
+}
+
+func (s *ShortCoder) currentBucket() int64 {
+	ms := time.Now().UnixMilli() - s.epoch
+	if ms < 0 {
+		return -1
+	}
+	return ms / s.bucketMs
+}
@@ -0,0 +1,102 @@
+package mkey
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestShortCoderUnique(t *testing.T) {
+	for _, length := range []int{6, 7, 8} {
+		s, err := NewShortCoder(ShortCodeConfig{
+			Length:      length,
+			Epoch:       time.Now().Add(-time.Hour).UnixMilli(),
+			Bucket:      time.Millisecond,
+			NodeBits:    2,
+			CounterBits: 3,
+			Node:        2,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 8 codes per bucket forces Generate to wait for later buckets
+		seen := make(map[string]bool)
+		for range 50 {
+			c, err := s.Generate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(c) != length {
+				t.Fatalf("code %q has length %d, want %d", c, len(c), length)
+			}
+			if seen[c] {
+				t.Fatalf("length %d: duplicate code %q", length, c)
+			}
+			seen[c] = true
+
+			info, err := s.Decode(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Node != 2 || info.Counter > 7 {
+				t.Fatalf("Decode(%q) = %+v", c, info)
+			}
+			if d := time.Since(info.Bucket); d < 0 || d > time.Minute {
+				t.Fatalf("Decode(%q).Bucket = %v, too far from now", c, info.Bucket)
+			}
+		}
+	}
+}
+
+func TestShortCoderExhausted(t *testing.T) {
+	// one bucket bit: buckets 0 and 1 are usable, the current one is not
+	s, err := NewShortCoder(ShortCodeConfig{
+		Length:      6,
+		Epoch:       time.Now().Add(-time.Hour).UnixMilli(),
+		Bucket:      time.Millisecond,
+		NodeBits:    4,
+		CounterBits: 30,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Generate(); !errors.Is(err, ErrShortCodeExhausted) {
+		t.Fatalf("Generate: err = %v, want ErrShortCodeExhausted", err)
+	}
+	if !s.Exhausted().Before(time.Now()) {
+		t.Fatalf("Exhausted() = %v, want the past", s.Exhausted())
+	}
+
+	future, err := NewShortCoder(ShortCodeConfig{Epoch: time.Now().Add(time.Hour).UnixMilli()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := future.Generate(); err == nil {
+		t.Fatal("Generate before the epoch succeeded")
+	}
+}
+
+func TestShortCoderConfig(t *testing.T) {
+	for name, cfg := range map[string]ShortCodeConfig{
+		"length 5":  {Length: 5},
+		"length 9":  {Length: 9},
+		"bucket":    {Bucket: time.Microsecond},
+		"no time":   {Length: 6, NodeBits: 5, CounterBits: 30},
+		"node":      {Node: 16},
+		"neg. node": {Node: -1},
+	} {
+		if _, err := NewShortCoder(cfg); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+
+	s, err := NewShortCoder(ShortCodeConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, bad := range []string{"abc", "abcdefg!", "zzzzzzzzz"} {
+		if _, err := s.Decode(bad); err == nil {
+			t.Errorf("Decode(%q) succeeded", bad)
+		}
+	}
+}
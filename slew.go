@@ -0,0 +1,61 @@
+package mkey
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrClockMovedBack is returned with Config.DetectClockBack when the clock
+// reads earlier than the last issued timestamp by more than the tolerance
+var ErrClockMovedBack = errors.New("clock moved backwards")
+
+// slew returns the millisecond to issue in given the real clock reading now.
+// After a backwards correction within SlewLimit it keeps the logical clock
+// at the last millisecond issued, so IDs stay ordered and unique while the
+// real clock catches up. Callers hold n.mu.
+func (n *Node) slew(now int64) int64 {
+	if n.slewMs > 0 && now < n.time && n.time-now <= n.slewMs {
+		return n.time
+	}
+	return now
+}
+
+// slewNext reports whether the logical clock may advance past n.time while
+// the real clock, at now, is still behind it. It only does so within
+// SlewLimit, so exhausting the step space while slewing does not block for
+// the whole correction. Callers hold n.mu.
+func (n *Node) slewNext(now int64) (int64, bool) {
+	if n.slewMs > 0 && now < n.time && n.time+1-now <= n.slewMs {
+		return n.time + 1, true
+	}
+	return 0, false
+}
+
+// borrowNext returns the millisecond after n.time, without waiting, while it
+// lies within Config.BorrowLimit ahead of the real clock reading now. The
+// Observe floor is raised along with it, so later calls keep issuing from
+// the borrowed millisecond until the clock catches up. Callers hold n.mu.
+func (n *Node) borrowNext(now int64) (int64, bool) {
+	if n.borrowMs <= 0 || n.time+1-now > n.borrowMs {
+		return 0, false
+	}
+	n.floor.Store(max(n.floor.Load(), n.time+1))
+	n.borrowed++
+	if n.hook != nil {
+		n.hook.OnClockEvent(ClockEvent{Node: n.node, Kind: ClockBorrow, Delta: time.Duration(n.time+1-now) * time.Millisecond})
+	}
+	return n.time + 1, true
+}
+
+// checkClockBack applies Config.DetectClockBack to the millisecond now.
+// Callers hold n.mu.
+func (n *Node) checkClockBack(now int64) (int64, error) {
+	if !n.detectBack || now >= n.time {
+		return now, nil
+	}
+	if back := n.time - now; back > n.backTolMs {
+		return 0, fmt.Errorf("%w by %dms", ErrClockMovedBack, back)
+	}
+	return n.time, nil
+}
@@ -0,0 +1,174 @@
+package mkey
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newSlewNode(t *testing.T, limit time.Duration) (*Node, *ManualClock) {
+	t.Helper()
+	clock := NewManualClock(time.Now())
+	cfg := NewConfig()
+	cfg.Node = 1
+	cfg.StepBits = 1
+	cfg.Clock = clock
+	cfg.SlewLimit = limit
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n, clock
+}
+
+func TestSlewAbsorbsCorrection(t *testing.T) {
+	n, clock := newSlewNode(t, 10*time.Millisecond)
+	l := n.Layout()
+	start := clock.Now()
+	last := n.Generate()
+
+	clock.Set(start.Add(-5 * time.Millisecond))
+
+	// one more ID fits in the current millisecond and five more milliseconds
+	// fit within the limit, two IDs each, all without waiting
+	for i := range 11 {
+		id := n.Generate()
+		if id <= last {
+			t.Fatalf("ID %d during slew %v does not follow %v", i, id, last)
+		}
+		last = id
+	}
+	if ahead := l.Time(last) - clock.Now().UnixMilli(); ahead != 10 {
+		t.Fatalf("logical clock %dms ahead of real time, want 10", ahead)
+	}
+
+	// the next ID would run more than SlewLimit ahead, so it waits for the clock
+	done := make(chan ID)
+	go func() { done <- n.Generate() }()
+	select {
+	case id := <-done:
+		t.Fatalf("Generate ran past SlewLimit with %v", id)
+	case <-time.After(20 * time.Millisecond):
+	}
+	clock.Advance(time.Millisecond)
+	select {
+	case id := <-done:
+		if id <= last {
+			t.Fatalf("ID %v after the clock advanced does not follow %v", id, last)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Generate did not resume")
+	}
+}
+
+func TestSlewBeyondLimit(t *testing.T) {
+	n, clock := newSlewNode(t, 10*time.Millisecond)
+	l := n.Layout()
+	start := clock.Now()
+	a := n.Generate()
+
+	// corrections larger than the limit are not slewed
+	clock.Set(start.Add(-50 * time.Millisecond))
+	if d := l.Time(a) - l.Time(n.Generate()); d != 50 {
+		t.Fatalf("ID after a 50ms correction is %dms older, want 50", d)
+	}
+}
+
+func TestDetectClockBack(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	cfg := NewConfig()
+	cfg.Node = 1
+	cfg.Clock = clock
+	cfg.DetectClockBack = true
+	cfg.ClockBackTolerance = 10 * time.Millisecond
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := clock.Now()
+	last := n.Generate()
+
+	// within the tolerance the node keeps issuing in the last millisecond
+	clock.Set(start.Add(-5 * time.Millisecond))
+	id, err := n.GenerateContext(t.Context())
+	if err != nil {
+		t.Fatalf("regression within tolerance: %v", err)
+	}
+	if id <= last || n.Layout().Time(id) != n.Layout().Time(last) {
+		t.Fatalf("ID %v within tolerance does not continue %v", id, last)
+	}
+	last = id
+
+	clock.Set(start.Add(-50 * time.Millisecond))
+	if _, err := n.GenerateContext(t.Context()); !errors.Is(err, ErrClockMovedBack) || !strings.Contains(err.Error(), "50ms") {
+		t.Fatalf("err = %v, want ErrClockMovedBack by 50ms", err)
+	}
+
+	// Generate cannot return the error, so it waits for the clock to recover
+	done := make(chan ID)
+	go func() { done <- n.Generate() }()
+	select {
+	case id := <-done:
+		t.Fatalf("Generate returned %v while the clock was back", id)
+	case <-time.After(20 * time.Millisecond):
+	}
+	clock.Set(start.Add(time.Millisecond))
+	select {
+	case id := <-done:
+		if id <= last {
+			t.Fatalf("ID %v after recovery does not follow %v", id, last)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Generate did not resume after the clock recovered")
+	}
+}
+
+func TestBorrowLimit(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	cfg := NewConfig()
+	cfg.Node = 1
+	cfg.StepBits = 1
+	cfg.Clock = clock
+	cfg.BorrowLimit = 3 * time.Millisecond
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := cfg.Layout()
+	now := clock.Now().UnixMilli()
+
+	// two steps in each of the current millisecond and the three borrowed
+	// ones, all without the clock moving
+	var last ID
+	for i := range 8 {
+		id, err := n.TryGenerate()
+		if err != nil {
+			t.Fatalf("TryGenerate %d within the borrow limit: %v", i, err)
+		}
+		if id <= last {
+			t.Fatalf("ID %d not above %d", id, last)
+		}
+		if got, want := l.Time(id), now+int64(i/2); got != want {
+			t.Fatalf("ID %d has time %d, want %d", i, got, want)
+		}
+		last = id
+	}
+	if _, err := n.TryGenerate(); !errors.Is(err, ErrSequenceExhausted) {
+		t.Fatalf("TryGenerate beyond the borrow limit = %v, want ErrSequenceExhausted", err)
+	}
+	if s := n.Stats(); s.Borrowed != 3 || s.Waits != 0 {
+		t.Fatalf("Stats = %+v, want 3 borrowed and no waits", s)
+	}
+
+	// once the clock moves on, the node keeps borrowing from where it was
+	// rather than going back to the clock
+	clock.Advance(time.Millisecond)
+	id, err := n.TryGenerate()
+	if err != nil {
+		t.Fatalf("TryGenerate after the clock moved: %v", err)
+	}
+	if got, want := l.Time(id), now+4; got != want {
+		t.Fatalf("ID after the clock moved has time %d, want %d", got, want)
+	}
+}
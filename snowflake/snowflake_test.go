@@ -0,0 +1,138 @@
+package snowflake
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestGenerateFields(t *testing.T) {
+	node, err := NewNode(511)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := node.Generate()
+	for range 10000 {
+		id := node.Generate()
+		if id <= prev {
+			t.Fatalf("%d not above %d", id, prev)
+		}
+		prev = id
+	}
+	if prev.Node() != 511 {
+		t.Fatalf("Node() = %d, want 511", prev.Node())
+	}
+	if d := time.Now().UnixMilli() - prev.Time(); d < 0 || d > 1000 {
+		t.Fatalf("Time() is %dms off the wall clock", d)
+	}
+	if prev.Step() > stepMask {
+		t.Fatalf("Step() = %d exceeds the mask", prev.Step())
+	}
+}
+
+func TestNewNodeRejects(t *testing.T) {
+	for _, node := range []int64{-1, 1024} {
+		if _, err := NewNode(node); err == nil {
+			t.Errorf("NewNode(%d) succeeded", node)
+		}
+	}
+
+	defer func(n, s uint8) { NodeBits, StepBits = n, s }(NodeBits, StepBits)
+	NodeBits, StepBits = 12, 11
+	if _, err := NewNode(1); err == nil {
+		t.Error("accepted 23 node and step bits")
+	}
+}
+
+func TestCustomBits(t *testing.T) {
+	defer func(n, s uint8) {
+		NodeBits, StepBits = n, s
+		NewNode(0)
+	}(NodeBits, StepBits)
+	NodeBits, StepBits = 6, 16
+
+	node, err := NewNode(63)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := node.Generate()
+	if id.Node() != 63 {
+		t.Fatalf("Node() = %d under 6 node bits, want 63", id.Node())
+	}
+	if d := time.Now().UnixMilli() - id.Time(); d < 0 || d > 1000 {
+		t.Fatalf("Time() is %dms off under custom bits", d)
+	}
+}
+
+func TestEncodings(t *testing.T) {
+	node, err := NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := node.Generate()
+
+	check := func(name string, got ID, err error) {
+		t.Helper()
+		if err != nil || got != id {
+			t.Errorf("%s round trip = %d, %v, want %d", name, got, err, id)
+		}
+	}
+	check("Int64", ParseInt64(id.Int64()), nil)
+	got, err := ParseString(id.String())
+	check("String", got, err)
+	got, err = ParseBase2(id.Base2())
+	check("Base2", got, err)
+	got, err = ParseBase32([]byte(id.Base32()))
+	check("Base32", got, err)
+	got, err = ParseBase36(id.Base36())
+	check("Base36", got, err)
+	got, err = ParseBase58([]byte(id.Base58()))
+	check("Base58", got, err)
+	got, err = ParseBase64(id.Base64())
+	check("Base64", got, err)
+	got, err = ParseBytes(id.Bytes())
+	check("Bytes", got, err)
+	check("IntBytes", ParseIntBytes(id.IntBytes()), nil)
+
+	if ID(0).Base32() != "y" || ID(31).Base32() != "9" || ID(32).Base32() != "by" {
+		t.Errorf("z-base-32 digits: %q %q %q", ID(0).Base32(), ID(31).Base32(), ID(32).Base32())
+	}
+	if _, err := ParseBase32([]byte("0")); err != ErrInvalidBase32 {
+		t.Errorf("ParseBase32 of an invalid digit = %v", err)
+	}
+	if _, err := ParseBase58([]byte("0")); err != ErrInvalidBase58 {
+		t.Errorf("ParseBase58 of an invalid digit = %v", err)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	id := ID(1234567890123)
+	b, err := json.Marshal(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `"1234567890123"` {
+		t.Fatalf("MarshalJSON = %s", b)
+	}
+	var got ID
+	if err := json.Unmarshal(b, &got); err != nil || got != id {
+		t.Fatalf("UnmarshalJSON = %d, %v", got, err)
+	}
+
+	for _, in := range []string{`1234`, `""`, `"12x"`} {
+		if err := got.UnmarshalJSON([]byte(in)); err == nil {
+			t.Errorf("UnmarshalJSON(%s) succeeded", in)
+		}
+	}
+	var syntax JSONSyntaxError
+	if err := got.UnmarshalJSON([]byte(`1234`)); err == nil {
+		t.Fatal("no error")
+	} else if e, ok := err.(JSONSyntaxError); !ok {
+		t.Fatalf("error %T, want JSONSyntaxError", err)
+	} else {
+		syntax = e
+	}
+	if syntax.Error() != `invalid snowflake ID "1234"` {
+		t.Fatalf("Error() = %q", syntax.Error())
+	}
+}
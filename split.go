@@ -0,0 +1,49 @@
+package mkey
+
+import (
+	"errors"
+	"time"
+)
+
+// SplitBatch splits ids into chunks of at most maxPerChunk, e.g. to respect
+// the item limit of a downstream bulk API. Chunks share the backing array of ids.
+func SplitBatch(ids []ID, maxPerChunk int) ([][]ID, error) {
+	if maxPerChunk <= 0 {
+		return nil, errors.New("maxPerChunk must be positive")
+	}
+
+	chunks := make([][]ID, 0, (len(ids)+maxPerChunk-1)/maxPerChunk)
+	for len(ids) > 0 {
+		n := min(maxPerChunk, len(ids))
+		chunks = append(chunks, ids[:n:n])
+		ids = ids[n:]
+	}
+	return chunks, nil
+}
+
+// SplitBatchByTime is SplitBatch that additionally starts a new chunk
+// whenever the creation time of consecutive IDs crosses a bucket boundary,
+// so no chunk spans two buckets (e.g. hourly partitions). Buckets are
+// aligned to the layout's epoch; IDs are taken in the given order.
+func SplitBatchByTime(ids []ID, maxPerChunk int, l Layout, bucket time.Duration) ([][]ID, error) {
+	if maxPerChunk <= 0 {
+		return nil, errors.New("maxPerChunk must be positive")
+	}
+	bucketMs := bucket.Milliseconds()
+	if bucketMs <= 0 {
+		return nil, errors.New("bucket must be at least 1ms")
+	}
+	bucketOf := func(id ID) int64 {
+		return (l.Time(id) - l.Epoch) / bucketMs
+	}
+
+	var chunks [][]ID
+	start := 0
+	for i := 1; i <= len(ids); i++ {
+		if i == len(ids) || i-start == maxPerChunk || bucketOf(ids[i]) != bucketOf(ids[start]) {
+			chunks = append(chunks, ids[start:i:i])
+			start = i
+		}
+	}
+	return chunks, nil
+}
@@ -0,0 +1,61 @@
+package mkey
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSplitBatch(t *testing.T) {
+	ids := []ID{1, 2, 3, 4, 5, 6, 7}
+	chunks, err := SplitBatch(ids, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]ID{{1, 2, 3}, {4, 5, 6}, {7}}
+	if !slices.EqualFunc(chunks, want, slices.Equal) {
+		t.Fatalf("chunks = %v, want %v", chunks, want)
+	}
+	// chunks share ids but cannot grow into each other
+	chunks[0] = append(chunks[0], 99)
+	if ids[3] != 4 {
+		t.Fatal("appending to a chunk overwrote the next one")
+	}
+
+	if chunks, _ := SplitBatch(nil, 3); len(chunks) != 0 {
+		t.Fatalf("empty input gave %v", chunks)
+	}
+	if _, err := SplitBatch(ids, 0); err == nil {
+		t.Fatal("maxPerChunk 0 accepted")
+	}
+}
+
+func TestSplitBatchByTime(t *testing.T) {
+	l := DefaultLayout()
+	at := func(minute, second int) ID {
+		id, err := l.Compose(time.UnixMilli(l.Epoch).Add(time.Duration(minute)*time.Minute+time.Duration(second)*time.Second), 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	ids := []ID{at(0, 1), at(0, 2), at(0, 3), at(0, 59), at(1, 0), at(1, 1), at(3, 0)}
+	chunks, err := SplitBatchByTime(ids, 3, l, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]ID{ids[0:3], ids[3:4], ids[4:6], ids[6:7]}
+	if !slices.EqualFunc(chunks, want, slices.Equal) {
+		t.Fatalf("chunks = %v, want %v", chunks, want)
+	}
+
+	if chunks, _ := SplitBatchByTime(nil, 3, l, time.Minute); len(chunks) != 0 {
+		t.Fatalf("empty input gave %v", chunks)
+	}
+	if _, err := SplitBatchByTime(ids, 0, l, time.Minute); err == nil {
+		t.Fatal("maxPerChunk 0 accepted")
+	}
+	if _, err := SplitBatchByTime(ids, 3, l, time.Microsecond); err == nil {
+		t.Fatal("sub-millisecond bucket accepted")
+	}
+}
@@ -0,0 +1,147 @@
+// Package sqlgen emits SQL that decomposes mkey IDs inside a database,
+// so analysts can extract timestamps, node IDs and steps without Go code
+package sqlgen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/icehuntmen/mkey"
+)
+
+// Dialect selects the SQL flavour to emit
+type Dialect string
+
+const (
+	// Postgres emits plpgsql functions
+	Postgres Dialect = "postgres"
+
+	// MySQL emits MySQL stored functions
+	MySQL Dialect = "mysql"
+
+	// ClickHouse emits ClickHouse SQL user-defined functions
+	ClickHouse Dialect = "clickhouse"
+
+	// BigQuery emits BigQuery temporary SQL functions
+	BigQuery Dialect = "bigquery"
+)
+
+// DefaultPrefix is the function name prefix used when none is given
+const DefaultPrefix = "mkey"
+
+// Expressions holds SQL expressions decoding the components of an ID column
+type Expressions struct {
+	// Time evaluates to the creation timestamp with millisecond precision
+	Time string
+
+	// Node evaluates to the node ID
+	Node string
+
+	// Step evaluates to the step (sequence) number
+	Step string
+}
+
+// Expr returns expressions decoding column under the given layout.
+// column is inserted verbatim and may be any SQL expression yielding the ID as a 64-bit integer.
+func Expr(d Dialect, l mkey.Layout, column string) (Expressions, error) {
+	if err := l.Validate(); err != nil {
+		return Expressions{}, err
+	}
+
+	shift, epoch := l.TimeShift(), l.Epoch
+	nodeMask, stepMask := l.NodeMask(), l.StepMask()
+
+	// shifted is the raw timestamp component; it only needs masking when
+	// priority bits sit above it
+	shifted := fmt.Sprintf("(%s >> %d)", column, shift)
+	if d == ClickHouse {
+		shifted = fmt.Sprintf("bitShiftRight(%s, %d)", column, shift)
+	}
+	if l.PriorityBits > 0 {
+		if d == ClickHouse {
+			shifted = fmt.Sprintf("bitAnd(%s, %d)", shifted, l.TimeMask())
+		} else {
+			shifted = fmt.Sprintf("(%s & %d)", shifted, l.TimeMask())
+		}
+	}
+
+	switch d {
+	case Postgres:
+		return Expressions{
+			Time: fmt.Sprintf("to_timestamp((%s + %d) / 1000.0)", shifted, epoch),
+			Node: fmt.Sprintf("((%s >> %d) & %d)::integer", column, l.StepBits, nodeMask),
+			Step: fmt.Sprintf("(%s & %d)::integer", column, stepMask),
+		}, nil
+	case MySQL:
+		return Expressions{
+			Time: fmt.Sprintf("FROM_UNIXTIME((%s + %d) / 1000)", shifted, epoch),
+			Node: fmt.Sprintf("(%s >> %d) & %d", column, l.StepBits, nodeMask),
+			Step: fmt.Sprintf("%s & %d", column, stepMask),
+		}, nil
+	case ClickHouse:
+		return Expressions{
+			Time: fmt.Sprintf("fromUnixTimestamp64Milli(toInt64(%s + %d))", shifted, epoch),
+			Node: fmt.Sprintf("bitAnd(bitShiftRight(%s, %d), %d)", column, l.StepBits, nodeMask),
+			Step: fmt.Sprintf("bitAnd(%s, %d)", column, stepMask),
+		}, nil
+	case BigQuery:
+		return Expressions{
+			Time: fmt.Sprintf("TIMESTAMP_MILLIS(%s + %d)", shifted, epoch),
+			Node: fmt.Sprintf("(%s >> %d) & %d", column, l.StepBits, nodeMask),
+			Step: fmt.Sprintf("%s & %d", column, stepMask),
+		}, nil
+	}
+	return Expressions{}, fmt.Errorf("unsupported dialect %q", d)
+}
+
+// Functions returns statements defining <prefix>_time(id), <prefix>_node(id)
+// and <prefix>_step(id) for the given layout.
+// Use distinct prefixes to install functions for several layouts side by side.
+func Functions(d Dialect, l mkey.Layout, prefix string) (string, error) {
+	e, err := Expr(d, l, "id")
+	if err != nil {
+		return "", err
+	}
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+
+	var b strings.Builder
+	var fn func(name, returns, body string)
+
+	switch d {
+	case Postgres:
+		fn = func(name, returns, body string) {
+			fmt.Fprintf(&b, "CREATE OR REPLACE FUNCTION %s_%s(id bigint) RETURNS %s\n", prefix, name, returns)
+			fmt.Fprintf(&b, "LANGUAGE plpgsql IMMUTABLE STRICT PARALLEL SAFE AS $$\n")
+			fmt.Fprintf(&b, "BEGIN\n\tRETURN %s;\nEND;\n$$;\n\n", body)
+		}
+		fn("time", "timestamptz", e.Time)
+		fn("node", "integer", e.Node)
+		fn("step", "integer", e.Step)
+	case MySQL:
+		fn = func(name, returns, body string) {
+			fmt.Fprintf(&b, "DROP FUNCTION IF EXISTS %s_%s;\n", prefix, name)
+			fmt.Fprintf(&b, "CREATE FUNCTION %s_%s(id BIGINT) RETURNS %s DETERMINISTIC\n", prefix, name, returns)
+			fmt.Fprintf(&b, "RETURN %s;\n\n", body)
+		}
+		fn("time", "DATETIME(3)", e.Time)
+		fn("node", "INT", e.Node)
+		fn("step", "INT", e.Step)
+	case ClickHouse:
+		fn = func(name, _, body string) {
+			fmt.Fprintf(&b, "CREATE OR REPLACE FUNCTION %s_%s AS (id) -> %s;\n\n", prefix, name, body)
+		}
+		fn("time", "", e.Time)
+		fn("node", "", e.Node)
+		fn("step", "", e.Step)
+	case BigQuery:
+		fn = func(name, returns, body string) {
+			fmt.Fprintf(&b, "CREATE TEMP FUNCTION %s_%s(id INT64) RETURNS %s AS (%s);\n\n", prefix, name, returns, body)
+		}
+		fn("time", "TIMESTAMP", e.Time)
+		fn("node", "INT64", e.Node)
+		fn("step", "INT64", e.Step)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,260 @@
+package sqlgen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/icehuntmen/mkey"
+)
+
+// evalSQL evaluates the integer subset of SQL used by the generated
+// expressions: id, literals, parentheses, +, >> and & (in rising binding
+// order &, >>, +), ::integer casts and the bitAnd, bitShiftRight and toInt64
+// functions
+func evalSQL(t *testing.T, expr string, id mkey.ID) int64 {
+	t.Helper()
+	p := &sqlParser{s: strings.ReplaceAll(expr, "::integer", ""), id: int64(id)}
+	v := p.and()
+	p.skip()
+	if p.err != nil || p.pos != len(p.s) {
+		t.Fatalf("cannot evaluate %q: %v at %d", expr, p.err, p.pos)
+	}
+	return v
+}
+
+type sqlParser struct {
+	s   string
+	pos int
+	id  int64
+	err error
+}
+
+func (p *sqlParser) skip() {
+	for p.pos < len(p.s) && p.s[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *sqlParser) eat(tok string) bool {
+	p.skip()
+	if strings.HasPrefix(p.s[p.pos:], tok) {
+		p.pos += len(tok)
+		return true
+	}
+	return false
+}
+
+func (p *sqlParser) and() int64 {
+	v := p.shift()
+	for p.eat("&") {
+		v &= p.shift()
+	}
+	return v
+}
+
+func (p *sqlParser) shift() int64 {
+	v := p.sum()
+	for p.eat(">>") {
+		v >>= p.sum()
+	}
+	return v
+}
+
+func (p *sqlParser) sum() int64 {
+	v := p.term()
+	for p.eat("+") {
+		v += p.term()
+	}
+	return v
+}
+
+func (p *sqlParser) term() int64 {
+	for _, fn := range []string{"bitAnd(", "bitShiftRight(", "toInt64("} {
+		if !p.eat(fn) {
+			continue
+		}
+		a := p.and()
+		if fn == "toInt64(" {
+			p.eat(")")
+			return a
+		}
+		p.eat(",")
+		b := p.and()
+		p.eat(")")
+		if fn == "bitAnd(" {
+			return a & b
+		}
+		return a >> b
+	}
+	if p.eat("(") {
+		v := p.and()
+		if !p.eat(")") {
+			p.err = fmt.Errorf("missing )")
+		}
+		return v
+	}
+	if p.eat("id") {
+		return p.id
+	}
+	p.skip()
+	end := p.pos
+	for end < len(p.s) && p.s[end] >= '0' && p.s[end] <= '9' {
+		end++
+	}
+	v, err := strconv.ParseInt(p.s[p.pos:end], 10, 64)
+	if err != nil && p.err == nil {
+		p.err = err
+	}
+	p.pos = end
+	return v
+}
+
+// unwrap strips the timestamp conversion around the millisecond expression
+func unwrap(t *testing.T, expr, prefix, suffix string) string {
+	t.Helper()
+	inner, ok := strings.CutPrefix(expr, prefix)
+	if ok {
+		inner, ok = strings.CutSuffix(inner, suffix)
+	}
+	if !ok {
+		t.Fatalf("%q is not wrapped in %s...%s", expr, prefix, suffix)
+	}
+	return inner
+}
+
+var testLayouts = map[string]func(*mkey.Config){
+	"default":  func(*mkey.Config) {},
+	"narrow":   func(c *mkey.Config) { c.NodeBits, c.StepBits = 4, 8 },
+	"priority": func(c *mkey.Config) { c.PriorityBits, c.NodeBits = 2, 8 },
+	"expiry":   func(c *mkey.Config) { c.ExpiryBits, c.NodeBits = 8, 2 },
+}
+
+// checkDialect evaluates the expressions of d against IDs of every test layout
+func checkDialect(t *testing.T, d Dialect, timePrefix, timeSuffix string) {
+	for name, opt := range testLayouts {
+		t.Run(name, func(t *testing.T) {
+			cfg := mkey.NewConfig()
+			opt(cfg)
+			cfg.Node = cfg.Layout().MaxNode()
+			n, err := mkey.NewNodeWithConfig(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			l := n.Layout()
+			e, err := Expr(d, l, "id")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ids := []mkey.ID{n.Generate(), n.Generate()}
+			if l.PriorityBits > 0 {
+				id, err := n.GenerateWithPriority(l.MaxPriority())
+				if err != nil {
+					t.Fatal(err)
+				}
+				ids = append(ids, id)
+			}
+			if l.ExpiryBits > 0 {
+				id, err := n.GenerateWithTTL(time.Minute)
+				if err != nil {
+					t.Fatal(err)
+				}
+				ids = append(ids, id)
+			}
+			for _, id := range ids {
+				if got := evalSQL(t, unwrap(t, e.Time, timePrefix, timeSuffix), id); got != l.Time(id) {
+					t.Errorf("time of %d = %d, want %d", id, got, l.Time(id))
+				}
+				if got := evalSQL(t, e.Node, id); got != l.NodeID(id) {
+					t.Errorf("node of %d = %d, want %d", id, got, l.NodeID(id))
+				}
+				if got := evalSQL(t, e.Step, id); got != l.Step(id) {
+					t.Errorf("step of %d = %d, want %d", id, got, l.Step(id))
+				}
+			}
+		})
+	}
+}
+
+func TestExprPostgres(t *testing.T) {
+	checkDialect(t, Postgres, "to_timestamp(", " / 1000.0)")
+}
+
+func TestExprMySQL(t *testing.T) {
+	checkDialect(t, MySQL, "FROM_UNIXTIME(", " / 1000)")
+}
+
+func TestExprClickHouse(t *testing.T) {
+	checkDialect(t, ClickHouse, "fromUnixTimestamp64Milli(", ")")
+}
+
+func TestExprBigQuery(t *testing.T) {
+	checkDialect(t, BigQuery, "TIMESTAMP_MILLIS(", ")")
+}
+
+func TestExprColumn(t *testing.T) {
+	e, err := Expr(MySQL, mkey.DefaultLayout(), "t.order_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{e.Time, e.Node, e.Step} {
+		if !strings.Contains(s, "t.order_id") || strings.Contains(s, "(id") {
+			t.Errorf("expression %q does not use the column", s)
+		}
+	}
+}
+
+func TestExprRejects(t *testing.T) {
+	if _, err := Expr("oracle", mkey.DefaultLayout(), "id"); err == nil {
+		t.Error("accepted an unknown dialect")
+	}
+	bad := mkey.DefaultLayout()
+	bad.NodeBits = 30
+	if _, err := Expr(Postgres, bad, "id"); err == nil {
+		t.Error("accepted an invalid layout")
+	}
+	if _, err := Functions("oracle", mkey.DefaultLayout(), ""); err == nil {
+		t.Error("Functions accepted an unknown dialect")
+	}
+}
+
+func TestFunctions(t *testing.T) {
+	tests := []struct {
+		d      Dialect
+		prefix string
+		want   []string
+	}{
+		{Postgres, "", []string{
+			"CREATE OR REPLACE FUNCTION mkey_time(id bigint) RETURNS timestamptz",
+			"FUNCTION mkey_node(id bigint) RETURNS integer",
+			"FUNCTION mkey_step(id bigint) RETURNS integer",
+		}},
+		{MySQL, "orders", []string{
+			"DROP FUNCTION IF EXISTS orders_time;",
+			"CREATE FUNCTION orders_node(id BIGINT) RETURNS INT DETERMINISTIC",
+			"CREATE FUNCTION orders_time(id BIGINT) RETURNS DATETIME(3) DETERMINISTIC",
+		}},
+		{ClickHouse, "", []string{
+			"CREATE OR REPLACE FUNCTION mkey_time AS (id) -> fromUnixTimestamp64Milli(",
+			"CREATE OR REPLACE FUNCTION mkey_step AS (id) -> bitAnd(id, ",
+		}},
+		{BigQuery, "ev", []string{
+			"CREATE TEMP FUNCTION ev_time(id INT64) RETURNS TIMESTAMP AS (TIMESTAMP_MILLIS(",
+			"CREATE TEMP FUNCTION ev_node(id INT64) RETURNS INT64 AS (",
+		}},
+	}
+	for _, tt := range tests {
+		out, err := Functions(tt.d, mkey.DefaultLayout(), tt.prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, w := range tt.want {
+			if !strings.Contains(out, w) {
+				t.Errorf("%s functions lack %q:\n%s", tt.d, w, out)
+			}
+		}
+	}
+}
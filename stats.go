@@ -0,0 +1,108 @@
+package mkey
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// Stats is a point-in-time snapshot of a Node's counters
+type Stats struct {
+	// Node is the node ID of the generator
+	Node int64
+
+	// Labels are the deployment labels attached via Config.Labels
+	Labels map[string]string
+
+	// Generated is the total number of IDs issued
+	Generated uint64
+
+	// Waits is the number of times generation had to wait for the next
+	// millisecond because the step space was exhausted
+	Waits uint64
+
+	// Borrowed is the number of times the node moved ahead of the clock
+	// instead of waiting, see Config.BorrowLimit
+	Borrowed uint64
+
+	// Latency is the distribution of generation latencies, nil unless
+	// Config.LatencyHistogram is set
+	Latency *LatencyHistogram
+}
+
+// Stats returns a snapshot of the node's counters
+func (n *Node) Stats() Stats {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	s := Stats{
+		Node:      n.node,
+		Labels:    copyLabels(n.labels),
+		Generated: n.generated.Load(),
+		Waits:     n.waits,
+		Borrowed:  n.borrowed,
+	}
+	if n.latency != nil {
+		h := *n.latency
+		s.Latency = &h
+	}
+	return s
+}
+
+func copyLabels(labels map[string]string) map[string]string {
+	if len(labels) == 0 {
+		return nil
+	}
+	c := make(map[string]string, len(labels))
+	for k, v := range labels {
+		c[k] = v
+	}
+	return c
+}
+
+// secondCounter counts events in the current second. The second and the
+// count share one word, second<<32 | count, so concurrent writers on the
+// lock-free generation path can reset and add in one CAS. 32 bits hold a
+// count of 1000 full milliseconds of the widest step field.
+type secondCounter struct {
+	v atomic.Uint64
+}
+
+func (c *secondCounter) add(second int64, delta uint64) {
+	for {
+		v := c.v.Load()
+		count := v & math.MaxUint32
+		if int64(v>>32) != second {
+			count = 0
+		}
+		if c.v.CompareAndSwap(v, uint64(second)<<32|(count+delta)) {
+			return
+		}
+	}
+}
+
+func (c *secondCounter) load(second int64) uint64 {
+	v := c.v.Load()
+	if int64(v>>32) != second {
+		return 0
+	}
+	return v & math.MaxUint32
+}
+
+// currentSecond returns the seconds elapsed since the node's epoch, matching
+// the units fed to the per-second counters
+func (n *Node) currentSecond() int64 {
+	return n.now() / 1000
+}
+
+// IDsThisSecond returns the number of IDs issued during the current second.
+// It does not lock the node, so admission controllers can poll it on every
+// request to shed load before generation saturates.
+func (n *Node) IDsThisSecond() uint64 {
+	return n.secIDs.load(n.currentSecond())
+}
+
+// WaitsThisSecond returns how many times generation waited for the next
+// millisecond during the current second. Like IDsThisSecond it does not lock.
+func (n *Node) WaitsThisSecond() uint64 {
+	return n.secWaits.load(n.currentSecond())
+}
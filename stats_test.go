@@ -0,0 +1,94 @@
+package mkey
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatsLabels(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Node = 7
+	cfg.Labels = map[string]string{"region": "eu", "az": "b"}
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The node keeps its own copy of the labels
+	cfg.Labels["region"] = "us"
+	n.Labels()["az"] = "c"
+
+	for range 5 {
+		n.Generate()
+	}
+	s := n.Stats()
+	if s.Node != 7 || s.Generated != 5 {
+		t.Fatalf("Stats = %+v, want node 7 and 5 generated", s)
+	}
+	if len(s.Labels) != 2 || s.Labels["region"] != "eu" || s.Labels["az"] != "b" {
+		t.Fatalf("Stats.Labels = %v", s.Labels)
+	}
+	s.Labels["region"] = "ap"
+	if n.Stats().Labels["region"] != "eu" {
+		t.Fatal("changing a Stats snapshot changed the node's labels")
+	}
+}
+
+func TestStatsNoLabels(t *testing.T) {
+	n, err := NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := n.Stats(); s.Labels != nil || n.Labels() != nil {
+		t.Fatalf("unlabelled node reports labels %v", s.Labels)
+	}
+}
+
+func TestIDsThisSecond(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	cfg := NewConfig()
+	cfg.Node = 1
+	cfg.Clock = clock
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := n.IDsThisSecond(); got != 0 {
+		t.Fatalf("IDsThisSecond on a new node = %d", got)
+	}
+	for range 10 {
+		n.Generate()
+	}
+	if got := n.IDsThisSecond(); got != 10 {
+		t.Fatalf("IDsThisSecond = %d, want 10", got)
+	}
+	clock.Advance(time.Second)
+	if got := n.IDsThisSecond(); got != 0 {
+		t.Fatalf("IDsThisSecond in the next second = %d, want 0", got)
+	}
+	n.Generate()
+	if got := n.IDsThisSecond(); got != 1 {
+		t.Fatalf("IDsThisSecond after one more ID = %d, want 1", got)
+	}
+}
+
+func TestWaitsThisSecond(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Node = 1
+	cfg.StepBits = 1
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := n.WaitsThisSecond(); got != 0 {
+		t.Fatalf("WaitsThisSecond on a new node = %d", got)
+	}
+	// two IDs per millisecond: the 11th ID starts a millisecond after a wait,
+	// so even across a second boundary the current second saw one
+	for range 11 {
+		n.Generate()
+	}
+	if got := n.WaitsThisSecond(); got == 0 {
+		t.Fatal("WaitsThisSecond = 0 after exhausting the step")
+	}
+}
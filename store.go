@@ -0,0 +1,108 @@
+package mkey
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// StateStore durably persists a single counter value
+type StateStore interface {
+	// Load returns the stored value, or 0 if nothing was stored yet
+	Load() (uint64, error)
+
+	// Save durably stores v; when it returns nil the value must survive a crash
+	Save(v uint64) error
+}
+
+// MemoryStore is a StateStore kept in memory, useful for tests
+type MemoryStore struct {
+	mu sync.Mutex
+	v  uint64
+}
+
+// Load implements StateStore
+func (m *MemoryStore) Load() (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.v, nil
+}
+
+// Save implements StateStore
+func (m *MemoryStore) Save(v uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.v = v
+	return nil
+}
+
+// FileStore is a StateStore backed by a file. Each Save writes a temporary
+// file, syncs it and renames it over Path, so a crash leaves either the old
+// or the new value.
+type FileStore struct {
+	Path string
+}
+
+// Load implements StateStore
+func (f *FileStore) Load() (uint64, error) {
+	b, err := os.ReadFile(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+}
+
+// Save implements StateStore
+func (f *FileStore) Save(v uint64) error {
+	return writeFileAtomic(f.Path, []byte(strconv.FormatUint(v, 10)+"\n"), 0o600)
+}
+
+// writeFileAtomic writes data with permissions perm to a temporary file next
+// to path, syncs it and renames it over path, so readers see either the old or
+// the new content
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	// Directories cannot be opened for syncing on Windows, so there the
+	// rename is left to the file system
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
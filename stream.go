@@ -0,0 +1,47 @@
+package mkey
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"time"
+)
+
+// streamBatch is how many IDs Stream reserves per lock acquisition; small
+// enough that buffered IDs stay close to the time they are yielded
+const streamBatch = 64
+
+// Stream returns an endless sequence of new IDs for pipelines that range
+// over IDs lazily. IDs are reserved in small batches, so one lock acquisition
+// serves several iterations; IDs reserved but not consumed when the loop
+// breaks are discarded.
+func (n *Node) Stream() iter.Seq[ID] {
+	return n.StreamContext(context.Background())
+}
+
+// StreamContext is like Stream but ends once ctx is done, including while
+// waiting for the next millisecond; check ctx.Err() after the loop to tell
+// cancellation apart from a break. Both also end when the node is closed.
+func (n *Node) StreamContext(ctx context.Context) iter.Seq[ID] {
+	return func(yield func(ID) bool) {
+		buf := make([]ID, min(streamBatch, int(n.stepLimit)))
+		for {
+			if err := n.generateBatch(ctx, buf, nil); err != nil {
+				if ctx.Err() != nil || errors.Is(err, ErrClosed) {
+					return
+				}
+				// Only ErrClockMovedBack gets here; retry once the clock catches up
+				time.Sleep(time.Millisecond)
+				continue
+			}
+			for _, id := range buf {
+				if !yield(id) {
+					return
+				}
+			}
+			if ctx.Err() != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package mkey
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStream(t *testing.T) {
+	n, _ := NewNode(1)
+	var got []ID
+	for id := range n.Stream() {
+		got = append(got, id)
+		if len(got) == 1000 {
+			break
+		}
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i] <= got[i-1] {
+			t.Fatalf("stream ID %d = %v does not follow %v", i, got[i], got[i-1])
+		}
+	}
+	// reserved but unconsumed IDs are skipped, never reissued
+	if next := n.Generate(); next <= got[len(got)-1] {
+		t.Fatal("Generate reissued a streamed ID")
+	}
+}
+
+func TestStreamContext(t *testing.T) {
+	// a stopped clock with two steps per millisecond blocks the stream after two IDs
+	clock := NewManualClock(time.Now())
+	cfg := NewConfig()
+	cfg.Node = 1
+	cfg.StepBits = 1
+	cfg.Clock = clock
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+	count := 0
+	for range n.StreamContext(ctx) {
+		count++
+	}
+	if ctx.Err() == nil || count != 2 {
+		t.Fatalf("stream ended with %d IDs, ctx err %v", count, ctx.Err())
+	}
+
+	closing, _ := NewNode(2)
+	count = 0
+	for range closing.Stream() {
+		if count++; count == 10 {
+			closing.Close()
+		}
+	}
+	// the batch already reserved is still yielded, then the stream ends
+	if count != streamBatch {
+		t.Fatalf("stream of a closed node yielded %d IDs, want %d", count, streamBatch)
+	}
+}
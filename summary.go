@@ -0,0 +1,88 @@
+package mkey
+
+import (
+	"iter"
+	"slices"
+	"time"
+)
+
+// Summary describes when a set of IDs was created and by which nodes
+type Summary struct {
+	// Total is the number of IDs summarized
+	Total int
+
+	// First and Last are the earliest and latest creation times
+	First time.Time
+	Last  time.Time
+
+	// Bucket is the width of each entry in Buckets
+	Bucket time.Duration
+
+	// Buckets holds the non-empty time buckets in chronological order
+	Buckets []BucketCount
+
+	// Nodes counts IDs per node ID
+	Nodes map[int64]int
+}
+
+// BucketCount holds the IDs created within one time bucket
+type BucketCount struct {
+	Start time.Time
+	Count int
+	Nodes map[int64]int
+}
+
+// Summarize counts ids per time bucket and per node under the given layout.
+// Buckets are aligned to multiples of bucket since the Unix epoch;
+// a bucket smaller than a millisecond is treated as one millisecond.
+func Summarize(ids iter.Seq[ID], l Layout, bucket time.Duration) Summary {
+	bucketMs := bucket.Milliseconds()
+	if bucketMs < 1 {
+		bucketMs = 1
+	}
+
+	s := Summary{
+		Bucket: time.Duration(bucketMs) * time.Millisecond,
+		Nodes:  make(map[int64]int),
+	}
+	buckets := make(map[int64]*BucketCount)
+	var first, last int64
+
+	for id := range ids {
+		ms := l.Time(id)
+		node := l.NodeID(id)
+
+		if s.Total == 0 || ms < first {
+			first = ms
+		}
+		if s.Total == 0 || ms > last {
+			last = ms
+		}
+		s.Total++
+		s.Nodes[node]++
+
+		start := ms - ms%bucketMs
+		if ms < 0 && ms%bucketMs != 0 {
+			start -= bucketMs
+		}
+		b, ok := buckets[start]
+		if !ok {
+			b = &BucketCount{Start: time.UnixMilli(start), Nodes: make(map[int64]int)}
+			buckets[start] = b
+		}
+		b.Count++
+		b.Nodes[node]++
+	}
+
+	if s.Total == 0 {
+		return s
+	}
+	s.First, s.Last = time.UnixMilli(first), time.UnixMilli(last)
+
+	s.Buckets = make([]BucketCount, 0, len(buckets))
+	for _, b := range buckets {
+		s.Buckets = append(s.Buckets, *b)
+	}
+	slices.SortFunc(s.Buckets, func(a, b BucketCount) int { return a.Start.Compare(b.Start) })
+	return s
+}
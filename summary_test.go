@@ -0,0 +1,80 @@
+package mkey
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSummarize(t *testing.T) {
+	l := DefaultLayout()
+	base := time.UnixMilli(l.Epoch).Add(1000 * time.Hour).Truncate(time.Minute)
+	compose := func(offset time.Duration, node int64) ID {
+		t.Helper()
+		id, err := l.Compose(base.Add(offset), node, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	ids := []ID{
+		compose(30*time.Second, 2),
+		compose(0, 1),
+		compose(59*time.Second, 1),
+		compose(61*time.Second, 2),
+		compose(5*time.Minute, 3),
+	}
+
+	s := Summarize(slices.Values(ids), l, time.Minute)
+	if s.Total != 5 || s.Bucket != time.Minute {
+		t.Fatalf("Total %d, Bucket %v", s.Total, s.Bucket)
+	}
+	if !s.First.Equal(base) || !s.Last.Equal(base.Add(5*time.Minute)) {
+		t.Fatalf("First %v, Last %v", s.First, s.Last)
+	}
+	if s.Nodes[1] != 2 || s.Nodes[2] != 2 || s.Nodes[3] != 1 {
+		t.Fatalf("Nodes = %v", s.Nodes)
+	}
+
+	want := []struct {
+		start time.Duration
+		count int
+		nodes map[int64]int
+	}{
+		{0, 3, map[int64]int{1: 2, 2: 1}},
+		{time.Minute, 1, map[int64]int{2: 1}},
+		{5 * time.Minute, 1, map[int64]int{3: 1}},
+	}
+	if len(s.Buckets) != len(want) {
+		t.Fatalf("%d buckets, want %d", len(s.Buckets), len(want))
+	}
+	for i, w := range want {
+		b := s.Buckets[i]
+		if !b.Start.Equal(base.Add(w.start)) || b.Count != w.count || len(b.Nodes) != len(w.nodes) {
+			t.Errorf("bucket %d = %v %d %v", i, b.Start, b.Count, b.Nodes)
+		}
+		for n, c := range w.nodes {
+			if b.Nodes[n] != c {
+				t.Errorf("bucket %d node %d = %d, want %d", i, n, b.Nodes[n], c)
+			}
+		}
+	}
+}
+
+func TestSummarizeEdges(t *testing.T) {
+	l := DefaultLayout()
+	s := Summarize(slices.Values([]ID(nil)), l, time.Minute)
+	if s.Total != 0 || s.Buckets != nil || !s.First.IsZero() {
+		t.Fatalf("empty summary = %+v", s)
+	}
+
+	// Sub-millisecond buckets are widened to one millisecond
+	n, err := NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s = Summarize(slices.Values([]ID{n.Generate()}), l, time.Microsecond)
+	if s.Bucket != time.Millisecond || len(s.Buckets) != 1 {
+		t.Fatalf("Bucket %v with %d buckets", s.Bucket, len(s.Buckets))
+	}
+}
@@ -0,0 +1,130 @@
+package mkey
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Metric describes one metric family derived from Stats
+type Metric struct {
+	// Name is the Prometheus metric name
+	Name string
+
+	// Help is the one-line description
+	Help string
+
+	// Kind is the Prometheus metric type, e.g. "counter"
+	Kind string
+
+	// Value extracts the metric from a snapshot
+	Value func(Stats) uint64
+}
+
+var metrics = []Metric{
+	{"mkey_ids_generated_total", "Total number of IDs issued.", "counter", func(s Stats) uint64 { return s.Generated }},
+	{"mkey_waits_total", "Times generation waited for the next millisecond.", "counter", func(s Stats) uint64 { return s.Waits }},
+	{"mkey_borrowed_total", "Times generation moved ahead of the clock instead of waiting.", "counter", func(s Stats) uint64 { return s.Borrowed }},
+}
+
+// Metrics returns the metric families written by WritePrometheus, for
+// exporters speaking other protocols
+func Metrics() []Metric {
+	return slices.Clone(metrics)
+}
+
+// WritePrometheus writes stats in the Prometheus text exposition format, one
+// series per Stats labelled with the node ID and the node's labels
+func WritePrometheus(w io.Writer, stats ...Stats) error {
+	var b bytes.Buffer
+	for _, m := range metrics {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", m.Name, m.Help, m.Name, m.Kind)
+		for _, s := range stats {
+			fmt.Fprintf(&b, "%s{%s} %d\n", m.Name, promLabels(s), m.Value(s))
+		}
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// promLabels formats the node ID and labels as a sorted label set
+func promLabels(s Stats) string {
+	keys := make([]string, 0, len(s.Labels))
+	for k := range s.Labels {
+		if k != "node" {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `node="%d"`, s.Node)
+	for _, k := range keys {
+		fmt.Fprintf(&b, `,%s="%s"`, promLabelName(k), promEscaper.Replace(s.Labels[k]))
+	}
+	return b.String()
+}
+
+var promEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// promLabelName replaces characters Prometheus does not allow in label names
+func promLabelName(k string) string {
+	b := []byte(k)
+	for i, c := range b {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9') {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// WriteTextfile writes the nodes' stats to path in the format read by the
+// node_exporter textfile collector. The file is replaced atomically, so the
+// collector never reads a partial write. path should end in ".prom".
+func WriteTextfile(path string, nodes ...*Node) error {
+	stats := make([]Stats, len(nodes))
+	for i, n := range nodes {
+		stats[i] = n.Stats()
+	}
+
+	var b bytes.Buffer
+	if err := WritePrometheus(&b, stats...); err != nil {
+		return err
+	}
+	// Readable by the collector, which usually runs as another user
+	return writeFileAtomic(path, b.Bytes(), 0o644)
+}
+
+// RunTextfile calls WriteTextfile now and then every interval until ctx is
+// done, for cron jobs and batch workers without a scrapeable endpoint. It
+// writes once more before returning, so the file reflects the final counts,
+// and returns the first write error or ctx.Err().
+func RunTextfile(ctx context.Context, path string, interval time.Duration, nodes ...*Node) error {
+	if interval <= 0 {
+		return errors.New("interval must be positive")
+	}
+	if err := WriteTextfile(path, nodes...); err != nil {
+		return err
+	}
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if err := WriteTextfile(path, nodes...); err != nil {
+				return err
+			}
+			return ctx.Err()
+		case <-t.C:
+			if err := WriteTextfile(path, nodes...); err != nil {
+				return err
+			}
+		}
+	}
+}
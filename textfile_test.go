@@ -0,0 +1,123 @@
+package mkey
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	stats := []Stats{
+		{Node: 1, Generated: 10, Waits: 2, Borrowed: 1},
+		{Node: 2, Labels: map[string]string{"region": "eu", "node": "x", "a-z": "q\"\n"}, Generated: 5},
+	}
+	var b bytes.Buffer
+	if err := WritePrometheus(&b, stats...); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP mkey_ids_generated_total Total number of IDs issued.
+# TYPE mkey_ids_generated_total counter
+mkey_ids_generated_total{node="1"} 10
+mkey_ids_generated_total{node="2",a_z="q\"\n",region="eu"} 5
+# HELP mkey_waits_total Times generation waited for the next millisecond.
+# TYPE mkey_waits_total counter
+mkey_waits_total{node="1"} 2
+mkey_waits_total{node="2",a_z="q\"\n",region="eu"} 0
+# HELP mkey_borrowed_total Times generation moved ahead of the clock instead of waiting.
+# TYPE mkey_borrowed_total counter
+mkey_borrowed_total{node="1"} 1
+mkey_borrowed_total{node="2",a_z="q\"\n",region="eu"} 0
+`
+	if b.String() != want {
+		t.Fatalf("got\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestMetricsMatchPrometheus(t *testing.T) {
+	var b bytes.Buffer
+	if err := WritePrometheus(&b, Stats{Generated: 3, Waits: 4, Borrowed: 5}); err != nil {
+		t.Fatal(err)
+	}
+	ms := Metrics()
+	if len(ms) == 0 {
+		t.Fatal("no metrics")
+	}
+	for _, m := range ms {
+		if !strings.Contains(b.String(), "# TYPE "+m.Name+" "+m.Kind+"\n") {
+			t.Errorf("%s is not written as a %s", m.Name, m.Kind)
+		}
+	}
+
+	// Callers get a copy of the table
+	ms[0].Name = "changed"
+	if Metrics()[0].Name == "changed" {
+		t.Fatal("Metrics returned the shared table")
+	}
+}
+
+func TestWriteTextfile(t *testing.T) {
+	n, err := NewNode(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.Generate()
+
+	path := filepath.Join(t.TempDir(), "mkey.prom")
+	if err := WriteTextfile(path, n); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `mkey_ids_generated_total{node="4"} 1`) {
+		t.Fatalf("textfile lacks the node's count:\n%s", data)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Fatalf("temporary files left behind: %v", entries)
+	}
+	if runtime.GOOS != "windows" {
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Mode().Perm() != 0o644 {
+			t.Fatalf("textfile mode = %v, want it readable by the collector", fi.Mode().Perm())
+		}
+	}
+}
+
+func TestRunTextfileFinalWrite(t *testing.T) {
+	n, err := NewNode(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "mkey.prom")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- RunTextfile(ctx, path, time.Hour, n) }()
+
+	for _, err := os.Stat(path); err != nil; _, err = os.Stat(path) {
+		time.Sleep(time.Millisecond)
+	}
+	n.Generate()
+	n.Generate()
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("RunTextfile = %v, want context.Canceled", err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `mkey_ids_generated_total{node="4"} 2`) {
+		t.Fatalf("final write missing:\n%s", data)
+	}
+
+	if err := RunTextfile(context.Background(), path, 0, n); err == nil {
+		t.Fatal("accepted a zero interval")
+	}
+}
@@ -0,0 +1,12 @@
+package mkey
+
+import "time"
+
+// qpcElapsed converts ticks of a counter running at freq ticks per second to
+// a duration, splitting the conversion to avoid overflowing ticks*1e9. It
+// holds the Windows performance counter arithmetic, kept here so it is
+// tested on every platform.
+func qpcElapsed(ticks, freq int64) time.Duration {
+	return time.Duration(ticks/freq)*time.Second +
+		time.Duration(ticks%freq*int64(time.Second)/freq)
+}
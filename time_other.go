@@ -0,0 +1,12 @@
+//go:build !windows
+
+package mkey
+
+import "time"
+
+// sinceEpoch returns the time elapsed since epoch on the node's time source.
+// Elsewhere than Windows the runtime's monotonic clock has nanosecond
+// resolution, so time.Since is used directly.
+func sinceEpoch(epoch time.Time) time.Duration {
+	return time.Since(epoch)
+}
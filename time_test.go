@@ -0,0 +1,50 @@
+package mkey
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestQPCElapsed(t *testing.T) {
+	tests := []struct {
+		name        string
+		ticks, freq int64
+		want        time.Duration
+	}{
+		{"zero", 0, 10_000_000, 0},
+		{"one tick at 10MHz", 1, 10_000_000, 100 * time.Nanosecond},
+		{"one second at 10MHz", 10_000_000, 10_000_000, time.Second},
+		{"ms at 10MHz", 10_000, 10_000_000, time.Millisecond},
+		{"partial second", 15_000_001, 10_000_000, 1500*time.Millisecond + 100},
+		{"ACPI PM timer", 3_579_545, 3_579_545, time.Second},
+		{"ACPI PM timer tick", 1, 3_579_545, 279},
+		{"nanosecond counter", 123_456_789_012, 1_000_000_000, 123_456_789_012},
+		{"TSC rate", 2_400_000_000 * 3600, 2_400_000_000, time.Hour},
+		{"TSC fraction", 2_400_000_000 + 1_200_000_000, 2_400_000_000, 1500 * time.Millisecond},
+		// A year of uptime at 10MHz would overflow a naive ticks*1e9
+		{"year at 10MHz", 10_000_000 * 86400 * 365, 10_000_000, 365 * 24 * time.Hour},
+		{"near Duration range", 10_000_000*(math.MaxInt64/int64(time.Second)-1) + 9_999_999, 10_000_000,
+			time.Duration(math.MaxInt64/int64(time.Second)-1)*time.Second + 999_999_900},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := qpcElapsed(tt.ticks, tt.freq); got != tt.want {
+				t.Fatalf("qpcElapsed(%d, %d) = %v, want %v", tt.ticks, tt.freq, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQPCElapsedMonotonic(t *testing.T) {
+	for _, freq := range []int64{3_579_545, 10_000_000, 24_000_000} {
+		prev := time.Duration(-1)
+		for ticks := int64(0); ticks < 3*freq; ticks += freq/1000 + 7 {
+			d := qpcElapsed(ticks, freq)
+			if d < prev {
+				t.Fatalf("freq %d: qpcElapsed(%d) = %v < %v", freq, ticks, d, prev)
+			}
+			prev = d
+		}
+	}
+}
@@ -0,0 +1,46 @@
+//go:build windows
+
+package mkey
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// On Windows the wall clock can advance in timer-interrupt ticks (up to
+// 15.6ms), which turns per-millisecond sequencing into long waits followed by
+// bursts. IDs are therefore timed with QueryPerformanceCounter, anchored to
+// the wall clock once at startup.
+var (
+	kernel32    = syscall.NewLazyDLL("kernel32.dll")
+	procQPC     = kernel32.NewProc("QueryPerformanceCounter")
+	procQPF     = kernel32.NewProc("QueryPerformanceFrequency")
+	qpcFreq     int64
+	qpcBase     int64
+	qpcBaseWall time.Time
+)
+
+func init() {
+	if r, _, _ := procQPF.Call(uintptr(unsafe.Pointer(&qpcFreq))); r == 0 || qpcFreq <= 0 {
+		qpcFreq = 0
+		return
+	}
+	qpcBaseWall = time.Now()
+	qpcBase = qpc()
+}
+
+func qpc() int64 {
+	var c int64
+	procQPC.Call(uintptr(unsafe.Pointer(&c)))
+	return c
+}
+
+// sinceEpoch returns the time elapsed since epoch on the node's time source,
+// falling back to time.Since if the performance counter is unavailable
+func sinceEpoch(epoch time.Time) time.Duration {
+	if qpcFreq == 0 {
+		return time.Since(epoch)
+	}
+	return qpcBaseWall.Sub(epoch) + qpcElapsed(qpc()-qpcBase, qpcFreq)
+}
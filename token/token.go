@@ -0,0 +1,124 @@
+// Package token builds URL-safe session tokens around mkey IDs.
+//
+// A token is the ID, random bytes and an HMAC tag over both. The ID keeps
+// tokens time-ordered and cheap to index in a database; the random part and
+// the tag make them unguessable and tamper-evident.
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+
+	"github.com/icehuntmen/mkey"
+)
+
+const (
+	// RandomSize is the number of random bytes in a token
+	RandomSize = 16
+
+	// TagSize is the length of the truncated HMAC-SHA256 tag
+	TagSize = 16
+
+	// Size is the length of a decoded token
+	Size = 8 + RandomSize + TagSize
+
+	// MinKeySize is the minimum accepted HMAC key length
+	MinKeySize = 16
+)
+
+// ErrInvalid is returned for tokens that are malformed or fail verification
+var ErrInvalid = errors.New("invalid token")
+
+// Token is a decoded session token
+type Token struct {
+	ID     mkey.ID
+	Random [RandomSize]byte
+	Tag    [TagSize]byte
+}
+
+// Issuer creates tokens with IDs from a node
+type Issuer struct {
+	node *mkey.Node
+	key  []byte
+}
+
+// NewIssuer creates an Issuer signing with key
+func NewIssuer(node *mkey.Node, key []byte) (*Issuer, error) {
+	if len(key) < MinKeySize {
+		return nil, errors.New("key must be at least 16 bytes")
+	}
+	return &Issuer{node: node, key: append([]byte(nil), key...)}, nil
+}
+
+// Issue creates a new token and returns its string form with the embedded ID
+func (i *Issuer) Issue() (string, mkey.ID, error) {
+	t := Token{ID: i.node.Generate()}
+	if _, err := rand.Read(t.Random[:]); err != nil {
+		return "", 0, err
+	}
+	t.Tag = tag(i.key, t.ID, t.Random)
+	return t.String(), t.ID, nil
+}
+
+// Verify parses s and checks its tag, returning the embedded ID
+func (i *Issuer) Verify(s string) (mkey.ID, error) {
+	return Verify(i.key, s)
+}
+
+// Verify parses s and checks its tag against key, returning the embedded ID.
+// Services that only validate tokens can use it without an Issuer.
+func Verify(key []byte, s string) (mkey.ID, error) {
+	t, err := Parse(s)
+	if err != nil {
+		return 0, err
+	}
+	if !t.Verify(key) {
+		return 0, ErrInvalid
+	}
+	return t.ID, nil
+}
+
+// Parse decodes a token without verifying it
+func Parse(s string) (Token, error) {
+	var t Token
+	if base64.RawURLEncoding.DecodedLen(len(s)) != Size {
+		return t, ErrInvalid
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return t, ErrInvalid
+	}
+	t.ID = mkey.ID(binary.BigEndian.Uint64(b[:8]))
+	copy(t.Random[:], b[8:8+RandomSize])
+	copy(t.Tag[:], b[8+RandomSize:])
+	return t, nil
+}
+
+// Verify reports whether the token's tag is valid for key, in constant time
+func (t Token) Verify(key []byte) bool {
+	want := tag(key, t.ID, t.Random)
+	return hmac.Equal(want[:], t.Tag[:])
+}
+
+// String returns the URL-safe base64 form of the token
+func (t Token) String() string {
+	var b [Size]byte
+	binary.BigEndian.PutUint64(b[:8], uint64(t.ID))
+	copy(b[8:], t.Random[:])
+	copy(b[8+RandomSize:], t.Tag[:])
+	return base64.RawURLEncoding.EncodeToString(b[:])
+}
+
+func tag(key []byte, id mkey.ID, random [RandomSize]byte) [TagSize]byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(id.Bytes())
+	h.Write(random[:])
+
+	var t [TagSize]byte
+	copy(t[:], h.Sum(nil))
+	return t
+}
@@ -0,0 +1,86 @@
+package token
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/icehuntmen/mkey"
+)
+
+var testKey = bytes.Repeat([]byte{0x42}, MinKeySize)
+
+func newIssuer(t *testing.T) *Issuer {
+	t.Helper()
+	n, err := mkey.NewNode(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i, err := NewIssuer(n, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return i
+}
+
+func TestIssueVerify(t *testing.T) {
+	i := newIssuer(t)
+	s, id, err := i.Issue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := i.Verify(s); err != nil || got != id {
+		t.Fatalf("Verify = %v, %v; want %v", got, err, id)
+	}
+	if got, err := Verify(testKey, s); err != nil || got != id {
+		t.Fatalf("package Verify = %v, %v; want %v", got, err, id)
+	}
+	if _, err := Verify(bytes.Repeat([]byte{1}, MinKeySize), s); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("Verify with another key: err = %v", err)
+	}
+
+	s2, id2, _ := i.Issue()
+	if s2 == s || id2 <= id {
+		t.Fatalf("second token %q (%v) does not follow %q (%v)", s2, id2, s, id)
+	}
+}
+
+func TestTampering(t *testing.T) {
+	i := newIssuer(t)
+	s, _, err := i.Issue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, err := Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, mut := range map[string]func(*Token){
+		"id":     func(t *Token) { t.ID++ },
+		"random": func(t *Token) { t.Random[0] ^= 1 },
+		"tag":    func(t *Token) { t.Tag[TagSize-1] ^= 1 },
+	} {
+		bad := tok
+		mut(&bad)
+		if _, err := i.Verify(bad.String()); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s changed: err = %v", name, err)
+		}
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+	i := newIssuer(t)
+	s, _, _ := i.Issue()
+	for _, bad := range []string{"", s[:len(s)-1], s + "A", "*" + s[1:]} {
+		if _, err := Parse(bad); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Parse(%q): err = %v", bad, err)
+		}
+	}
+}
+
+func TestNewIssuerShortKey(t *testing.T) {
+	n, _ := mkey.NewNode(3)
+	if _, err := NewIssuer(n, testKey[:MinKeySize-1]); err == nil {
+		t.Fatal("short key accepted")
+	}
+}
@@ -0,0 +1,44 @@
+package mkey
+
+import "errors"
+
+// ErrNoTombstoneBit is returned when a layout without TombstoneBit is asked
+// for tombstone IDs
+var ErrNoTombstoneBit = errors.New("layout has no tombstone bit")
+
+func (l Layout) tombstoneBits() uint8 {
+	if l.TombstoneBit {
+		return 1
+	}
+	return 0
+}
+
+// tombstoneMask returns the tombstone bit in position, 0 if the layout has none
+func (l Layout) tombstoneMask() int64 {
+	if !l.TombstoneBit {
+		return 0
+	}
+	return 1 << (l.ExpiryBits + l.NodeBits + l.StepBits)
+}
+
+// IsTombstone reports whether the ID is a tombstone. Nodes never issue
+// tombstones; they are only produced by Tombstone.
+func (f ID) IsTombstone(l Layout) bool {
+	return int64(f)&l.tombstoneMask() != 0
+}
+
+// Tombstone returns the tombstone paired with the ID. It keeps the timestamp,
+// node and step, so event-sourced systems can key a deletion off the original
+// ID and the pair sorts together.
+func (f ID) Tombstone(l Layout) (ID, error) {
+	if !l.TombstoneBit {
+		return 0, ErrNoTombstoneBit
+	}
+	return ID(int64(f) | l.tombstoneMask()), nil
+}
+
+// Original returns the live ID a tombstone was derived from, or the ID
+// itself if it is not a tombstone
+func (f ID) Original(l Layout) ID {
+	return ID(int64(f) &^ l.tombstoneMask())
+}
@@ -0,0 +1,62 @@
+package mkey
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTombstone(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	cfg := NewConfig()
+	cfg.Node = 5
+	cfg.Clock = clock
+	cfg.StepBits = 11
+	cfg.TombstoneBit = true
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := n.Layout()
+
+	var ids []ID
+	for range 100 {
+		ids = append(ids, n.Generate())
+	}
+	clock.Advance(time.Millisecond)
+	later := n.Generate()
+
+	for _, id := range ids {
+		if id.IsTombstone(l) {
+			t.Fatalf("node issued tombstone %v", id)
+		}
+		ts, err := id.Tombstone(l)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ts.IsTombstone(l) || ts.Original(l) != id || id.Original(l) != id {
+			t.Fatalf("tombstone %v of %v does not pair back", ts, id)
+		}
+		if l.Time(ts) != l.Time(id) || l.NodeID(ts) != l.NodeID(id) || l.Step(ts) != l.Step(id) {
+			t.Fatalf("tombstone %v changed the fields of %v", ts, id)
+		}
+		// the tombstone sorts after its millisecond and before the next one
+		if ts <= ids[len(ids)-1] || ts >= later {
+			t.Fatalf("tombstone %v does not sort between %v and %v", ts, ids[len(ids)-1], later)
+		}
+		if again, _ := ts.Tombstone(l); again != ts {
+			t.Fatal("Tombstone is not idempotent")
+		}
+	}
+}
+
+func TestTombstoneNoBit(t *testing.T) {
+	l := DefaultLayout()
+	id := ID(1 << 40)
+	if _, err := id.Tombstone(l); !errors.Is(err, ErrNoTombstoneBit) {
+		t.Fatalf("err = %v, want ErrNoTombstoneBit", err)
+	}
+	if id.IsTombstone(l) || id.Original(l) != id {
+		t.Fatal("layout without the bit reports tombstones")
+	}
+}
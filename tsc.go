@@ -0,0 +1,94 @@
+package mkey
+
+import (
+	"math/bits"
+	"time"
+)
+
+// DefaultTSCCalibration is how long NewTSCClock measures the TSC frequency
+// against the system clock when no duration is given
+const DefaultTSCCalibration = 20 * time.Millisecond
+
+// TSCClock is a Clock that reads the CPU's time stamp counter instead of
+// asking the operating system, for the lowest generation latency. The
+// counter's frequency is calibrated against the system clock once at
+// creation and its readings are anchored to the wall clock then, so like the
+// monotonic clock it ignores later wall-clock steps; a calibration error of
+// e ppm drifts by e microseconds per second. Where the counter is not
+// invariant (it may change rate or stop with power states), or on other
+// architectures than amd64, the clock falls back to SystemClock.
+type TSCClock struct {
+	fallback SystemClock
+
+	// tsc is false when the clock falls back to the system clock
+	tsc bool
+
+	// ns = (ticks * mult) >> 32 converts ticks since baseTicks
+	baseWall  time.Time
+	baseTicks uint64
+	mult      uint64
+}
+
+// NewTSCClock returns a TSCClock calibrated over calibration, or
+// DefaultTSCCalibration if it is not positive. Longer calibration is more
+// accurate; creation blocks for that long unless the clock falls back.
+func NewTSCClock(calibration time.Duration) *TSCClock {
+	if !invariantTSC() {
+		return &TSCClock{}
+	}
+	if calibration <= 0 {
+		calibration = DefaultTSCCalibration
+	}
+
+	startWall, startTicks := time.Now(), rdtsc()
+	time.Sleep(calibration)
+	endWall, endTicks := time.Now(), rdtsc()
+
+	ticks := endTicks - startTicks
+	elapsed := endWall.Sub(startWall)
+	if ticks == 0 || elapsed <= 0 {
+		return &TSCClock{}
+	}
+	return &TSCClock{
+		tsc:       true,
+		baseWall:  endWall,
+		baseTicks: endTicks,
+		mult:      uint64(float64(elapsed) / float64(ticks) * (1 << 32)),
+	}
+}
+
+// TSC reports whether the clock reads the time stamp counter, false if it
+// fell back to the system clock
+func (c *TSCClock) TSC() bool {
+	return c.tsc
+}
+
+// Frequency returns the calibrated counter frequency in Hz, 0 on fallback
+func (c *TSCClock) Frequency() float64 {
+	if !c.tsc {
+		return 0
+	}
+	return float64(time.Second) * (1 << 32) / float64(c.mult)
+}
+
+// offset returns the time elapsed since baseWall according to the counter
+func (c *TSCClock) offset() time.Duration {
+	hi, lo := bits.Mul64(rdtsc()-c.baseTicks, c.mult)
+	return time.Duration(hi<<32 | lo>>32)
+}
+
+// Now implements Clock
+func (c *TSCClock) Now() time.Time {
+	if !c.tsc {
+		return c.fallback.Now()
+	}
+	return c.baseWall.Add(c.offset())
+}
+
+// Since implements Clock
+func (c *TSCClock) Since(t time.Time) time.Duration {
+	if !c.tsc {
+		return c.fallback.Since(t)
+	}
+	return c.baseWall.Sub(t) + c.offset()
+}
@@ -0,0 +1,17 @@
+package mkey
+
+// rdtsc returns the time stamp counter
+func rdtsc() uint64
+
+// cpuid executes the CPUID instruction for leaf and subleaf
+func cpuid(leaf, subleaf uint32) (eax, ebx, ecx, edx uint32)
+
+// invariantTSC reports whether the time stamp counter runs at a constant
+// rate in all power states, CPUID leaf 0x80000007 EDX bit 8
+func invariantTSC() bool {
+	if maxExt, _, _, _ := cpuid(0x80000000, 0); maxExt < 0x80000007 {
+		return false
+	}
+	_, _, _, edx := cpuid(0x80000007, 0)
+	return edx&(1<<8) != 0
+}
@@ -0,0 +1,12 @@
+//go:build !amd64
+
+package mkey
+
+// rdtsc is only implemented on amd64; elsewhere TSCClock falls back
+func rdtsc() uint64 {
+	return 0
+}
+
+func invariantTSC() bool {
+	return false
+}
@@ -0,0 +1,63 @@
+package mkey
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTSCClock(t *testing.T) {
+	c := NewTSCClock(0)
+	if c.TSC() != (c.Frequency() > 0) {
+		t.Fatalf("TSC() = %v with frequency %v", c.TSC(), c.Frequency())
+	}
+	t.Logf("TSC %v, frequency %.0f Hz", c.TSC(), c.Frequency())
+
+	const tolerance = 10 * time.Millisecond
+	start := time.Now()
+	prev := c.Now()
+	for time.Since(start) < 50*time.Millisecond {
+		// bracket the reading so a preemption between the two clocks is not
+		// counted as drift
+		before := time.Now()
+		now := c.Now()
+		after := time.Now()
+		if now.Before(prev) {
+			t.Fatalf("Now went back from %s to %s", prev, now)
+		}
+		if now.Before(before.Add(-tolerance)) || now.After(after.Add(tolerance)) {
+			t.Fatalf("Now is %s off the system clock", now.Sub(before))
+		}
+		prev = now
+	}
+	lo := time.Since(start)
+	d := c.Since(start)
+	hi := time.Since(start)
+	if d < lo-tolerance || d > hi+tolerance {
+		t.Fatalf("Since is %s, want between %s and %s", d, lo, hi)
+	}
+}
+
+func TestTSCClockFallback(t *testing.T) {
+	var c TSCClock
+	if c.TSC() || c.Frequency() != 0 {
+		t.Fatal("zero TSCClock claims to read the counter")
+	}
+	before := time.Now()
+	if now := c.Now(); now.Before(before) || now.After(time.Now()) {
+		t.Fatalf("fallback Now %s is not the system clock", now)
+	}
+}
+
+func TestTSCClockNode(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Clock = NewTSCClock(5 * time.Millisecond)
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := time.Now().UnixMilli()
+	ts := n.Layout().Time(n.Generate())
+	if ts < before-10 || ts > time.Now().UnixMilli()+10 {
+		t.Fatalf("ID time %d, want about %d", ts, before)
+	}
+}
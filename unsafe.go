@@ -0,0 +1,78 @@
+package mkey
+
+import (
+	"context"
+	"errors"
+)
+
+// UnsafeNode is a generator without any locking, for single-goroutine batch
+// jobs where the synchronization of Node is pure overhead. It must only be
+// used from one goroutine at a time; concurrent calls issue duplicate IDs.
+//
+// It supports the layout, clock and waiting options of Config. Maintenance
+// windows, fault injection, latency histograms and DetectClockBack are
+// rejected, IDs are not recorded for provenance or the high watermark, and
+// the per-second counters are not maintained.
+type UnsafeNode struct {
+	n         *Node
+	generated uint64
+}
+
+// NewUnsafeNode creates an UnsafeNode with the given configuration
+func NewUnsafeNode(cfg *Config) (*UnsafeNode, error) {
+	switch {
+	case len(cfg.Maintenance) > 0:
+		return nil, errors.New("UnsafeNode does not support maintenance windows")
+	case cfg.Faults != nil:
+		return nil, errors.New("UnsafeNode does not support fault injection")
+	case cfg.LatencyHistogram:
+		return nil, errors.New("UnsafeNode does not support LatencyHistogram")
+	case cfg.DetectClockBack:
+		return nil, errors.New("UnsafeNode does not support DetectClockBack")
+	case cfg.RateLimit > 0:
+		return nil, errors.New("UnsafeNode does not support RateLimit")
+	case cfg.ReservedSteps > 0:
+		return nil, errors.New("UnsafeNode does not support ReservedSteps")
+	case cfg.Hook != nil:
+		return nil, errors.New("UnsafeNode does not support Hook")
+	}
+
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &UnsafeNode{n: n}, nil
+}
+
+// Generate creates and returns a unique snowflake ID
+func (u *UnsafeNode) Generate() ID {
+	n := u.n
+	now := n.slew(n.now())
+
+	if now == n.time {
+		n.step = (n.step + 1) & n.stepMask
+		if n.step == 0 {
+			// Without a context the wait cannot fail
+			now, _ = n.waitNextMilli(context.Background())
+		}
+	} else {
+		n.step = 0
+	}
+
+	n.time = now
+	u.generated++
+	return ID(now<<n.timeShift | n.node<<n.nodeShift | n.stepValue(now, n.step))
+}
+
+// Layout returns the layout used by the node
+func (u *UnsafeNode) Layout() Layout {
+	return u.n.layout
+}
+
+// Stats returns a snapshot of the node's counters. Like Generate it must not
+// be called concurrently with other methods.
+func (u *UnsafeNode) Stats() Stats {
+	s := u.n.Stats()
+	s.Generated = u.generated
+	return s
+}
@@ -0,0 +1,90 @@
+package mkey
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUnsafeNodeMatchesNode(t *testing.T) {
+	start := time.Now()
+	newConfig := func() *Config {
+		cfg := NewConfig()
+		cfg.Node = 7
+		cfg.Clock = NewManualClock(start)
+		return cfg
+	}
+	n, err := NewNodeWithConfig(newConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := NewUnsafeNode(newConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Layout() != n.Layout() {
+		t.Fatalf("Layout = %+v, want %+v", u.Layout(), n.Layout())
+	}
+	for i := range 1000 {
+		if got, want := u.Generate(), n.Generate(); got != want {
+			t.Fatalf("ID %d = %d, want %d as issued by Node", i, got, want)
+		}
+	}
+}
+
+func TestUnsafeNodeExhaustion(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Node = 1
+	cfg.StepBits = 2
+	u, err := NewUnsafeNode(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := u.Layout()
+	var prev ID
+	perMilli := map[int64]int{}
+	for i := range 40 {
+		id := u.Generate()
+		if id <= prev {
+			t.Fatalf("ID %d = %d, not above %d", i, id, prev)
+		}
+		perMilli[l.Time(id)]++
+		prev = id
+	}
+	for ms, count := range perMilli {
+		if count > 4 {
+			t.Fatalf("%d IDs in millisecond %d, want at most 4", count, ms)
+		}
+	}
+	s := u.Stats()
+	if s.Generated != 40 {
+		t.Fatalf("Generated = %d, want 40", s.Generated)
+	}
+	if s.Waits == 0 {
+		t.Fatal("Waits = 0 after exhausting the step space")
+	}
+}
+
+func TestNewUnsafeNodeRejects(t *testing.T) {
+	for name, set := range map[string]func(*Config){
+		"Maintenance": func(c *Config) {
+			c.Maintenance = []MaintenanceWindow{{Start: time.Now(), End: time.Now().Add(time.Hour)}}
+		},
+		"Faults":           func(c *Config) { c.Faults = &FaultInjector{} },
+		"LatencyHistogram": func(c *Config) { c.LatencyHistogram = true },
+		"DetectClockBack":  func(c *Config) { c.DetectClockBack = true },
+		"RateLimit":        func(c *Config) { c.RateLimit = 100 },
+		"ReservedSteps":    func(c *Config) { c.ReservedSteps = 1 },
+	} {
+		cfg := NewConfig()
+		set(cfg)
+		if _, err := NewUnsafeNode(cfg); err == nil {
+			t.Errorf("NewUnsafeNode accepted %s", name)
+		}
+	}
+
+	cfg := NewConfig()
+	cfg.Node = -1
+	if _, err := NewUnsafeNode(cfg); err == nil {
+		t.Error("NewUnsafeNode accepted an invalid node")
+	}
+}
@@ -0,0 +1,56 @@
+package mkey
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrVanityTimeout is returned when FindVanity finds no match in time
+var ErrVanityTimeout = errors.New("no vanity ID found before timeout")
+
+// vanityShare is the fraction of a millisecond's step space FindVanity may use
+const vanityShare = 4
+
+// FindVanity generates IDs on node until predicate accepts the Base58 form of
+// one, or timeout passes. It is meant for marketing-friendly IDs in
+// non-critical paths: every candidate consumes a real ID, so it uses at most a
+// quarter of the steps each millisecond can issue to regular callers and then
+// waits for the node's clock to reach the next millisecond, leaving room for
+// regular callers on the same node.
+func FindVanity(node *Node, predicate func(string) bool, timeout time.Duration) (ID, error) {
+	deadline := time.Now().Add(timeout)
+	l := node.Layout()
+
+	// node.stepLimit already excludes child steps and ReservedSteps
+	budget := max((node.stepLimit+1)/vanityShare, 1)
+
+	var ms, used int64
+	for time.Now().Before(deadline) {
+		id := node.Generate()
+		if predicate(id.Base58()) {
+			return id, nil
+		}
+
+		if t := l.Time(id) - l.Epoch; t != ms {
+			ms, used = t, 0
+		}
+		used++
+		if used >= budget {
+			waitMilli(node, ms+1, deadline)
+		}
+	}
+	return 0, ErrVanityTimeout
+}
+
+// waitMilli sleeps until node's clock reaches millisecond ms since its epoch
+// or deadline passes. It rechecks at least every millisecond, as the clock
+// may be a ManualClock or lag the wall clock by Config.Jitter.
+func waitMilli(node *Node, ms int64, deadline time.Time) {
+	for time.Now().Before(deadline) {
+		d := time.Duration(ms)*time.Millisecond - node.elapsed()
+		if d <= 0 {
+			return
+		}
+		time.Sleep(min(d, time.Millisecond, time.Until(deadline)))
+	}
+}
@@ -0,0 +1,90 @@
+package mkey
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFindVanity(t *testing.T) {
+	n, err := NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := FindVanity(n, func(s string) bool { return strings.HasSuffix(s, "z") }, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(id.Base58(), "z") {
+		t.Fatalf("FindVanity returned %s", id.Base58())
+	}
+}
+
+func TestFindVanityBudget(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Node = 1
+	cfg.StepBits = 4
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := n.Layout()
+
+	perMs := make(map[int64]int64)
+	start := time.Now()
+	_, err = FindVanity(n, func(s string) bool {
+		id, err := ParseBase58([]byte(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		perMs[l.Time(id)]++
+		return false
+	}, 20*time.Millisecond)
+	if !errors.Is(err, ErrVanityTimeout) {
+		t.Fatalf("err = %v, want ErrVanityTimeout", err)
+	}
+	if time.Since(start) < 20*time.Millisecond {
+		t.Fatal("FindVanity gave up before the timeout")
+	}
+
+	budget := (l.StepMask() + 1) / vanityShare
+	for ms, c := range perMs {
+		if c > budget {
+			t.Fatalf("%d candidates in millisecond %d, budget %d", c, ms, budget)
+		}
+	}
+
+}
+
+func TestFindVanityOwnClock(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Node = 1
+	cfg.StepBits = 4
+	cfg.ReservedSteps = 8
+	cfg.Clock = NewManualClock(time.Now())
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 8 steps are left to regular callers, so the budget is 2 of them; on
+	// a stopped clock FindVanity must wait for the next millisecond rather
+	// than spin into steps the normal lane cannot issue
+	done := make(chan error, 1)
+	go func() {
+		_, err := FindVanity(n, func(string) bool { return false }, 20*time.Millisecond)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrVanityTimeout) {
+			t.Fatalf("err = %v, want ErrVanityTimeout", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("FindVanity did not stop at its timeout")
+	}
+	if got := n.Stats().Generated; got != 2 {
+		t.Fatalf("FindVanity used %d IDs of a stopped millisecond, want 2", got)
+	}
+}
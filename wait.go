@@ -0,0 +1,80 @@
+package mkey
+
+import (
+	"runtime"
+	"time"
+)
+
+// WaitStrategy controls how a node waits for the next millisecond once the
+// step space of the current one is exhausted
+type WaitStrategy uint8
+
+const (
+	// WaitSpin polls the clock in a tight loop. It resumes soonest but burns
+	// a full core for as long as the node is saturated.
+	WaitSpin WaitStrategy = iota
+
+	// WaitSleep sleeps until the next millisecond is due. A context passed to
+	// GenerateContext is only checked between sleeps.
+	WaitSleep
+
+	// WaitTimer blocks on a timer for the next millisecond and returns as
+	// soon as the context passed to GenerateContext is done
+	WaitTimer
+
+	// WaitAdaptive spins during the last adaptiveSpin before the next
+	// millisecond, yields the processor while it is close and blocks on a
+	// timer otherwise, trading a little latency for most of the CPU
+	WaitAdaptive
+)
+
+const (
+	// adaptiveSpin is how close to the next millisecond WaitAdaptive spins
+	adaptiveSpin = 20 * time.Microsecond
+
+	// adaptiveYield is how close to the next millisecond WaitAdaptive yields
+	// rather than arming a timer, whose wakeup latency is coarser
+	adaptiveYield = 200 * time.Microsecond
+)
+
+// untilNextMilli returns how long until the clock passes the millisecond
+// n.time, the last one issued. Callers hold n.mu.
+func (n *Node) untilNextMilli() time.Duration {
+	return time.Duration(n.time+1)*time.Millisecond - n.elapsed()
+}
+
+// pause waits part or all of the way to the next millisecond according to
+// the node's wait strategy, at most one millisecond at a time. It returns
+// early when done is closed; the caller rechecks the clock and the context
+// either way. Callers hold n.mu.
+func (n *Node) pause(done <-chan struct{}) {
+	d := min(n.untilNextMilli(), time.Millisecond)
+	switch n.waitStrategy {
+	case WaitSleep:
+		if d > 0 {
+			time.Sleep(d)
+		}
+	case WaitTimer:
+		n.pauseTimer(done, d)
+	case WaitAdaptive:
+		switch {
+		case d <= adaptiveSpin:
+		case d <= adaptiveYield:
+			runtime.Gosched()
+		default:
+			n.pauseTimer(done, d-adaptiveSpin)
+		}
+	}
+}
+
+func (n *Node) pauseTimer(done <-chan struct{}, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-done:
+	}
+}
@@ -0,0 +1,80 @@
+package mkey
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWaitStrategies(t *testing.T) {
+	for _, s := range []WaitStrategy{WaitSpin, WaitSleep, WaitTimer, WaitAdaptive} {
+		cfg := NewConfig()
+		cfg.Node = 1
+		cfg.StepBits = 2
+		cfg.WaitStrategy = s
+		n, err := NewNodeWithConfig(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		l := n.Layout()
+
+		// four IDs per millisecond: 20 IDs span at least five milliseconds
+		first := n.Generate()
+		prev := first
+		for range 19 {
+			id := n.Generate()
+			if id <= prev {
+				t.Fatalf("strategy %d: %v does not follow %v", s, id, prev)
+			}
+			prev = id
+		}
+		if span := l.Time(prev) - l.Time(first); span < 4 {
+			t.Fatalf("strategy %d: 20 IDs within %dms", s, span)
+		}
+		if n.Stats().Waits == 0 {
+			t.Fatalf("strategy %d: no waits recorded", s)
+		}
+	}
+
+	cfg := NewConfig()
+	cfg.WaitStrategy = WaitAdaptive + 1
+	if _, err := NewNodeWithConfig(cfg); err == nil {
+		t.Fatal("unknown wait strategy accepted")
+	}
+}
+
+func TestPauseHonorsDone(t *testing.T) {
+	closed := make(chan struct{})
+	close(closed)
+
+	for _, tt := range []struct {
+		s     WaitStrategy
+		early bool
+	}{
+		{WaitSleep, false},
+		{WaitTimer, true},
+		{WaitAdaptive, true},
+	} {
+		clock := NewManualClock(time.Now())
+		cfg := NewConfig()
+		cfg.Node = 1
+		cfg.Clock = clock
+		cfg.WaitStrategy = tt.s
+		n, err := NewNodeWithConfig(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// a stopped clock a full millisecond before the next one is due
+		n.time = n.elapsed().Milliseconds()
+		clock.Set(time.UnixMilli(n.layout.Epoch + n.time))
+
+		start := time.Now()
+		n.pause(closed)
+		took := time.Since(start)
+		if tt.early && took > 500*time.Microsecond {
+			t.Errorf("strategy %d ignored done and paused %v", tt.s, took)
+		}
+		if !tt.early && took < 900*time.Microsecond {
+			t.Errorf("strategy %d paused only %v", tt.s, took)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package mkey
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WatchdogPolicy selects what a Watchdog does when generation is stuck
+type WatchdogPolicy uint8
+
+const (
+	// WatchdogNotify calls WatchdogConfig.OnStuck and counts the trip
+	WatchdogNotify WatchdogPolicy = iota
+
+	// WatchdogPanic panics in the watchdog goroutine after calling OnStuck,
+	// crashing the process so a supervisor restarts it
+	WatchdogPanic
+)
+
+// StuckEvent describes a generation call blocked beyond the threshold
+type StuckEvent struct {
+	// Node is the node ID of the stuck generator
+	Node int64
+
+	// Since is when the call started waiting for the next millisecond
+	Since time.Time
+
+	// Blocked is how long it had been waiting when detected
+	Blocked time.Duration
+}
+
+func (e StuckEvent) String() string {
+	return fmt.Sprintf("mkey: node %d stuck waiting for the next millisecond for %s", e.Node, e.Blocked)
+}
+
+// WatchdogConfig configures a Watchdog
+type WatchdogConfig struct {
+	// Threshold is how long a call may wait for the next millisecond before
+	// it counts as stuck. A healthy node never waits more than about 1ms.
+	Threshold time.Duration
+
+	// Interval is how often the node is checked, default Threshold/2
+	Interval time.Duration
+
+	Policy WatchdogPolicy
+
+	// OnStuck, if set, is called once per stuck episode from the watchdog goroutine
+	OnStuck func(StuckEvent)
+}
+
+// Watchdog detects generation calls stuck behind a clock that does not
+// advance, so hung ID issuance surfaces as an event rather than as latency
+type Watchdog struct {
+	node  *Node
+	cfg   WatchdogConfig
+	trips atomic.Uint64
+
+	stop chan struct{}
+	once sync.Once
+	done chan struct{}
+}
+
+// NewWatchdog starts watching node; call Stop to release it
+func NewWatchdog(node *Node, cfg WatchdogConfig) (*Watchdog, error) {
+	if node == nil {
+		return nil, errors.New("node must not be nil")
+	}
+	if cfg.Threshold <= 0 {
+		return nil, errors.New("Threshold must be positive")
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = cfg.Threshold / 2
+	}
+
+	w := &Watchdog{
+		node: node,
+		cfg:  cfg,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go w.run()
+	return w, nil
+}
+
+func (w *Watchdog) run() {
+	defer close(w.done)
+
+	t := time.NewTicker(w.cfg.Interval)
+	defer t.Stop()
+
+	// reported is the waitSince of the last episode reported, so each stuck
+	// call trips only once
+	var reported int64
+	for {
+		select {
+		case <-w.stop:
+			return
+		case now := <-t.C:
+			since := w.node.waitSince.Load()
+			if since == 0 || since == reported {
+				continue
+			}
+			blocked := now.Sub(time.Unix(0, since))
+			if blocked < w.cfg.Threshold {
+				continue
+			}
+			reported = since
+			w.trip(StuckEvent{Node: w.node.node, Since: time.Unix(0, since), Blocked: blocked})
+		}
+	}
+}
+
+func (w *Watchdog) trip(e StuckEvent) {
+	w.trips.Add(1)
+	if w.cfg.OnStuck != nil {
+		w.cfg.OnStuck(e)
+	}
+	if w.cfg.Policy == WatchdogPanic {
+		panic(e.String())
+	}
+}
+
+// Trips returns the number of stuck episodes detected so far
+func (w *Watchdog) Trips() uint64 {
+	return w.trips.Load()
+}
+
+// Stop stops the watchdog and waits for its goroutine to exit
+func (w *Watchdog) Stop() {
+	w.once.Do(func() { close(w.stop) })
+	<-w.done
+}
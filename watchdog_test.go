@@ -0,0 +1,84 @@
+package mkey
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWatchdog(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	cfg := NewConfig()
+	cfg.Node = 3
+	cfg.StepBits = 1
+	cfg.Clock = clock
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := make(chan StuckEvent, 4)
+	w, err := NewWatchdog(n, WatchdogConfig{
+		Threshold: 20 * time.Millisecond,
+		Interval:  5 * time.Millisecond,
+		OnStuck:   func(e StuckEvent) { events <- e },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	// a stopped clock strands the third ID of the millisecond
+	n.Generate()
+	n.Generate()
+	start := time.Now()
+	done := make(chan ID)
+	go func() { done <- n.Generate() }()
+
+	var e StuckEvent
+	select {
+	case e = <-events:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watchdog did not report the stuck call")
+	}
+	if e.Node != 3 || e.Blocked < 20*time.Millisecond || e.Since.Before(start.Add(-time.Millisecond)) {
+		t.Fatalf("StuckEvent = %+v", e)
+	}
+
+	clock.Advance(time.Millisecond)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Generate did not resume after the clock advanced")
+	}
+
+	// one trip per episode, however long it lasted
+	time.Sleep(30 * time.Millisecond)
+	if got := w.Trips(); got != 1 {
+		t.Fatalf("Trips = %d, want 1", got)
+	}
+	select {
+	case e := <-events:
+		t.Fatalf("extra event %+v", e)
+	default:
+	}
+}
+
+func TestWatchdogConfig(t *testing.T) {
+	n, _ := NewNode(1)
+	if _, err := NewWatchdog(nil, WatchdogConfig{Threshold: time.Second}); err == nil {
+		t.Error("nil node accepted")
+	}
+	if _, err := NewWatchdog(n, WatchdogConfig{}); err == nil {
+		t.Error("zero Threshold accepted")
+	}
+
+	w, err := NewWatchdog(n, WatchdogConfig{Threshold: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Stop()
+	w.Stop()
+	if w.Trips() != 0 {
+		t.Fatal("healthy node tripped the watchdog")
+	}
+}
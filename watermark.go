@@ -0,0 +1,84 @@
+package mkey
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// HighWatermark returns the largest ID of the millisecond before the newest
+// one the node has issued in, 0 if there is none. Every ID at or below it
+// that this node will ever issue has been issued, which gives replication
+// consumers "IDs <= X are complete" semantics; a consumer still has to wait
+// for the writes carrying those IDs to land. The newest millisecond is left
+// out because IDs issued later in it may sort below ones already issued
+// (expiry or shard fields, ScrambleSteps, RandomStepStart), so the watermark
+// trails issuance by up to a millisecond and stays put while the node is
+// idle. IDs with priority bits set always sort above it. A clock that moves
+// back breaks the guarantee unless SlewLimit or DetectClockBack absorbs it.
+// It does not lock the node.
+func (n *Node) HighWatermark() ID {
+	t := n.highTime.Load() - 1
+	if t <= 0 {
+		return 0
+	}
+	return ID(t<<n.timeShift - 1)
+}
+
+// raiseWatermark records id as issued
+func (n *Node) raiseWatermark(id ID) {
+	raiseMax(&n.high, int64(id))
+	raiseMax(&n.highTime, int64(id)>>n.timeShift&n.layout.TimeMask()+1)
+}
+
+// raiseMax sets v to x if x is larger
+func raiseMax(v *atomic.Int64, x int64) {
+	for {
+		cur := v.Load()
+		if x <= cur || v.CompareAndSwap(cur, x) {
+			return
+		}
+	}
+}
+
+// WatchWatermark returns a channel receiving the high watermark every
+// interval while it changes, until ctx is done, when the channel is closed.
+// A slow receiver skips intermediate values and gets the latest one.
+func (n *Node) WatchWatermark(ctx context.Context, interval time.Duration) <-chan ID {
+	ch := make(chan ID, 1)
+	go func() {
+		defer close(ch)
+
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		var last ID
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+
+			hw := n.HighWatermark()
+			if hw == last {
+				continue
+			}
+			select {
+			case ch <- hw:
+				last = hw
+			case <-ctx.Done():
+				return
+			default:
+				// The receiver has not taken the previous value; replace it
+				select {
+				case <-ch:
+				default:
+				}
+				ch <- hw
+				last = hw
+			}
+		}
+	}()
+	return ch
+}
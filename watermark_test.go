@@ -0,0 +1,101 @@
+package mkey
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHighWatermarkComplete(t *testing.T) {
+	for name, opt := range barrierConfigs {
+		t.Run(name, func(t *testing.T) {
+			cfg := NewConfig()
+			cfg.Node = 3
+			opt(cfg)
+			n, err := NewNodeWithConfig(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hw := n.HighWatermark(); hw != 0 {
+				t.Fatalf("fresh node watermark = %d, want 0", hw)
+			}
+
+			var prev ID
+			for i := range 50 {
+				hw := n.HighWatermark()
+				if hw < prev {
+					t.Fatalf("round %d: watermark moved back from %d to %d", i, prev, hw)
+				}
+				prev = hw
+				if id := issueMixed(t, n, cfg); id <= hw {
+					t.Fatalf("round %d: issued %d <= watermark %d", i, id, hw)
+				}
+				plain := n.Generate()
+				if plain <= hw {
+					t.Fatalf("round %d: issued %d <= watermark %d", i, plain, hw)
+				}
+
+				// Once a later millisecond has been issued in, everything
+				// issued before it is covered
+				time.Sleep(2 * time.Millisecond)
+				n.Generate()
+				if hw := n.HighWatermark(); hw < plain {
+					t.Fatalf("round %d: watermark %d below earlier ID %d", i, hw, plain)
+				}
+			}
+		})
+	}
+}
+
+func TestHighWatermarkTrailsNewestMillisecond(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	cfg := NewConfig()
+	cfg.Clock = clock
+	cfg.ScrambleSteps = true
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := n.Generate()
+	if hw := n.HighWatermark(); hw >= first {
+		t.Fatalf("watermark %d covers the open millisecond of %d", hw, first)
+	}
+	clock.Advance(time.Millisecond)
+	n.Generate()
+	hw := n.HighWatermark()
+	if hw < first {
+		t.Fatalf("watermark %d below %d from a closed millisecond", hw, first)
+	}
+	if want := ID((n.layout.Time(first)-n.layout.Epoch+1)<<n.timeShift - 1); hw != want {
+		t.Fatalf("watermark = %d, want %d", hw, want)
+	}
+}
+
+func TestWatchWatermark(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	cfg := NewConfig()
+	cfg.Clock = clock
+	n, err := NewNodeWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := n.WatchWatermark(ctx, time.Millisecond)
+
+	n.Generate()
+	clock.Advance(time.Millisecond)
+	n.Generate()
+	select {
+	case hw := <-ch:
+		if hw != n.HighWatermark() {
+			t.Fatalf("watched %d, want %d", hw, n.HighWatermark())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no watermark received")
+	}
+
+	cancel()
+	for range ch {
+	}
+}
@@ -0,0 +1,81 @@
+package mkey
+
+import (
+	"errors"
+	"hash/fnv"
+	"strings"
+)
+
+// WorkflowIDs formats workflow and run IDs for engines such as Temporal or
+// Cadence as <Prefix><Separator><ID>, with the ID in fixed-width Base62 so
+// workflow IDs sort by creation time
+type WorkflowIDs struct {
+	// Prefix names the workflow type, e.g. "order"
+	Prefix string
+
+	// Separator joins prefix and ID (default "-")
+	Separator string
+
+	// Layout is used to keep the parent's timestamp in derived child IDs
+	// (default DefaultLayout())
+	Layout Layout
+}
+
+func (w WorkflowIDs) sep() string {
+	if w.Separator == "" {
+		return "-"
+	}
+	return w.Separator
+}
+
+func (w WorkflowIDs) layout() Layout {
+	if w.Layout == (Layout{}) {
+		return DefaultLayout()
+	}
+	return w.Layout
+}
+
+// New generates a workflow ID on node. Call it outside workflow code, e.g.
+// in the client starting the workflow.
+func (w WorkflowIDs) New(node *Node) string {
+	return w.Format(node.Generate())
+}
+
+// Format returns the workflow ID string for id
+func (w WorkflowIDs) Format(id ID) string {
+	return w.Prefix + w.sep() + formatBase62(uint64(id), Base62Width)
+}
+
+// Parse extracts the ID from a workflow ID string
+func (w WorkflowIDs) Parse(s string) (ID, error) {
+	rest, ok := strings.CutPrefix(s, w.Prefix+w.sep())
+	if !ok {
+		return 0, errors.New("workflow ID has the wrong prefix")
+	}
+	return ParseBase62([]byte(rest))
+}
+
+// Child deterministically derives the ID of a child workflow from its parent's
+// ID and a key naming the child (e.g. "payment" or "item-3"). It uses neither
+// the clock nor randomness, so it is safe inside workflow code and gives the
+// same result on replay. The child keeps the parent's timestamp; the bits
+// below it are a hash of parent and key, so distinct keys can collide with
+// probability about n²/2^(TimeShift+1) for n children.
+func (w WorkflowIDs) Child(parent ID, key string) ID {
+	h := fnv.New64a()
+	h.Write(parent.Bytes())
+	h.Write([]byte(key))
+
+	shift := w.layout().TimeShift()
+	low := int64(h.Sum64() & (1<<shift - 1))
+	return ID(int64(parent)&^(1<<shift-1) | low)
+}
+
+// ChildString is Child for workflow ID strings
+func (w WorkflowIDs) ChildString(parent, key string) (string, error) {
+	id, err := w.Parse(parent)
+	if err != nil {
+		return "", err
+	}
+	return w.Format(w.Child(id, key)), nil
+}
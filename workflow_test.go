@@ -0,0 +1,91 @@
+package mkey
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWorkflowIDs(t *testing.T) {
+	n, err := NewNode(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := WorkflowIDs{Prefix: "order", Layout: NewConfig().Layout()}
+
+	var ids []string
+	for range 100 {
+		ids = append(ids, w.New(n))
+	}
+	if !slices.IsSorted(ids) {
+		t.Fatal("workflow IDs do not sort by creation")
+	}
+	for _, s := range ids {
+		if !strings.HasPrefix(s, "order-") || len(s) != len("order-")+Base62Width {
+			t.Fatalf("workflow ID %q has the wrong shape", s)
+		}
+		id, err := w.Parse(s)
+		if err != nil || w.Format(id) != s {
+			t.Fatalf("Parse(%q) = %v, %v", s, id, err)
+		}
+	}
+
+	if _, err := w.Parse("refund-" + ids[0][len("order-"):]); err == nil {
+		t.Fatal("Parse accepted the wrong prefix")
+	}
+	colon := WorkflowIDs{Prefix: "order", Separator: ":"}
+	if s := colon.Format(1); !strings.HasPrefix(s, "order:") {
+		t.Fatalf("Format with separator = %q", s)
+	}
+}
+
+func TestWorkflowChild(t *testing.T) {
+	n, err := NewNode(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := NewConfig().Layout()
+	w := WorkflowIDs{Prefix: "order", Layout: l}
+	parent := n.Generate()
+
+	a, b := w.Child(parent, "payment"), w.Child(parent, "shipping")
+	if a != w.Child(parent, "payment") {
+		t.Fatal("Child is not deterministic")
+	}
+	if a == b || a == parent {
+		t.Fatalf("children %v, %v of %v are not distinct", a, b, parent)
+	}
+	if l.Time(a) != l.Time(parent) || l.Time(b) != l.Time(parent) {
+		t.Fatal("children do not keep the parent's timestamp")
+	}
+	if w.Child(n.Generate(), "payment") == a {
+		t.Fatal("same key under another parent gave the same child")
+	}
+
+	ps := w.Format(parent)
+	cs, err := w.ChildString(ps, "payment")
+	if err != nil || cs != w.Format(a) {
+		t.Fatalf("ChildString = %q, %v; want %q", cs, err, w.Format(a))
+	}
+	if _, err := w.ChildString("bad", "payment"); err == nil {
+		t.Fatal("ChildString accepted a bad parent")
+	}
+}
+
+func TestWorkflowChildDefaultLayout(t *testing.T) {
+	n, err := NewNode(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := WorkflowIDs{Prefix: "order"}
+	parent := n.Generate()
+
+	a, b := w.Child(parent, "a"), w.Child(parent, "b")
+	if a == b || a == parent || b == parent {
+		t.Fatalf("children %v, %v of %v are not distinct", a, b, parent)
+	}
+	want := WorkflowIDs{Prefix: "order", Layout: DefaultLayout()}.Child(parent, "a")
+	if a != want {
+		t.Fatalf("zero Layout child = %v, want %v as with DefaultLayout", a, want)
+	}
+}